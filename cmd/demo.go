@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"gonotes/internal/model"
+	"gonotes/internal/service"
+)
+
+// Demo account created by seedDemo
+const (
+	demoEmail    = "demo@gonotes.dev"
+	demoPassword = "demo1234"
+)
+
+// demoNotes are the sample notes created for the demo account
+var demoNotes = []struct {
+	title   string
+	content string
+	tags    []string
+	public  bool
+	status  string
+}{
+	{"Welcome to GoNotes", "This instance runs in demo mode: everything is stored in memory and disappears on restart.", []string{"welcome"}, true, "active"},
+	{"Go concurrency patterns", "Worker pools, fan-in and fan-out, and cancellation with context.Context.", []string{"go", "programming"}, true, "active"},
+	{"Shopping list", "Coffee, oat milk, bread.", []string{"personal"}, false, "active"},
+	{"Blog post ideas", "Full-text search in PostgreSQL; graceful shutdown in Go services.", []string{"writing", "ideas"}, false, "draft"},
+}
+
+// seedDemo creates the demo account and its sample notes through the services
+// so that the data passes the same validation as user input
+func seedDemo(ctx context.Context, users *service.UserService, notes *service.NoteService) error {
+	user, err := users.Register(ctx, &model.RegisterRequest{
+		Email:    demoEmail,
+		Password: demoPassword,
+		FullName: "Demo User",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create demo user: %w", err)
+	}
+
+	for _, note := range demoNotes {
+		content, status, public := note.content, note.status, note.public
+		_, err := notes.CreateNote(ctx, user.ID, &model.CreateNoteRequest{
+			Title:    note.title,
+			Content:  &content,
+			Status:   &status,
+			Tags:     note.tags,
+			IsPublic: &public,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create demo note %q: %w", note.title, err)
+		}
+	}
+
+	// Spelled out in the message: attributes named "password" are redacted
+	slog.Info(fmt.Sprintf("Demo data seeded, log in as %s / %s", demoEmail, demoPassword), "notes", len(demoNotes))
+	return nil
+}
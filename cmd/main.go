@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,9 +15,18 @@ import (
 
 	"gonotes/internal/config"
 	"gonotes/internal/handler"
+	"gonotes/internal/lifecycle"
+	"gonotes/internal/logger"
+	"gonotes/internal/metrics"
 	"gonotes/internal/middleware"
+	"gonotes/internal/migrate"
+	"gonotes/internal/queue"
+	"gonotes/internal/ratelimit"
 	"gonotes/internal/repository"
+	"gonotes/internal/scheduler"
 	"gonotes/internal/service"
+	"gonotes/internal/store"
+	"gonotes/internal/tracing"
 	"gonotes/internal/utils"
 
 	"github.com/go-chi/chi/v5"
@@ -28,52 +41,236 @@ func main() {
 		log.Fatal("Failed to load config:", err)
 	}
 
-	// Initialize database
-	db, err := utils.ConnectDB(cfg)
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	// Structured logging; the standard log package is routed through it too
+	if _, err := logger.Setup(cfg); err != nil {
+		log.Fatal("Invalid logging configuration:", err)
 	}
-	defer db.Close()
 
-	// Test database connection
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
+	// Run the migrate subcommand instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
 	}
-	log.Println("Database connected successfully")
 
-	// Initialize Redis
-	redisClient, err := utils.ConnectRedis(cfg)
+	demo := flag.Bool("demo", false, "run without PostgreSQL or Redis, using in-memory storage with sample data")
+	flag.Parse()
+
+	// Lifecycle manager runs shutdown in order: HTTP, flush, workers, store, database
+	lc := lifecycle.NewManager()
+
+	// Initialize tracing before any instrumented client is created
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
 	if err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		fatal("Failed to initialize tracing", err)
 	}
-	defer redisClient.Close()
-	log.Println("Redis connected successfully")
+	lc.OnShutdown(lifecycle.PhaseFlush, "tracing", shutdownTracing)
+
+	// Storage: PostgreSQL and Redis, or in-memory with sample data in demo mode
+	var (
+		db          *sql.DB
+		migrator    *migrate.Migrator
+		kvStore     store.Store
+		broker      queue.Broker
+		userRepo    repository.UserRepository
+		sessionRepo repository.SessionRepository
+		noteRepo    repository.NoteRepository
+		outboxRepo  repository.OutboxRepository
+		statsRepo   repository.ViewStatsRepository
+		shareRepo   repository.ShareLinkRepository
+		txManager   repository.TxManager
+	)
+
+	if *demo {
+		slog.Warn("Demo mode: data is kept in memory and lost on restart")
+		kvStore = store.NewMemoryStore()
+		broker = queue.NewMemoryBroker()
+		memoryUserRepo := repository.NewMemoryUserRepository()
+		userRepo = memoryUserRepo
+		sessionRepo = repository.NewMemorySessionRepository()
+		memoryNoteRepo := repository.NewMemoryNoteRepository()
+		memoryNoteRepo.SetUsers(memoryUserRepo)
+		noteRepo = memoryNoteRepo
+		outboxRepo = repository.NewMemoryOutboxRepository()
+		statsRepo = repository.NewMemoryViewStatsRepository(memoryNoteRepo)
+		shareRepo = repository.NewMemoryShareLinkRepository()
+		txManager = repository.NewMemoryTxManager()
+	} else {
+		// Initialize database
+		db, err = utils.ConnectDB(cfg)
+		if err != nil {
+			fatal("Failed to initialize database", err)
+		}
+		lc.OnShutdown(lifecycle.PhaseDatabase, cfg.DBDriver, func(ctx context.Context) error {
+			return db.Close()
+		})
+		slog.Info("Database connected successfully", "driver", cfg.DBDriver)
+		metrics.RegisterDB(db, cfg.DBName)
+
+		// Load embedded migrations; readiness compares the schema against them
+		migrator, err = newMigrator(cfg, db)
+		if err != nil {
+			fatal("Failed to load migrations", err)
+		}
+
+		// Apply pending migrations, serialized across replicas by an advisory lock
+		if cfg.MigrateOnStartup {
+			count, err := migrator.Up(context.Background())
+			if err != nil {
+				fatal("Failed to apply migrations", err)
+			}
+			slog.Info("Database migrations up to date", "applied", count)
+		}
+
+		// Initialize key-value store (Redis with in-memory fallback)
+		kvStore, err = utils.ConnectStore(cfg)
+		if err != nil {
+			fatal("Failed to connect to Redis", err)
+		}
+		slog.Info("Key-value store initialized", "backend", cfg.StoreBackend)
+
+		// Job queue on Redis Streams (in memory with the memory backend)
+		broker, err = utils.ConnectQueue(cfg)
+		if err != nil {
+			fatal("Failed to initialize job queue", err)
+		}
+
+		// Read replicas for public listings, search and stats; users who just
+		// wrote are pinned to the primary through the key-value store
+		replicas, err := utils.ConnectReplicas(cfg)
+		if err != nil {
+			fatal("Failed to open read replicas", err)
+		}
+		for i, replica := range replicas {
+			metrics.RegisterDB(replica, fmt.Sprintf("%s_replica%d", cfg.DBName, i))
+		}
+		lc.OnShutdown(lifecycle.PhaseDatabase, "read replicas", func(ctx context.Context) error {
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			return nil
+		})
+		router := repository.NewDBRouter(db, replicas, kvStore, cfg.DBReadYourWritesWindow, cfg.DBReplicaMaxLag)
+		lc.Go("replica health checks", func(ctx context.Context) {
+			router.Run(ctx, cfg.DBReplicaCheckInterval)
+		})
+		if len(replicas) > 0 {
+			slog.Info("Read replicas configured", "count", len(replicas))
+		}
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	sessionRepo := repository.NewSessionRepository(db)
-	noteRepo := repository.NewNoteRepository(db)
+		// Initialize repositories
+		if cfg.DBDriver == "sqlite" {
+			userRepo = repository.NewSQLiteUserRepository(db, cfg.DBQueryTimeout)
+			sessionRepo = repository.NewSQLiteSessionRepository(db, cfg.DBQueryTimeout)
+			noteRepo = repository.NewSQLiteNoteRepository(db, cfg.DBQueryTimeout)
+			outboxRepo = repository.NewSQLiteOutboxRepository(db, cfg.DBQueryTimeout)
+			statsRepo = repository.NewSQLiteViewStatsRepository(db, cfg.DBQueryTimeout)
+			shareRepo = repository.NewSQLiteShareLinkRepository(db, cfg.DBQueryTimeout)
+		} else {
+			userRepo = repository.NewPostgresUserRepository(db, cfg.DBQueryTimeout)
+			sessionRepo = repository.NewPostgresSessionRepository(db, cfg.DBQueryTimeout)
+			noteRepo = repository.NewPostgresNoteRepository(router, cfg.DBQueryTimeout)
+			outboxRepo = repository.NewPostgresOutboxRepository(db, cfg.DBQueryTimeout)
+			statsRepo = repository.NewPostgresViewStatsRepository(db, cfg.DBQueryTimeout)
+			shareRepo = repository.NewPostgresShareLinkRepository(db, cfg.DBQueryTimeout)
+		}
+		txManager = repository.NewSQLTxManager(db)
+	}
+	lc.OnShutdown(lifecycle.PhaseStore, "key-value store", func(ctx context.Context) error {
+		return kvStore.Close()
+	})
+	lc.OnShutdown(lifecycle.PhaseStore, "job queue", func(ctx context.Context) error {
+		return broker.Close()
+	})
 
 	// Initialize validator
 	validator := utils.NewValidator()
 
 	// Initialize services
-	userService := service.NewUserServiceWithRedis(userRepo, redisClient)
-	sessionService := service.NewSessionService(sessionRepo, userRepo, redisClient, cfg)
-	noteService := service.NewNoteService(noteRepo, userRepo, validator)
+	outboxService := service.NewOutboxService(outboxRepo, cfg.OutboxPollInterval)
+	userService := service.NewUserServiceWithCache(userRepo, kvStore)
+	sessionService := service.NewSessionService(sessionRepo, userRepo, kvStore, txManager, outboxService, cfg)
+	viewCounter := service.NewViewCounter(noteRepo, statsRepo, txManager, kvStore, cfg.ViewDedupWindow, cfg.ViewFlushInterval)
+	lc.Go("view counter", viewCounter.Run)
+	lc.OnShutdown(lifecycle.PhaseFlush, "view counts", viewCounter.Flush)
+	noteService := service.NewNoteService(noteRepo, userRepo, txManager, viewCounter, validator)
+	if cfg.NoteCacheTTL > 0 {
+		noteCache := service.NewNoteCache(kvStore, cfg.NoteCacheTTL)
+		noteService = service.NewNoteServiceWithCache(noteRepo, userRepo, txManager, viewCounter, validator, noteCache)
+	}
+	shareService := service.NewShareService(shareRepo, noteRepo, validator)
+	feedService := service.NewFeedService(noteRepo, userRepo)
+	profileService := service.NewProfileService(userRepo, noteService, txManager, validator)
+	lc.Go("outbox relay", outboxService.Run)
+	lc.Go("publish scheduler", service.NewPublishScheduler(noteService, cfg.NoteScheduleInterval).Run)
+
+	if *demo {
+		if err := seedDemo(context.Background(), userService, noteService); err != nil {
+			fatal("Failed to seed demo data", err)
+		}
+	}
 
 	// Initialize audit service
 	auditService := service.NewAuditService()
-	defer auditService.Close()
+	// Closed after the workers stop, since the trash purge job writes audit events
+	lc.OnShutdown(lifecycle.PhaseClose, "audit log", auditService.Shutdown)
+
+	trashService := service.NewTrashService(noteRepo, userRepo, noteService, auditService, cfg.TrashRetentionDays)
+
+	// Background job queue; features register their job handlers before it runs
+	instance, _ := os.Hostname()
+	jobQueue := queue.New(broker, instance, queue.Options{
+		Workers:           cfg.QueueWorkers,
+		MaxAttempts:       cfg.QueueMaxAttempts,
+		Backoff:           cfg.QueueRetryBackoff,
+		MaxBackoff:        cfg.QueueMaxBackoff,
+		VisibilityTimeout: cfg.QueueVisibilityTimeout,
+	})
+	lc.Go("job queue", jobQueue.Run)
+
+	// Maintenance jobs; each occurrence runs on a single replica
+	jobScheduler := scheduler.New(kvStore, instance, cfg.JobHistorySize)
+	jobs := []struct {
+		name, schedule string
+		fn             scheduler.Func
+	}{
+		{"session_cleanup", cfg.JobSessionCleanupSchedule, sessionService.CleanupExpiredSessions},
+		{"stale_key_sweep", cfg.JobStaleKeySweepSchedule, service.NewStaleKeySweeper(kvStore).Sweep},
+		{"trash_purge", cfg.JobTrashPurgeSchedule, trashService.PurgeExpired},
+		{"queue_consumer_prune", cfg.JobQueuePruneSchedule, jobQueue.PruneConsumers},
+	}
+	for _, job := range jobs {
+		if err := jobScheduler.Register(job.name, job.schedule, cfg.JobTimeout, job.fn); err != nil {
+			fatal("Invalid job schedule", err)
+		}
+	}
+	lc.Go("job scheduler", jobScheduler.Run)
+
+	healthService := service.NewHealthService(db, cfg.DBDriver, kvStore, migrator, lc, cfg.RedisRequired)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(userService, sessionService)
-	noteHandler := handler.NewNoteHandler(noteService)
+	noteHandler := handler.NewNoteHandler(noteService, trashService, cfg.PublicNoteMaxAge)
+	shareHandler := handler.NewShareHandler(shareService)
+	feedHandler := handler.NewFeedHandler(feedService, cfg.PublicBaseURL, cfg.PublicNoteMaxAge)
+	profileHandler := handler.NewProfileHandler(profileService)
 	sessionHandler := handler.NewSessionHandler(sessionService)
+	healthHandler := handler.NewHealthHandler(healthService, cfg.AdminToken)
+	jobHandler := handler.NewJobHandler(jobScheduler)
+	queueHandler := handler.NewQueueHandler(jobQueue)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(sessionService, cfg)
-	rateLimitConfig := middleware.DefaultRateLimitConfig(redisClient)
+	ipResolver, err := utils.NewIPResolver(cfg.TrustedProxies)
+	if err != nil {
+		fatal("Invalid trusted proxy configuration", err)
+	}
+	rateLimitConfig, err := middleware.NewRateLimitConfig(cfg, ratelimit.NewStoreLimiter(kvStore))
+	if err != nil {
+		fatal("Invalid rate limit configuration", err)
+	}
+	rateLimitConfig.Identify = authMiddleware.Identify
 
 	// Setup routes
 	r := chi.NewRouter()
@@ -81,117 +278,204 @@ func main() {
 	// Global middleware
 	r.Use(middleware.CORSMiddleware)
 	r.Use(chiMiddleware.RequestID)
-	r.Use(chiMiddleware.RealIP)
+	r.Use(middleware.ClientIPMiddleware(ipResolver))
+	r.Use(middleware.LogContextMiddleware)
+	r.Use(middleware.TracingMiddleware)
+	r.Use(middleware.MetricsMiddleware)
 	r.Use(middleware.SecurityHeadersMiddleware())
 	r.Use(middleware.LoggingMiddleware())
 	r.Use(middleware.AuditLogMiddleware())
-	r.Use(middleware.RateLimitMiddleware(rateLimitConfig))
-	r.Use(middleware.DDoSProtectionMiddleware(redisClient))
 	r.Use(chiMiddleware.Recoverer)
 	r.Use(chiMiddleware.Timeout(60 * time.Second))
 
-	// Health check endpoint
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy","timestamp":"%s"}`, time.Now().Format(time.RFC3339))
-	})
+	// Probes skip rate limiting and DDoS protection: all kubelet probes come
+	// from the node IP, and a throttled probe restarts a healthy pod
+	r.Get("/livez", healthHandler.Livez)
+	r.Get("/readyz", healthHandler.Readyz)
 
-	// Public routes (authentication)
-	r.Route("/api/v1/auth", func(r chi.Router) {
-		r.Post("/register", authHandler.Register)
-		r.Post("/login", authHandler.Login)
-		r.Post("/refresh", authHandler.RefreshToken)
-		r.Post("/logout", authHandler.Logout)
-	})
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimitMiddleware(rateLimitConfig))
+		r.Use(middleware.DDoSProtectionMiddleware(kvStore))
 
-	// Protected routes (require authentication)
-	r.Route("/api/v1/user", func(r chi.Router) {
-		r.Use(authMiddleware.RequireAuth)
+		// Detailed health (details need the admin token)
+		r.Get("/health", healthHandler.Health)
 
-		// Profile management
-		r.Get("/profile", authHandler.GetProfile)
-		r.Put("/profile", authHandler.UpdateProfile)
+		// Prometheus metrics (blocked at the reverse proxy, scrape the app directly)
+		r.Handle("/metrics", metrics.Handler())
 
-		// Basic session info (legacy)
-		r.Get("/sessions", authHandler.GetSessions)
-	})
+		// Admin routes (require the admin token)
+		r.Route("/api/v1/admin", func(r chi.Router) {
+			r.Use(middleware.RequireAdminToken(cfg.AdminToken))
+
+			// Scheduled maintenance jobs with their recent runs
+			r.Get("/jobs", jobHandler.GetJobs)
 
-	// Advanced session management routes
-	r.Route("/api/v1/user/sessions", func(r chi.Router) {
-		r.Use(authMiddleware.RequireAuth)
+			// Background job queue: depth, dead jobs and their retry
+			r.Get("/queue", queueHandler.GetStats)
+			r.Get("/queue/dead", queueHandler.GetDeadJobs)
+			r.Post("/queue/dead/retry", queueHandler.RetryAllDeadJobs)
+			r.Post("/queue/dead/{entryId}/retry", queueHandler.RetryDeadJob)
+		})
 
-		// Get all active sessions with device info
-		r.Get("/active", sessionHandler.GetActiveSessions)
+		// Public routes (authentication)
+		r.Route("/api/v1/auth", func(r chi.Router) {
+			r.Post("/register", authHandler.Register)
+			r.Post("/login", authHandler.Login)
+			r.Post("/refresh", authHandler.RefreshToken)
+			r.Post("/logout", authHandler.Logout)
+		})
 
-		// Session statistics
-		r.Get("/stats", sessionHandler.GetSessionsStats)
+		// Protected routes (require authentication)
+		r.Route("/api/v1/user", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
 
-		// Invalidate all sessions (logout from all devices)
-		r.Delete("/", sessionHandler.InvalidateAllSessions)
+			// Profile management
+			r.Get("/profile", authHandler.GetProfile)
+			r.Put("/profile", authHandler.UpdateProfile)
 
-		// Invalidate specific session (logout from specific device)
-		r.Delete("/{sessionId}", sessionHandler.InvalidateSession)
+			// Preferences
+			r.Get("/settings", authHandler.GetSettings)
+			r.Put("/settings", authHandler.UpdateSettings)
 
-		// Alternative endpoint for session invalidation via POST
-		r.Post("/invalidate", sessionHandler.InvalidateSessionByRequest)
-	})
+			// Public profile shown at /api/v1/users/{handle}
+			r.Get("/public-profile", profileHandler.GetOwnProfile)
+			r.Put("/public-profile", profileHandler.UpdateOwnProfile)
 
-	// Public notes routes (no authentication required)
-	r.Route("/api/v1/notes", func(r chi.Router) {
-		// Public endpoints
-		r.Get("/public", noteHandler.GetPublicNotes)
+			// Basic session info (legacy)
+			r.Get("/sessions", authHandler.GetSessions)
+		})
 
-		// Protected endpoints (require authentication)
-		r.Group(func(r chi.Router) {
+		// Advanced session management routes
+		r.Route("/api/v1/user/sessions", func(r chi.Router) {
 			r.Use(authMiddleware.RequireAuth)
 
-			// Basic CRUD operations
-			r.Post("/", noteHandler.CreateNote)
-			r.Get("/", noteHandler.GetNotes)
-			r.Get("/{id}", noteHandler.GetNote)
-			r.Put("/{id}", noteHandler.UpdateNote)
-			r.Delete("/{id}", noteHandler.DeleteNote)
-
-			// Advanced operations
-			r.Post("/search", noteHandler.SearchNotes)
-			r.Post("/bulk", noteHandler.BulkUpdateNotes)
-			r.Get("/stats", noteHandler.GetNoteStats)
-			r.Get("/tags", noteHandler.GetUserTags)
-			r.Get("/tag/{tag}", noteHandler.GetNotesByTag)
-
-			// Note-specific operations
-			r.Post("/{id}/restore", noteHandler.RestoreNote)
-			r.Delete("/{id}/hard", noteHandler.HardDeleteNote)
-			r.Post("/{id}/duplicate", noteHandler.DuplicateNote)
-			r.Post("/{id}/toggle-public", noteHandler.ToggleNotePublicStatus)
+			// Get all active sessions with device info
+			r.Get("/active", sessionHandler.GetActiveSessions)
+
+			// Session statistics
+			r.Get("/stats", sessionHandler.GetSessionsStats)
+
+			// Invalidate all sessions (logout from all devices)
+			r.Delete("/", sessionHandler.InvalidateAllSessions)
+
+			// Invalidate specific session (logout from specific device)
+			r.Delete("/{sessionId}", sessionHandler.InvalidateSession)
+
+			// Alternative endpoint for session invalidation via POST
+			r.Post("/invalidate", sessionHandler.InvalidateSessionByRequest)
+		})
+
+		// Public notes routes (no authentication required)
+		r.Route("/api/v1/notes", func(r chi.Router) {
+			// Public endpoints
+			r.With(authMiddleware.OptionalAuth).Get("/public", noteHandler.GetPublicNotes)
+			r.With(authMiddleware.OptionalAuth).Get("/public/{id}", noteHandler.GetPublicNote)
+
+			// Protected endpoints (require authentication)
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequireAuth)
+
+				// Basic CRUD operations
+				r.Post("/", noteHandler.CreateNote)
+				r.Get("/", noteHandler.GetNotes)
+				r.Get("/{id}", noteHandler.GetNote)
+				r.Put("/{id}", noteHandler.UpdateNote)
+				r.Delete("/{id}", noteHandler.DeleteNote)
+
+				// Advanced operations
+				r.Post("/search", noteHandler.SearchNotes)
+				r.Post("/bulk", noteHandler.BulkUpdateNotes)
+				r.Get("/stats", noteHandler.GetNoteStats)
+				r.Get("/tags", noteHandler.GetUserTags)
+				r.Get("/tag/{tag}", noteHandler.GetNotesByTag)
+				r.Delete("/trash", noteHandler.EmptyTrash)
+
+				// Note-specific operations
+				r.Post("/{id}/restore", noteHandler.RestoreNote)
+				r.Delete("/{id}/hard", noteHandler.HardDeleteNote)
+				r.Post("/{id}/duplicate", noteHandler.DuplicateNote)
+				r.Post("/{id}/toggle-public", noteHandler.ToggleNotePublicStatus)
+				r.Put("/{id}/schedule", noteHandler.ScheduleNote)
+				r.Get("/{id}/analytics", noteHandler.GetNoteAnalytics)
+
+				// Share links
+				r.Post("/{id}/shares", shareHandler.CreateShareLink)
+				r.Get("/{id}/shares", shareHandler.GetShareLinks)
+				r.Delete("/{id}/shares/{shareId}", shareHandler.RevokeShareLink)
+			})
+		})
+
+		// Public profiles (no authentication required); previous handles redirect
+		r.Route("/api/v1/users/{handle}", func(r chi.Router) {
+			r.Get("/", profileHandler.GetPublicProfile)
+			r.Get("/notes", profileHandler.GetProfileNotes)
+		})
+
+		// Atom and JSON feeds of public notes (no authentication required)
+		r.Route("/feeds", func(r chi.Router) {
+			r.Get("/{file}", feedHandler.PublicFeed)
+			r.Get("/users/{file}", feedHandler.UserFeed)
+			r.Get("/tags/{file}", feedHandler.TagFeed)
 		})
+
+		// Share links (no authentication required, password checked per link)
+		r.Get("/s/{slug}", shareHandler.OpenShareLink)
+		r.Post("/s/{slug}", shareHandler.OpenShareLink)
 	})
 
 	// Start server
 	serverAddr := fmt.Sprintf(":%s", cfg.AppPort)
-	log.Printf("Starting server on port %s", cfg.AppPort)
-	log.Printf("Health check: http://localhost%s/health", serverAddr)
-	log.Printf("API Documentation: http://localhost%s/api/v1", serverAddr)
+	slog.Info("Starting server",
+		"port", cfg.AppPort,
+		"health", fmt.Sprintf("http://localhost%s/health", serverAddr),
+		"api", fmt.Sprintf("http://localhost%s/api/v1", serverAddr),
+	)
 
-	// Graceful shutdown
 	srv := &http.Server{
 		Addr:    serverAddr,
 		Handler: r,
 	}
+	lc.OnShutdown(lifecycle.PhaseHTTP, "http server", srv.Shutdown)
 
+	serverErr := make(chan error, 1)
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Server failed to start:", err)
+			serverErr <- err
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
+	// Wait for interrupt signal or a server failure to shut down
+	quit := make(chan os.Signal, 2)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		slog.Info("Shutting down server", "signal", sig.String())
+	case err := <-serverErr:
+		slog.Error("Server failed", "error", err)
+		exitCode = 1
+	}
+
+	// A second signal aborts the graceful shutdown
+	go func() {
+		<-quit
+		slog.Warn("Forced shutdown")
+		os.Exit(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDelay+cfg.ShutdownTimeout)
+	if err := lc.Shutdown(ctx, cfg.ShutdownDelay); err != nil {
+		slog.Error("Shutdown completed with errors", "error", err)
+		exitCode = 1
+	}
+	cancel()
+
+	slog.Info("Server stopped")
+	os.Exit(exitCode)
+}
 
-	// Cleanup
-	log.Println("Server stopped")
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
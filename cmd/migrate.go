@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gonotes/internal/config"
+	"gonotes/internal/migrate"
+	"gonotes/internal/utils"
+	"gonotes/migrations"
+)
+
+const migrateUsage = "usage: gonotes migrate up | down [N] | status | to N"
+
+// newMigrator loads the embedded migrations for the configured database driver
+func newMigrator(cfg *config.Config, db *sql.DB) (*migrate.Migrator, error) {
+	if cfg.DBDriver == "sqlite" {
+		return migrate.New(db, migrations.SQLiteFS, migrate.SQLite)
+	}
+	return migrate.New(db, migrations.FS, migrate.Postgres)
+}
+
+// runMigrate executes the migrate subcommand against the configured database
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := utils.ConnectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", count)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", count)
+
+	case "to":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		count, err := migrator.To(ctx, version)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Migrated to version %d (%d change(s))\n", version, count)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if errors.Is(err, migrate.ErrNotInitialized) {
+			fmt.Printf("Database not initialised, run \"gonotes migrate up\" to migrate to version %d\n", migrator.Latest())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
@@ -1,8 +1,9 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -10,23 +11,112 @@ import (
 
 type Config struct {
 	AppPort string `mapstructure:"APP_PORT"`
+	AppEnv  string `mapstructure:"APP_ENV"`
 
-	// Database
+	// External URL of the API (e.g. https://notes.example.com) used for links
+	// in feeds; derived from each request when empty, and feeds are then not
+	// stored by shared caches
+	PublicBaseURL string `mapstructure:"PUBLIC_BASE_URL"`
+
+	// Database driver: "postgres" or "sqlite" (single file, single instance)
+	DBDriver   string `mapstructure:"DB_DRIVER"`
+	SQLitePath string `mapstructure:"SQLITE_PATH"`
+
+	// PostgreSQL
 	DBHost     string `mapstructure:"DB_HOST"`
 	DBPort     string `mapstructure:"DB_PORT"`
 	DBUser     string `mapstructure:"DB_USER"`
 	DBPassword string `mapstructure:"DB_PASSWORD"`
 	DBName     string `mapstructure:"DB_NAME"`
 
+	// Upper bound for a single repository call (0 disables)
+	DBQueryTimeout time.Duration // Will be parsed manually
+
+	// Read replicas for heavy read-only queries (PostgreSQL only)
+	DBReplicaDSNs          []string      // Will be parsed manually; comma-separated connection strings
+	DBReplicaMaxLag        time.Duration // Will be parsed manually; replicas further behind are skipped
+	DBReplicaCheckInterval time.Duration // Will be parsed manually
+	DBReadYourWritesWindow time.Duration // Will be parsed manually; reads go to the primary after a user's write
+
+	// How long note responses and public listing pages stay cached (0 disables)
+	NoteCacheTTL time.Duration // Will be parsed manually
+
+	// How long browsers and shared caches may reuse public note responses
+	PublicNoteMaxAge time.Duration // Will be parsed manually
+
+	// View counting: a viewer counts once per note per window; counts are written in batches
+	ViewDedupWindow   time.Duration // Will be parsed manually
+	ViewFlushInterval time.Duration // Will be parsed manually
+
+	// How often the outbox relay retries side effects not delivered after commit
+	OutboxPollInterval time.Duration // Will be parsed manually
+
+	// How often scheduled publish_at/unpublish_at changes are applied
+	NoteScheduleInterval time.Duration // Will be parsed manually
+
+	// Deleted notes are purged after TrashRetentionDays unless the user overrides it
+	TrashRetentionDays int `mapstructure:"TRASH_RETENTION_DAYS"`
+
+	// Maintenance jobs: cron schedules in UTC (empty disables a job), the
+	// longest a run may take and how many runs are kept per job
+	JobSessionCleanupSchedule string        `mapstructure:"JOB_SESSION_CLEANUP_SCHEDULE"`
+	JobStaleKeySweepSchedule  string        `mapstructure:"JOB_STALE_KEY_SWEEP_SCHEDULE"`
+	JobTrashPurgeSchedule     string        `mapstructure:"JOB_TRASH_PURGE_SCHEDULE"`
+	JobQueuePruneSchedule     string        `mapstructure:"JOB_QUEUE_PRUNE_SCHEDULE"`
+	JobTimeout                time.Duration // Will be parsed manually
+	JobHistorySize            int           `mapstructure:"JOB_HISTORY_SIZE"`
+
+	// Background job queue: concurrent jobs per instance, attempts before a
+	// job is dead, retry backoff (doubling up to the max) and the longest a
+	// job may run before another instance takes it over
+	QueueWorkers           int           `mapstructure:"QUEUE_WORKERS"`
+	QueueMaxAttempts       int           `mapstructure:"QUEUE_MAX_ATTEMPTS"`
+	QueueRetryBackoff      time.Duration // Will be parsed manually
+	QueueMaxBackoff        time.Duration // Will be parsed manually
+	QueueVisibilityTimeout time.Duration // Will be parsed manually
+
+	// Apply pending migrations before serving
+	MigrateOnStartup bool `mapstructure:"MIGRATE_ON_STARTUP"`
+
 	// Redis
-	RedisHost     string `mapstructure:"REDIS_HOST"`
-	RedisPort     string `mapstructure:"REDIS_PORT"`
-	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+	RedisHost     string        `mapstructure:"REDIS_HOST"`
+	RedisPort     string        `mapstructure:"REDIS_PORT"`
+	RedisPassword string        `mapstructure:"REDIS_PASSWORD"`
+	RedisRequired bool          `mapstructure:"REDIS_REQUIRED"`
+	RedisTimeout  time.Duration // Will be parsed manually; read/write timeout per command
+
+	// Key-value store backend: "redis" or "memory" (single instance, no Redis)
+	StoreBackend string `mapstructure:"STORE_BACKEND"`
 
 	// Auth
 	JWTSecret     string        `mapstructure:"JWT_SECRET"`
 	JWTExpire     time.Duration // Will be parsed manually
 	RefreshExpire time.Duration // Will be parsed manually
+
+	// Operator token for detailed health and admin endpoints (empty disables them)
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	// Logging
+	LogLevel  string `mapstructure:"LOG_LEVEL"`  // debug, info, warn or error
+	LogFormat string `mapstructure:"LOG_FORMAT"` // json or text
+
+	// Tracing (OpenTelemetry)
+	ServiceName       string  `mapstructure:"OTEL_SERVICE_NAME"`
+	TracesExporter    string  `mapstructure:"OTEL_TRACES_EXPORTER"` // none, otlp or stdout
+	OTLPEndpoint      string  `mapstructure:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	TracesSampleRatio float64 `mapstructure:"OTEL_TRACES_SAMPLE_RATIO"`
+
+	// Networking
+	TrustedProxies []string // Will be parsed manually
+
+	// Shutdown
+	ShutdownTimeout time.Duration // Will be parsed manually
+	ShutdownDelay   time.Duration // Will be parsed manually
+
+	// Rate limiting
+	RateLimitRequests   int           `mapstructure:"RATE_LIMIT_REQUESTS"`
+	RateLimitWindow     time.Duration // Will be parsed manually
+	RateLimitConfigFile string        `mapstructure:"RATE_LIMIT_CONFIG"`
 }
 
 func Load() (*Config, error) {
@@ -35,20 +125,64 @@ func Load() (*Config, error) {
 
 	// Set default values
 	viper.SetDefault("APP_PORT", "8080")
+	viper.SetDefault("APP_ENV", "development")
+	viper.SetDefault("PUBLIC_BASE_URL", "")
+	viper.SetDefault("DB_DRIVER", "postgres")
+	viper.SetDefault("SQLITE_PATH", "gonotes.db")
 	viper.SetDefault("DB_HOST", "localhost")
 	viper.SetDefault("DB_PORT", "5432")
 	viper.SetDefault("DB_USER", "postgres")
 	viper.SetDefault("DB_PASSWORD", "postgres")
 	viper.SetDefault("DB_NAME", "gonotes")
+	viper.SetDefault("DB_QUERY_TIMEOUT", "5s")
+	viper.SetDefault("DB_REPLICA_DSNS", "")
+	viper.SetDefault("DB_REPLICA_MAX_LAG", "10s")
+	viper.SetDefault("DB_REPLICA_CHECK_INTERVAL", "5s")
+	viper.SetDefault("DB_READ_YOUR_WRITES_WINDOW", "5s")
+	viper.SetDefault("NOTE_CACHE_TTL", "60s")
+	viper.SetDefault("PUBLIC_NOTE_MAX_AGE", "60s")
+	viper.SetDefault("VIEW_DEDUP_WINDOW", "30m")
+	viper.SetDefault("VIEW_FLUSH_INTERVAL", "10s")
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	viper.SetDefault("NOTE_SCHEDULE_INTERVAL", "30s")
+	viper.SetDefault("TRASH_RETENTION_DAYS", 30)
+	viper.SetDefault("JOB_SESSION_CLEANUP_SCHEDULE", "15 * * * *")
+	viper.SetDefault("JOB_STALE_KEY_SWEEP_SCHEDULE", "30 3 * * *")
+	viper.SetDefault("JOB_TRASH_PURGE_SCHEDULE", "45 * * * *")
+	viper.SetDefault("JOB_TIMEOUT", "10m")
+	viper.SetDefault("JOB_QUEUE_PRUNE_SCHEDULE", "50 4 * * *")
+	viper.SetDefault("JOB_HISTORY_SIZE", 20)
+	viper.SetDefault("QUEUE_WORKERS", 4)
+	viper.SetDefault("QUEUE_MAX_ATTEMPTS", 5)
+	viper.SetDefault("QUEUE_RETRY_BACKOFF", "5s")
+	viper.SetDefault("QUEUE_MAX_BACKOFF", "1h")
+	viper.SetDefault("QUEUE_VISIBILITY_TIMEOUT", "5m")
+	viper.SetDefault("MIGRATE_ON_STARTUP", false)
 	viper.SetDefault("REDIS_HOST", "localhost")
 	viper.SetDefault("REDIS_PORT", "6379")
 	viper.SetDefault("REDIS_PASSWORD", "")
+	viper.SetDefault("REDIS_REQUIRED", false)
+	viper.SetDefault("REDIS_TIMEOUT", "2s")
+	viper.SetDefault("STORE_BACKEND", "redis")
 	viper.SetDefault("JWT_SECRET", "supersecretkey")
 	viper.SetDefault("JWT_EXPIRE", "15m")
 	viper.SetDefault("REFRESH_EXPIRE", "7d")
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("OTEL_SERVICE_NAME", "gonotes")
+	viper.SetDefault("OTEL_TRACES_EXPORTER", "none")
+	viper.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	viper.SetDefault("OTEL_TRACES_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "30s")
+	viper.SetDefault("SHUTDOWN_DELAY", "0s")
+	viper.SetDefault("RATE_LIMIT_REQUESTS", 100)
+	viper.SetDefault("RATE_LIMIT_WINDOW", "60")
+	viper.SetDefault("RATE_LIMIT_CONFIG", "")
 
 	if err := viper.ReadInConfig(); err != nil {
-		log.Printf("Warning: Could not read config file: %v", err)
+		slog.Warn("Could not read config file", "error", err)
 	}
 
 	var cfg Config
@@ -72,9 +206,173 @@ func Load() (*Config, error) {
 		cfg.RefreshExpire = refreshExpire
 	}
 
+	queryTimeout, err := parseSecondsOrDuration(viper.GetString("DB_QUERY_TIMEOUT"))
+	if err != nil {
+		cfg.DBQueryTimeout = 5 * time.Second
+	} else {
+		cfg.DBQueryTimeout = queryTimeout
+	}
+
+	cfg.DBReplicaDSNs = parseList(viper.GetString("DB_REPLICA_DSNS"))
+
+	replicaMaxLag, err := parseSecondsOrDuration(viper.GetString("DB_REPLICA_MAX_LAG"))
+	if err != nil {
+		cfg.DBReplicaMaxLag = 10 * time.Second
+	} else {
+		cfg.DBReplicaMaxLag = replicaMaxLag
+	}
+
+	replicaCheckInterval, err := parseSecondsOrDuration(viper.GetString("DB_REPLICA_CHECK_INTERVAL"))
+	if err != nil || replicaCheckInterval <= 0 {
+		cfg.DBReplicaCheckInterval = 5 * time.Second
+	} else {
+		cfg.DBReplicaCheckInterval = replicaCheckInterval
+	}
+
+	readYourWritesWindow, err := parseSecondsOrDuration(viper.GetString("DB_READ_YOUR_WRITES_WINDOW"))
+	if err != nil {
+		cfg.DBReadYourWritesWindow = 5 * time.Second
+	} else {
+		cfg.DBReadYourWritesWindow = readYourWritesWindow
+	}
+
+	noteCacheTTL, err := parseSecondsOrDuration(viper.GetString("NOTE_CACHE_TTL"))
+	if err != nil {
+		cfg.NoteCacheTTL = time.Minute
+	} else {
+		cfg.NoteCacheTTL = noteCacheTTL
+	}
+
+	publicNoteMaxAge, err := parseSecondsOrDuration(viper.GetString("PUBLIC_NOTE_MAX_AGE"))
+	if err != nil {
+		cfg.PublicNoteMaxAge = time.Minute
+	} else {
+		cfg.PublicNoteMaxAge = publicNoteMaxAge
+	}
+
+	viewDedupWindow, err := parseSecondsOrDuration(viper.GetString("VIEW_DEDUP_WINDOW"))
+	if err != nil {
+		cfg.ViewDedupWindow = 30 * time.Minute
+	} else {
+		cfg.ViewDedupWindow = viewDedupWindow
+	}
+
+	viewFlushInterval, err := parseSecondsOrDuration(viper.GetString("VIEW_FLUSH_INTERVAL"))
+	if err != nil || viewFlushInterval <= 0 {
+		cfg.ViewFlushInterval = 10 * time.Second
+	} else {
+		cfg.ViewFlushInterval = viewFlushInterval
+	}
+
+	outboxPollInterval, err := parseSecondsOrDuration(viper.GetString("OUTBOX_POLL_INTERVAL"))
+	if err != nil || outboxPollInterval <= 0 {
+		cfg.OutboxPollInterval = time.Second
+	} else {
+		cfg.OutboxPollInterval = outboxPollInterval
+	}
+
+	noteScheduleInterval, err := parseSecondsOrDuration(viper.GetString("NOTE_SCHEDULE_INTERVAL"))
+	if err != nil || noteScheduleInterval <= 0 {
+		cfg.NoteScheduleInterval = 30 * time.Second
+	} else {
+		cfg.NoteScheduleInterval = noteScheduleInterval
+	}
+
+	if cfg.TrashRetentionDays <= 0 {
+		cfg.TrashRetentionDays = 30
+	}
+
+	jobTimeout, err := parseSecondsOrDuration(viper.GetString("JOB_TIMEOUT"))
+	if err != nil || jobTimeout <= 0 {
+		cfg.JobTimeout = 10 * time.Minute
+	} else {
+		cfg.JobTimeout = jobTimeout
+	}
+
+	if cfg.JobHistorySize <= 0 {
+		cfg.JobHistorySize = 20
+	}
+
+	if cfg.QueueWorkers <= 0 {
+		cfg.QueueWorkers = 4
+	}
+	if cfg.QueueMaxAttempts <= 0 {
+		cfg.QueueMaxAttempts = 5
+	}
+
+	queueRetryBackoff, err := parseSecondsOrDuration(viper.GetString("QUEUE_RETRY_BACKOFF"))
+	if err != nil || queueRetryBackoff <= 0 {
+		cfg.QueueRetryBackoff = 5 * time.Second
+	} else {
+		cfg.QueueRetryBackoff = queueRetryBackoff
+	}
+
+	queueMaxBackoff, err := parseSecondsOrDuration(viper.GetString("QUEUE_MAX_BACKOFF"))
+	if err != nil || queueMaxBackoff <= 0 {
+		cfg.QueueMaxBackoff = time.Hour
+	} else {
+		cfg.QueueMaxBackoff = queueMaxBackoff
+	}
+
+	queueVisibilityTimeout, err := parseSecondsOrDuration(viper.GetString("QUEUE_VISIBILITY_TIMEOUT"))
+	if err != nil || queueVisibilityTimeout <= 0 {
+		cfg.QueueVisibilityTimeout = 5 * time.Minute
+	} else {
+		cfg.QueueVisibilityTimeout = queueVisibilityTimeout
+	}
+
+	redisTimeout, err := parseSecondsOrDuration(viper.GetString("REDIS_TIMEOUT"))
+	if err != nil || redisTimeout <= 0 {
+		cfg.RedisTimeout = 2 * time.Second
+	} else {
+		cfg.RedisTimeout = redisTimeout
+	}
+
+	cfg.TrustedProxies = parseList(viper.GetString("TRUSTED_PROXIES"))
+
+	shutdownTimeout, err := parseSecondsOrDuration(viper.GetString("SHUTDOWN_TIMEOUT"))
+	if err != nil {
+		cfg.ShutdownTimeout = 30 * time.Second
+	} else {
+		cfg.ShutdownTimeout = shutdownTimeout
+	}
+
+	shutdownDelay, err := parseSecondsOrDuration(viper.GetString("SHUTDOWN_DELAY"))
+	if err != nil {
+		cfg.ShutdownDelay = 0
+	} else {
+		cfg.ShutdownDelay = shutdownDelay
+	}
+
+	rateLimitWindow, err := parseSecondsOrDuration(viper.GetString("RATE_LIMIT_WINDOW"))
+	if err != nil {
+		cfg.RateLimitWindow = time.Minute
+	} else {
+		cfg.RateLimitWindow = rateLimitWindow
+	}
+
 	return &cfg, nil
 }
 
+// parseSecondsOrDuration parses a plain number of seconds or a duration string
+func parseSecondsOrDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func parseList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
 // parseDurationWithDays parses duration string with support for "d" (days) unit
 func parseDurationWithDays(s string) (time.Duration, error) {
 	// Handle "d" suffix for days
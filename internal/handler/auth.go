@@ -55,19 +55,8 @@ func extractClientInfo(r *http.Request) (userAgent, ipAddress string) {
 		userAgent = "Unknown"
 	}
 
-	// Get real IP address (considering proxies)
-	ipAddress = r.Header.Get("X-Forwarded-For")
-	if ipAddress == "" {
-		ipAddress = r.Header.Get("X-Real-IP")
-	}
-	if ipAddress == "" {
-		ipAddress = r.RemoteAddr
-	}
-
-	// Extract IP from address:port format
-	if idx := strings.LastIndex(ipAddress, ":"); idx != -1 {
-		ipAddress = ipAddress[:idx]
-	}
+	// Get client IP resolved against the trusted proxy configuration
+	ipAddress = middleware.GetClientIP(r)
 
 	return userAgent, ipAddress
 }
@@ -87,7 +76,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	req.FullName = strings.TrimSpace(req.FullName)
 
 	// Register user
-	user, err := h.userService.Register(&req)
+	user, err := h.userService.Register(r.Context(), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") ||
 			strings.Contains(err.Error(), "email already exists") {
@@ -116,7 +105,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
 	// Authenticate user
-	user, err := h.userService.Login(&req)
+	user, err := h.userService.Login(r.Context(), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") ||
 			strings.Contains(err.Error(), "invalid email or password") {
@@ -131,7 +120,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	userAgent, ipAddress := extractClientInfo(r)
 
 	// Create session with JWT tokens
-	authResponse, err := h.sessionService.CreateSession(user, userAgent, ipAddress)
+	authResponse, err := h.sessionService.CreateSession(r.Context(), user, userAgent, ipAddress)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to create session", nil, err.Error())
 		return
@@ -152,7 +141,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh session
-	authResponse, err := h.sessionService.RefreshSession(req.RefreshToken)
+	authResponse, err := h.sessionService.RefreshSession(r.Context(), req.RefreshToken)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid") ||
 			strings.Contains(err.Error(), "expired") ||
@@ -179,7 +168,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Invalidate session
-	err := h.sessionService.InvalidateSession(req.RefreshToken)
+	err := h.sessionService.InvalidateSession(r.Context(), req.RefreshToken)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid") {
 			sendResponse(w, http.StatusUnauthorized, "error", "Invalid refresh token", nil, nil)
@@ -203,7 +192,7 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get profile with caching
-	profile, err := h.userService.GetProfileWithCache(userID)
+	profile, err := h.userService.GetProfileWithCache(r.Context(), userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "user not found") {
 			sendResponse(w, http.StatusNotFound, "error", "User not found", nil, nil)
@@ -234,7 +223,7 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update profile
-	user, err := h.userService.UpdateProfile(userID, &req)
+	user, err := h.userService.UpdateProfile(r.Context(), userID, &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") {
 			sendResponse(w, http.StatusBadRequest, "error", err.Error(), nil, nil)
@@ -256,6 +245,63 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, http.StatusOK, "success", "Profile updated successfully", user.ToResponse(), nil)
 }
 
+// GetSettings handles GET /api/v1/user/settings request
+func (h *AuthHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "Authentication required", nil, nil)
+		return
+	}
+
+	settings, err := h.userService.GetSettings(r.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			sendResponse(w, http.StatusNotFound, "error", "User not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get settings", nil, err.Error())
+		return
+	}
+
+	// Send success response
+	sendResponse(w, http.StatusOK, "success", "Settings retrieved successfully", settings, nil)
+}
+
+// UpdateSettings handles PUT /api/v1/user/settings request
+func (h *AuthHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "Authentication required", nil, nil)
+		return
+	}
+
+	// Parse request body
+	var req model.UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid JSON format", nil, err.Error())
+		return
+	}
+
+	settings, err := h.userService.UpdateSettings(r.Context(), userID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			sendResponse(w, http.StatusBadRequest, "error", err.Error(), nil, nil)
+			return
+		}
+		if strings.Contains(err.Error(), "user not found") {
+			sendResponse(w, http.StatusNotFound, "error", "User not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to update settings", nil, err.Error())
+		return
+	}
+
+	// Send success response
+	sendResponse(w, http.StatusOK, "success", "Settings updated successfully", settings, nil)
+}
+
 // GetSessions handles GET /api/v1/user/sessions request (legacy endpoint)
 func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
@@ -266,7 +312,7 @@ func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all user sessions
-	sessions, err := h.sessionService.GetUserSessions(userID, nil)
+	sessions, err := h.sessionService.GetUserSessions(r.Context(), userID, nil)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to retrieve sessions", nil, err.Error())
 		return
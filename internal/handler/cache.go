@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sendCacheable writes body as a response that browsers, and shared caches
+// when shared is set, may reuse for maxAge, replacing the no-store headers
+// set by SecurityHeadersMiddleware. The ETag is derived from body, and
+// conditional requests matching it or lastModified get 304 Not Modified.
+func sendCacheable(w http.ResponseWriter, r *http.Request, contentType string, body []byte, lastModified time.Time, maxAge time.Duration, shared bool) {
+	sum := sha256.Sum256(body)
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	scope := "private"
+	if shared {
+		scope = "public"
+	}
+	header.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds())))
+	header.Del("Pragma")
+	header.Del("Expires")
+
+	http.ServeContent(w, r, "", lastModified, bytes.NewReader(body))
+}
+
+// sendCacheableResponse is sendCacheable for a success response in the API envelope
+func sendCacheableResponse(w http.ResponseWriter, r *http.Request, message string, data interface{}, lastModified time.Time, maxAge time.Duration) {
+	body, err := json.Marshal(APIResponse{
+		Status:  "success",
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    data,
+	})
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to encode response", nil, err.Error())
+		return
+	}
+	sendCacheable(w, r, "application/json", append(body, '\n'), lastModified, maxAge, true)
+}
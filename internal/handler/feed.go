@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Feed formats, by file extension
+const (
+	feedFormatAtom = "atom"
+	feedFormatJSON = "json"
+)
+
+// FeedHandler serves public notes as Atom and JSON Feed
+type FeedHandler struct {
+	feedService *service.FeedService
+	baseURL     string
+	maxAge      time.Duration
+}
+
+// NewFeedHandler creates a new feed handler. Links point at baseURL, or at
+// the host of each request when it is empty; feeds may be cached for maxAge,
+// by shared caches only when baseURL is set.
+func NewFeedHandler(feedService *service.FeedService, baseURL string, maxAge time.Duration) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		maxAge:      maxAge,
+	}
+}
+
+// PublicFeed handles GET /feeds/public.atom and .json, optionally limited by ?tag=
+func (h *FeedHandler) PublicFeed(w http.ResponseWriter, r *http.Request) {
+	name, format, ok := splitFeedFile(chi.URLParam(r, "file"))
+	if !ok || name != "public" {
+		sendResponse(w, http.StatusNotFound, "error", "Feed not found", nil, nil)
+		return
+	}
+
+	feed, err := h.feedService.PublicFeed(r.Context(), r.URL.Query().Get("tag"))
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get feed", nil, err.Error())
+		return
+	}
+	h.send(w, r, feed, format)
+}
+
+// TagFeed handles GET /feeds/tags/{tag}.atom and .json
+func (h *FeedHandler) TagFeed(w http.ResponseWriter, r *http.Request) {
+	tag, format, ok := splitFeedFile(chi.URLParam(r, "file"))
+	if !ok || tag == "" {
+		sendResponse(w, http.StatusNotFound, "error", "Feed not found", nil, nil)
+		return
+	}
+
+	feed, err := h.feedService.PublicFeed(r.Context(), tag)
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get feed", nil, err.Error())
+		return
+	}
+	h.send(w, r, feed, format)
+}
+
+// UserFeed handles GET /feeds/users/{id}.atom and .json, optionally limited by ?tag=
+func (h *FeedHandler) UserFeed(w http.ResponseWriter, r *http.Request) {
+	id, format, ok := splitFeedFile(chi.URLParam(r, "file"))
+	if !ok {
+		sendResponse(w, http.StatusNotFound, "error", "Feed not found", nil, nil)
+		return
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid user ID", nil, err.Error())
+		return
+	}
+
+	feed, err := h.feedService.UserFeed(r.Context(), userID, r.URL.Query().Get("tag"))
+	if err != nil {
+		if err.Error() == "feed not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Feed not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get feed", nil, err.Error())
+		return
+	}
+	h.send(w, r, feed, format)
+}
+
+// splitFeedFile splits "name.atom" or "name.json" into name and format
+func splitFeedFile(file string) (name, format string, ok bool) {
+	i := strings.LastIndex(file, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	name, format = file[:i], file[i+1:]
+	return name, format, format == feedFormatAtom || format == feedFormatJSON
+}
+
+// send renders feed in format with caching headers, so feed readers polling
+// an unchanged feed get 304 Not Modified
+func (h *FeedHandler) send(w http.ResponseWriter, r *http.Request, feed *model.Feed, format string) {
+	base := h.base(r)
+	// The requested URL without the extension, shared by both formats
+	self := base + strings.TrimSuffix(r.URL.Path, "."+format)
+	if r.URL.RawQuery != "" {
+		self += "?" + r.URL.RawQuery
+	}
+	withFormat := func(f string) string {
+		if i := strings.Index(self, "?"); i >= 0 {
+			return self[:i] + "." + f + self[i:]
+		}
+		return self + "." + f
+	}
+
+	var body []byte
+	var contentType string
+	var err error
+	switch format {
+	case feedFormatAtom:
+		contentType = "application/atom+xml; charset=utf-8"
+		body, err = renderAtom(feed, base, withFormat(feedFormatAtom), withFormat(feedFormatJSON))
+	case feedFormatJSON:
+		contentType = "application/feed+json; charset=utf-8"
+		body, err = renderJSONFeed(feed, base, withFormat(feedFormatJSON))
+	default:
+		sendResponse(w, http.StatusNotFound, "error", "Feed not found", nil, nil)
+		return
+	}
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to render feed", nil, err.Error())
+		return
+	}
+
+	// Links built from the Host header must not be stored by shared caches
+	sendCacheable(w, r, contentType, body, feed.Updated, h.maxAge, h.baseURL != "")
+}
+
+// base returns the configured base URL or the one the request was made to
+func (h *FeedHandler) base(r *http.Request) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// noteURL is where the full note of an entry can be read
+func noteURL(base string, id uuid.UUID) string {
+	return base + "/api/v1/notes/public/" + id.String()
+}
+
+// atomFeed is an Atom (RFC 4287) feed document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// renderAtom encodes feed as Atom; self and alternate are the URLs of the
+// Atom and JSON Feed documents
+func renderAtom(feed *model.Feed, base, self, alternate string) ([]byte, error) {
+	doc := atomFeed{
+		ID:      self,
+		Title:   feed.Title,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+			{Rel: "alternate", Type: "application/feed+json", Href: alternate},
+		},
+		Entries: make([]atomEntry, len(feed.Entries)),
+	}
+	if feed.Author != "" {
+		doc.Author = &atomPerson{Name: feed.Author}
+	}
+
+	for i, entry := range feed.Entries {
+		doc.Entries[i] = atomEntry{
+			ID:        "urn:uuid:" + entry.ID.String(),
+			Title:     entry.Title,
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Updated:   entry.Updated.UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: entry.Author},
+			Links:     []atomLink{{Rel: "alternate", Type: "application/json", Href: noteURL(base, entry.ID)}},
+			Content:   atomContent{Type: "text", Body: entry.Content},
+		}
+		for _, tag := range entry.Tags {
+			doc.Entries[i].Categories = append(doc.Entries[i].Categories, atomCategory{Term: tag})
+		}
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
+
+// jsonFeed is a JSON Feed 1.1 document
+type jsonFeed struct {
+	Version string           `json:"version"`
+	Title   string           `json:"title"`
+	FeedURL string           `json:"feed_url"`
+	Authors []jsonFeedAuthor `json:"authors,omitempty"`
+	Items   []jsonFeedItem   `json:"items"`
+}
+
+// jsonFeedItem is an item of a JSON Feed
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// renderJSONFeed encodes feed as JSON Feed; self is the URL of the document
+func renderJSONFeed(feed *model.Feed, base, self string) ([]byte, error) {
+	doc := jsonFeed{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   feed.Title,
+		FeedURL: self,
+		Items:   make([]jsonFeedItem, len(feed.Entries)),
+	}
+	if feed.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: feed.Author}}
+	}
+
+	for i, entry := range feed.Entries {
+		doc.Items[i] = jsonFeedItem{
+			ID:            entry.ID.String(),
+			URL:           noteURL(base, entry.ID),
+			Title:         entry.Title,
+			ContentText:   entry.Content,
+			DatePublished: entry.Published.UTC().Format(time.RFC3339),
+			DateModified:  entry.Updated.UTC().Format(time.RFC3339),
+			Tags:          entry.Tags,
+		}
+		if entry.Author != "" {
+			doc.Items[i].Authors = []jsonFeedAuthor{{Name: entry.Author}}
+		}
+	}
+
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"gonotes/internal/middleware"
+	"gonotes/internal/service"
+)
+
+// HealthHandler serves liveness, readiness and health probes. Probe
+// responses are plain JSON rather than the API envelope.
+type HealthHandler struct {
+	healthService *service.HealthService
+	adminToken    string
+}
+
+// NewHealthHandler creates a new health handler. Detailed health reports are
+// only returned to callers presenting adminToken.
+func NewHealthHandler(healthService *service.HealthService, adminToken string) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		adminToken:    adminToken,
+	}
+}
+
+// sendProbe writes a probe response
+func sendProbe(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Livez handles GET /livez, succeeding as long as the process serves requests
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	sendProbe(w, http.StatusOK, map[string]string{
+		"status": "alive",
+	})
+}
+
+// Readyz handles GET /readyz
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.healthService.Check(r.Context())
+	if !report.Ready() {
+		failed := report.FailedChecks()
+		sort.Strings(failed)
+		if report.ShuttingDown {
+			failed = append(failed, "shutdown")
+		}
+		sendProbe(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "not_ready",
+			"failed": failed,
+		})
+		return
+	}
+
+	sendProbe(w, http.StatusOK, map[string]string{
+		"status": "ready",
+	})
+}
+
+// Health handles GET /health. Public callers only get the overall status;
+// dependency details, errors and pool stats require the admin token.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	report := h.healthService.Check(r.Context())
+
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+
+	if middleware.IsAdminRequest(r, h.adminToken) {
+		sendProbe(w, code, report)
+		return
+	}
+
+	sendProbe(w, code, map[string]string{
+		"status":    report.Status,
+		"timestamp": report.Timestamp.Format(time.RFC3339),
+	})
+}
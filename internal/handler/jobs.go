@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"gonotes/internal/scheduler"
+)
+
+// JobHandler serves the admin view of scheduled maintenance jobs
+type JobHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(s *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{
+		scheduler: s,
+	}
+}
+
+// GetJobs handles GET /api/v1/admin/jobs
+func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.Status(r.Context())
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get jobs", nil, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Jobs retrieved successfully", jobs, nil)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"gonotes/internal/middleware"
 	"gonotes/internal/model"
@@ -15,13 +16,18 @@ import (
 
 // NoteHandler handles HTTP requests for notes
 type NoteHandler struct {
-	noteService *service.NoteService
+	noteService  *service.NoteService
+	trashService *service.TrashService
+	publicMaxAge time.Duration
 }
 
-// NewNoteHandler creates a new note handler
-func NewNoteHandler(noteService *service.NoteService) *NoteHandler {
+// NewNoteHandler creates a new note handler. Public note responses may be
+// cached for publicMaxAge.
+func NewNoteHandler(noteService *service.NoteService, trashService *service.TrashService, publicMaxAge time.Duration) *NoteHandler {
 	return &NoteHandler{
-		noteService: noteService,
+		noteService:  noteService,
+		trashService: trashService,
+		publicMaxAge: publicMaxAge,
 	}
 }
 
@@ -42,7 +48,7 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create note
-	note, err := h.noteService.CreateNote(userID, &req)
+	note, err := h.noteService.CreateNote(r.Context(), userID, &req)
 	if err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Validation error", nil, err.Error())
@@ -74,7 +80,7 @@ func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get note
-	note, err := h.noteService.GetNoteByID(noteID, userID)
+	note, err := h.noteService.GetNoteByID(r.Context(), noteID, userID, r.Referer())
 	if err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
@@ -92,6 +98,35 @@ func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, http.StatusOK, "success", "Note retrieved successfully", note, nil)
 }
 
+// GetPublicNote handles GET /notes/public/{id}. Authentication is optional
+// and only used so authors viewing their own note are not counted.
+func (h *NoteHandler) GetPublicNote(w http.ResponseWriter, r *http.Request) {
+	// Get note ID from URL
+	noteIDStr := chi.URLParam(r, "id")
+	noteID, err := uuid.Parse(noteIDStr)
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+
+	// Reader, if authenticated
+	userID, _ := middleware.GetUserID(r)
+
+	// Get note
+	note, err := h.noteService.GetPublicNote(r.Context(), noteID, userID, middleware.GetClientIP(r), r.Referer())
+	if err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get note", nil, err.Error())
+		return
+	}
+
+	// The response is the same for every reader, so shared caches may keep it
+	sendCacheableResponse(w, r, "Note retrieved successfully", note, note.UpdatedAt, h.publicMaxAge)
+}
+
 // GetNotes handles GET /notes
 func (h *NoteHandler) GetNotes(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
@@ -119,8 +154,14 @@ func (h *NoteHandler) GetNotes(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get notes
-	notes, err := h.noteService.GetUserNotes(userID, params)
+	// Get notes; the trash also tells when each note will be purged
+	var notes *model.NotesListResponse
+	var err error
+	if params.Status == string(model.NoteStatusDeleted) {
+		notes, err = h.trashService.GetTrash(r.Context(), userID, params)
+	} else {
+		notes, err = h.noteService.GetUserNotes(r.Context(), userID, params)
+	}
 	if err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Invalid parameters", nil, err.Error())
@@ -159,7 +200,7 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update note
-	note, err := h.noteService.UpdateNote(noteID, userID, &req)
+	note, err := h.noteService.UpdateNote(r.Context(), noteID, userID, &req)
 	if err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
@@ -195,7 +236,7 @@ func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete note
-	if err := h.noteService.DeleteNote(noteID, userID); err != nil {
+	if err := h.noteService.DeleteNote(r.Context(), noteID, userID); err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
 			return
@@ -230,7 +271,7 @@ func (h *NoteHandler) RestoreNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Restore note
-	note, err := h.noteService.RestoreNote(noteID, userID)
+	note, err := h.noteService.RestoreNote(r.Context(), noteID, userID)
 	if err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
@@ -266,7 +307,7 @@ func (h *NoteHandler) HardDeleteNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Hard delete note
-	if err := h.noteService.HardDeleteNote(noteID, userID); err != nil {
+	if err := h.noteService.HardDeleteNote(r.Context(), noteID, userID); err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
 			return
@@ -296,7 +337,7 @@ func (h *NoteHandler) SearchNotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Search notes
-	notes, err := h.noteService.SearchNotes(userID, &req)
+	notes, err := h.noteService.SearchNotes(r.Context(), userID, &req)
 	if err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Validation error", nil, err.Error())
@@ -323,7 +364,7 @@ func (h *NoteHandler) GetPublicNotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get public notes
-	notes, err := h.noteService.GetPublicNotes(params)
+	notes, err := h.noteService.GetPublicNotes(r.Context(), params)
 	if err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Invalid parameters", nil, err.Error())
@@ -354,11 +395,15 @@ func (h *NoteHandler) BulkUpdateNotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Perform bulk operation
-	if err := h.noteService.BulkUpdateNotesStatus(userID, &req); err != nil {
+	if err := h.noteService.BulkUpdateNotesStatus(r.Context(), userID, &req); err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Validation error", nil, err.Error())
 			return
 		}
+		if err.Error() == "one or more notes not found" {
+			sendResponse(w, http.StatusNotFound, "error", "One or more notes not found", nil, nil)
+			return
+		}
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to perform bulk operation", nil, err.Error())
 		return
 	}
@@ -377,7 +422,7 @@ func (h *NoteHandler) GetNoteStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get stats
-	stats, err := h.noteService.GetNoteStats(userID)
+	stats, err := h.noteService.GetNoteStats(r.Context(), userID)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get note stats", nil, err.Error())
 		return
@@ -387,6 +432,44 @@ func (h *NoteHandler) GetNoteStats(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, http.StatusOK, "success", "Stats retrieved successfully", stats, nil)
 }
 
+// GetNoteAnalytics handles GET /notes/{id}/analytics
+func (h *NoteHandler) GetNoteAnalytics(w http.ResponseWriter, r *http.Request) {
+	// Get note ID from URL
+	noteIDStr := chi.URLParam(r, "id")
+	noteID, err := uuid.Parse(noteIDStr)
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	days := getIntParam(r, "days", 30)
+	if days < 1 || days > 90 {
+		sendResponse(w, http.StatusBadRequest, "error", "Days must be between 1 and 90", nil, nil)
+		return
+	}
+
+	// Get analytics
+	analytics, err := h.noteService.GetNoteAnalytics(r.Context(), noteID, userID, days)
+	if err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get note analytics", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusOK, "success", "Analytics retrieved successfully", analytics, nil)
+}
+
 // DuplicateNote handles POST /notes/{id}/duplicate
 func (h *NoteHandler) DuplicateNote(w http.ResponseWriter, r *http.Request) {
 	// Get note ID from URL
@@ -405,7 +488,7 @@ func (h *NoteHandler) DuplicateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Duplicate note
-	note, err := h.noteService.DuplicateNote(noteID, userID)
+	note, err := h.noteService.DuplicateNote(r.Context(), noteID, userID)
 	if err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
@@ -445,7 +528,7 @@ func (h *NoteHandler) GetNotesByTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get notes by tag
-	notes, err := h.noteService.GetNotesByTag(userID, tag, params)
+	notes, err := h.noteService.GetNotesByTag(r.Context(), userID, tag, params)
 	if err != nil {
 		if isValidationError(err) {
 			sendResponse(w, http.StatusBadRequest, "error", "Validation error", nil, err.Error())
@@ -469,7 +552,7 @@ func (h *NoteHandler) GetUserTags(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all user tags
-	tags, err := h.noteService.GetAllUserTags(userID)
+	tags, err := h.noteService.GetAllUserTags(r.Context(), userID)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get user tags", nil, err.Error())
 		return
@@ -499,7 +582,7 @@ func (h *NoteHandler) ToggleNotePublicStatus(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Toggle public status
-	note, err := h.noteService.ToggleNotePublicStatus(noteID, userID)
+	note, err := h.noteService.ToggleNotePublicStatus(r.Context(), noteID, userID)
 	if err != nil {
 		if err.Error() == "note not found" {
 			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
@@ -521,6 +604,72 @@ func (h *NoteHandler) ToggleNotePublicStatus(w http.ResponseWriter, r *http.Requ
 	sendResponse(w, http.StatusOK, "success", message, note, nil)
 }
 
+// EmptyTrash handles DELETE /notes/trash
+func (h *NoteHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	userAgent, ipAddress := extractClientInfo(r)
+	deleted, err := h.trashService.EmptyTrash(r.Context(), userID, ipAddress, &userAgent)
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to empty trash", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusOK, "success", "Trash emptied", map[string]int{"deleted": deleted}, nil)
+}
+
+// ScheduleNote handles PUT /notes/{id}/schedule
+func (h *NoteHandler) ScheduleNote(w http.ResponseWriter, r *http.Request) {
+	// Get note ID from URL
+	noteIDStr := chi.URLParam(r, "id")
+	noteID, err := uuid.Parse(noteIDStr)
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	// Parse request body
+	var req model.ScheduleNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid request body", nil, err.Error())
+		return
+	}
+
+	note, err := h.noteService.ScheduleNote(r.Context(), noteID, userID, &req)
+	if err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		if err.Error() == "only active notes can be scheduled" {
+			sendResponse(w, http.StatusBadRequest, "error", "Only active notes can be scheduled", nil, nil)
+			return
+		}
+		if isValidationError(err) {
+			sendResponse(w, http.StatusBadRequest, "error", "Validation failed", nil, err.Error())
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to schedule note", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusOK, "success", "Note schedule updated", note, nil)
+}
+
 // Helper functions
 
 // getIntParam extracts integer parameter from query string with default value
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"gonotes/internal/middleware"
+	"gonotes/internal/model"
+	"gonotes/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ProfileHandler serves public user profiles
+type ProfileHandler struct {
+	profileService *service.ProfileService
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// GetOwnProfile handles GET /api/v1/user/public-profile
+func (h *ProfileHandler) GetOwnProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "Authentication required", nil, nil)
+		return
+	}
+
+	profile, err := h.profileService.GetOwnProfile(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			sendResponse(w, http.StatusNotFound, "error", "User not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get profile", nil, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Profile retrieved successfully", profile, nil)
+}
+
+// UpdateOwnProfile handles PUT /api/v1/user/public-profile
+func (h *ProfileHandler) UpdateOwnProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "Authentication required", nil, nil)
+		return
+	}
+
+	var req model.UpdatePublicProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid JSON format", nil, err.Error())
+		return
+	}
+
+	profile, err := h.profileService.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		if isValidationError(err) {
+			sendResponse(w, http.StatusBadRequest, "error", "Validation failed", nil, err.Error())
+			return
+		}
+		switch err.Error() {
+		case "handle already taken":
+			sendResponse(w, http.StatusConflict, "error", "Handle already taken", nil, nil)
+		case "user not found":
+			sendResponse(w, http.StatusNotFound, "error", "User not found", nil, nil)
+		default:
+			sendResponse(w, http.StatusInternalServerError, "error", "Failed to update profile", nil, err.Error())
+		}
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Profile updated successfully", profile, nil)
+}
+
+// GetPublicProfile handles GET /api/v1/users/{handle}
+func (h *ProfileHandler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+
+	profile, err := h.profileService.GetPublicProfile(r.Context(), handle)
+	if err != nil {
+		if err.Error() == "profile not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Profile not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get profile", nil, err.Error())
+		return
+	}
+
+	if profile.Handle != handle {
+		redirectToHandle(w, r, profile.Handle, "")
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Profile retrieved successfully", profile, nil)
+}
+
+// GetProfileNotes handles GET /api/v1/users/{handle}/notes
+func (h *ProfileHandler) GetProfileNotes(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+
+	params := &model.GetNotesParams{
+		Page:     getIntParam(r, "page", 1),
+		PageSize: getIntParam(r, "page_size", 20),
+		Search:   r.URL.Query().Get("search"),
+		Tags:     r.URL.Query().Get("tags"),
+		SortBy:   r.URL.Query().Get("sort_by"),
+		SortDir:  r.URL.Query().Get("sort_dir"),
+	}
+
+	notes, current, err := h.profileService.GetProfileNotes(r.Context(), handle, params)
+	if err != nil {
+		if isValidationError(err) {
+			sendResponse(w, http.StatusBadRequest, "error", "Invalid parameters", nil, err.Error())
+			return
+		}
+		if err.Error() == "profile not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Profile not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get notes", nil, err.Error())
+		return
+	}
+
+	if current != handle {
+		redirectToHandle(w, r, current, "/notes")
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Public notes retrieved successfully", notes, nil)
+}
+
+// redirectToHandle sends the client to the same profile page under its
+// current handle. The redirect is temporary: the old handle may become
+// current again if the user takes it back.
+func redirectToHandle(w http.ResponseWriter, r *http.Request, handle, suffix string) {
+	target := url.URL{
+		Path:     "/api/v1/users/" + url.PathEscape(handle) + suffix,
+		RawQuery: r.URL.RawQuery,
+	}
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"gonotes/internal/queue"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxDeadJobsPage caps the dead jobs listed at once
+const maxDeadJobsPage = 500
+
+// QueueHandler serves the admin view of the background job queue
+type QueueHandler struct {
+	queue *queue.Queue
+}
+
+// NewQueueHandler creates a new queue handler
+func NewQueueHandler(q *queue.Queue) *QueueHandler {
+	return &QueueHandler{
+		queue: q,
+	}
+}
+
+// GetStats handles GET /api/v1/admin/queue
+func (h *QueueHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.queue.Stats(r.Context())
+	if err != nil {
+		sendResponse(w, http.StatusServiceUnavailable, "error", "Failed to get queue stats", nil, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Queue stats retrieved successfully", stats, nil)
+}
+
+// GetDeadJobs handles GET /api/v1/admin/queue/dead
+func (h *QueueHandler) GetDeadJobs(w http.ResponseWriter, r *http.Request) {
+	limit := getIntParam(r, "limit", 50)
+	if limit < 1 || limit > maxDeadJobsPage {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid parameters", nil, "limit must be between 1 and 500")
+		return
+	}
+
+	jobs, err := h.queue.DeadJobs(r.Context(), limit)
+	if err != nil {
+		sendResponse(w, http.StatusServiceUnavailable, "error", "Failed to get dead jobs", nil, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Dead jobs retrieved successfully", jobs, nil)
+}
+
+// RetryDeadJob handles POST /api/v1/admin/queue/dead/{entryId}/retry
+func (h *QueueHandler) RetryDeadJob(w http.ResponseWriter, r *http.Request) {
+	entryID := chi.URLParam(r, "entryId")
+
+	if err := h.queue.RetryDead(r.Context(), entryID); err != nil {
+		if err.Error() == "dead job not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Dead job not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusServiceUnavailable, "error", "Failed to retry job", nil, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Job queued for retry", nil, nil)
+}
+
+// RetryAllDeadJobs handles POST /api/v1/admin/queue/dead/retry
+func (h *QueueHandler) RetryAllDeadJobs(w http.ResponseWriter, r *http.Request) {
+	retried, err := h.queue.RetryAllDead(r.Context())
+	if err != nil {
+		sendResponse(w, http.StatusServiceUnavailable, "error", "Failed to retry jobs", map[string]int{"retried": retried}, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, "success", "Jobs queued for retry", map[string]int{"retried": retried}, nil)
+}
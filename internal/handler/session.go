@@ -43,7 +43,7 @@ func (h *SessionHandler) GetActiveSessions(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Get all sessions for user
-	sessions, err := h.sessionService.GetUserSessions(userID, currentRefreshToken)
+	sessions, err := h.sessionService.GetUserSessions(r.Context(), userID, currentRefreshToken)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get user sessions", nil, err.Error())
 		return
@@ -71,7 +71,7 @@ func (h *SessionHandler) InvalidateSession(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Invalidate the specific session
-	if err := h.sessionService.InvalidateSpecificSession(userID, sessionID); err != nil {
+	if err := h.sessionService.InvalidateSpecificSession(r.Context(), userID, sessionID); err != nil {
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not owned") {
 			sendResponse(w, http.StatusNotFound, "error", "Session not found", nil, nil)
 			return
@@ -94,7 +94,7 @@ func (h *SessionHandler) InvalidateAllSessions(w http.ResponseWriter, r *http.Re
 	}
 
 	// Invalidate all sessions for user
-	if err := h.sessionService.InvalidateAllSessions(userID); err != nil {
+	if err := h.sessionService.InvalidateAllSessions(r.Context(), userID); err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to invalidate all sessions", nil, err.Error())
 		return
 	}
@@ -126,7 +126,7 @@ func (h *SessionHandler) InvalidateSessionByRequest(w http.ResponseWriter, r *ht
 	}
 
 	// Invalidate the specific session
-	if err := h.sessionService.InvalidateSpecificSession(userID, req.SessionID); err != nil {
+	if err := h.sessionService.InvalidateSpecificSession(r.Context(), userID, req.SessionID); err != nil {
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not owned") {
 			sendResponse(w, http.StatusNotFound, "error", "Session not found", nil, nil)
 			return
@@ -149,7 +149,7 @@ func (h *SessionHandler) GetSessionsStats(w http.ResponseWriter, r *http.Request
 	}
 
 	// Get all sessions for user
-	sessions, err := h.sessionService.GetUserSessions(userID, nil)
+	sessions, err := h.sessionService.GetUserSessions(r.Context(), userID, nil)
 	if err != nil {
 		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get user sessions", nil, err.Error())
 		return
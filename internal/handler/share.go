@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"gonotes/internal/middleware"
+	"gonotes/internal/model"
+	"gonotes/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// sharePasswordHeader carries the password of a protected share link for API clients
+const sharePasswordHeader = "X-Share-Password"
+
+// sharedNoteTemplate renders a shared note, or the password form when Note is nil
+var sharedNoteTemplate = template.Must(template.New("shared").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Note}}{{.Note.Title}}{{else}}Shared note{{end}}</title>
+</head>
+<body>
+{{- if .Note}}
+<article>
+<h1>{{.Note.Title}}</h1>
+{{- if .Note.Tags}}
+<p>{{range $i, $tag := .Note.Tags}}{{if $i}}, {{end}}#{{$tag}}{{end}}</p>
+{{- end}}
+<div style="white-space: pre-wrap">{{if .Note.Content}}{{.Note.Content}}{{end}}</div>
+<p><small>Updated {{.Note.UpdatedAt.Format "2 Jan 2006"}}</small></p>
+</article>
+{{- else if .Password}}
+<form method="post">
+<p>{{.Message}}</p>
+<label>Password <input type="password" name="password" autofocus required></label>
+<button type="submit">Open</button>
+</form>
+{{- else}}
+<p>{{.Message}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// sharedNotePage is the data of sharedNoteTemplate
+type sharedNotePage struct {
+	Note     *model.SharedNoteResponse
+	Password bool
+	Message  string
+}
+
+// ShareHandler handles share link requests
+type ShareHandler struct {
+	shareService *service.ShareService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *service.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+// CreateShareLink handles POST /notes/{id}/shares
+func (h *ShareHandler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	// Get note ID from URL
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	// Parse request body; an empty body creates a link without restrictions
+	var req model.CreateShareLinkRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, http.StatusBadRequest, "error", "Invalid request body", nil, err.Error())
+			return
+		}
+	}
+
+	// Create share link
+	link, err := h.shareService.CreateShareLink(r.Context(), noteID, userID, &req)
+	if err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		if isValidationError(err) {
+			sendResponse(w, http.StatusBadRequest, "error", "Validation error", nil, err.Error())
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to create share link", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusCreated, "success", "Share link created successfully", link, nil)
+}
+
+// GetShareLinks handles GET /notes/{id}/shares
+func (h *ShareHandler) GetShareLinks(w http.ResponseWriter, r *http.Request) {
+	// Get note ID from URL
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	// Get share links
+	links, err := h.shareService.GetShareLinks(r.Context(), noteID, userID)
+	if err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to get share links", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusOK, "success", "Share links retrieved successfully", links, nil)
+}
+
+// RevokeShareLink handles DELETE /notes/{id}/shares/{shareId}
+func (h *ShareHandler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	// Get note and share link IDs from URL
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid note ID", nil, err.Error())
+		return
+	}
+	linkID, err := uuid.Parse(chi.URLParam(r, "shareId"))
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, "error", "Invalid share link ID", nil, err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		sendResponse(w, http.StatusUnauthorized, "error", "User not authenticated", nil, nil)
+		return
+	}
+
+	// Revoke share link
+	if err := h.shareService.RevokeShareLink(r.Context(), noteID, linkID, userID); err != nil {
+		if err.Error() == "note not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Note not found", nil, nil)
+			return
+		}
+		if err.Error() == "share link not found" {
+			sendResponse(w, http.StatusNotFound, "error", "Share link not found", nil, nil)
+			return
+		}
+		sendResponse(w, http.StatusInternalServerError, "error", "Failed to revoke share link", nil, err.Error())
+		return
+	}
+
+	// Send response
+	sendResponse(w, http.StatusOK, "success", "Share link revoked successfully", nil, nil)
+}
+
+// OpenShareLink handles GET and POST /s/{slug}. API clients send the password
+// of a protected link in the X-Share-Password header or a JSON body; browsers
+// get HTML and post the password form.
+func (h *ShareHandler) OpenShareLink(w http.ResponseWriter, r *http.Request) {
+	password := r.Header.Get(sharePasswordHeader)
+	if r.Method == http.MethodPost {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			var body struct {
+				Password string `json:"password"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				sendResponse(w, http.StatusBadRequest, "error", "Invalid request body", nil, err.Error())
+				return
+			}
+			password = body.Password
+		} else {
+			password = r.FormValue("password")
+		}
+	}
+
+	// Shared notes are not meant to be indexed
+	w.Header().Set("X-Robots-Tag", "noindex")
+	html := strings.Contains(r.Header.Get("Accept"), "text/html")
+
+	note, err := h.shareService.OpenShareLink(r.Context(), chi.URLParam(r, "slug"), password)
+	if err != nil {
+		code, message := http.StatusInternalServerError, "Failed to open share link"
+		switch err.Error() {
+		case "share link not found":
+			code, message = http.StatusNotFound, "Share link not found"
+		case "share link expired":
+			code, message = http.StatusGone, "Share link is no longer available"
+		case "password required":
+			code, message = http.StatusUnauthorized, "Password required"
+		case "incorrect password":
+			code, message = http.StatusForbidden, "Incorrect password"
+		}
+
+		if html {
+			protected := code == http.StatusUnauthorized || code == http.StatusForbidden
+			renderSharedNote(w, code, sharedNotePage{Password: protected, Message: message})
+			return
+		}
+		var details interface{}
+		if code == http.StatusInternalServerError {
+			details = err.Error()
+		}
+		sendResponse(w, code, "error", message, nil, details)
+		return
+	}
+
+	if html {
+		renderSharedNote(w, http.StatusOK, sharedNotePage{Note: note})
+		return
+	}
+	sendResponse(w, http.StatusOK, "success", "Note retrieved successfully", note, nil)
+}
+
+// renderSharedNote writes the HTML page of a share link
+func renderSharedNote(w http.ResponseWriter, code int, page sharedNotePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	sharedNoteTemplate.Execute(w, page)
+}
@@ -0,0 +1,176 @@
+// Package lifecycle coordinates background workers and the ordered shutdown
+// of the server and its dependencies.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Phase orders shutdown hooks. Hooks run phase by phase in ascending order.
+type Phase int
+
+const (
+	// PhaseHTTP stops accepting connections and drains in-flight requests
+	PhaseHTTP Phase = iota
+	// PhaseFlush flushes buffered writers such as view counts
+	PhaseFlush
+	// PhaseWorkers stops background jobs started with Go
+	PhaseWorkers
+	// PhaseClose closes writers that background jobs may still use, such as
+	// the audit log written by scheduled purges
+	PhaseClose
+	// PhaseStore closes the key-value store (Redis)
+	PhaseStore
+	// PhaseDatabase closes the database
+	PhaseDatabase
+)
+
+// String returns the phase name used in logs
+func (p Phase) String() string {
+	switch p {
+	case PhaseHTTP:
+		return "http"
+	case PhaseFlush:
+		return "flush"
+	case PhaseWorkers:
+		return "workers"
+	case PhaseClose:
+		return "close"
+	case PhaseStore:
+		return "store"
+	case PhaseDatabase:
+		return "database"
+	default:
+		return fmt.Sprintf("phase-%d", int(p))
+	}
+}
+
+// hook is a named shutdown step
+type hook struct {
+	phase Phase
+	name  string
+	fn    func(ctx context.Context) error
+}
+
+// Manager tracks readiness and runs shutdown hooks in order
+type Manager struct {
+	shuttingDown atomic.Bool
+
+	mu    sync.Mutex
+	hooks []hook
+
+	// Background workers share a context cancelled in PhaseWorkers
+	workerCtx    context.Context
+	stopWorkers  context.CancelFunc
+	workers      sync.WaitGroup
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewManager creates a lifecycle manager
+func NewManager() *Manager {
+	m := &Manager{}
+	m.workerCtx, m.stopWorkers = context.WithCancel(context.Background())
+	m.OnShutdown(PhaseWorkers, "background workers", m.waitWorkers)
+	return m
+}
+
+// ShuttingDown reports whether shutdown has started. Readiness probes should
+// fail from that moment so load balancers stop routing new traffic.
+func (m *Manager) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// OnShutdown registers fn to run during phase. Hooks of the same phase run
+// in reverse registration order, like deferred calls.
+func (m *Manager) OnShutdown(phase Phase, name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{phase: phase, name: name, fn: fn})
+}
+
+// Go runs a background worker. Its context is cancelled during PhaseWorkers
+// and shutdown waits for fn to return.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn(m.workerCtx)
+		if m.workerCtx.Err() == nil {
+			slog.Warn("Background worker exited", "worker", name)
+		}
+	}()
+}
+
+// Shutdown flips readiness, waits delay so load balancers notice, then runs
+// the shutdown hooks phase by phase. Hooks share ctx, whose deadline bounds
+// the whole shutdown. Only the first call has an effect.
+func (m *Manager) Shutdown(ctx context.Context, delay time.Duration) error {
+	m.shutdownOnce.Do(func() {
+		m.shuttingDown.Store(true)
+
+		if delay > 0 {
+			slog.Info("Readiness failing, waiting before draining", "delay", delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+
+		m.shutdownErr = m.runHooks(ctx)
+	})
+	return m.shutdownErr
+}
+
+// runHooks runs all registered hooks ordered by phase
+func (m *Manager) runHooks(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := make([]hook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.Unlock()
+
+	var errs []error
+	for phase := PhaseHTTP; phase <= PhaseDatabase; phase++ {
+		for i := len(hooks) - 1; i >= 0; i-- {
+			h := hooks[i]
+			if h.phase != phase {
+				continue
+			}
+
+			start := time.Now()
+			if err := h.fn(ctx); err != nil {
+				slog.Error("Shutdown step failed", "phase", phase.String(), "step", h.name, "error", err)
+				errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+				continue
+			}
+			slog.Info("Shutdown step done", "phase", phase.String(), "step", h.name, "duration", time.Since(start).Round(time.Millisecond))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// waitWorkers cancels background workers and waits for them to return
+func (m *Manager) waitWorkers(ctx context.Context) error {
+	m.stopWorkers()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManager_ShutdownOrder(t *testing.T) {
+	m := NewManager()
+
+	var order []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m.OnShutdown(PhaseDatabase, "postgres", record("postgres"))
+	m.OnShutdown(PhaseStore, "redis", record("redis"))
+	m.OnShutdown(PhaseClose, "audit", record("audit"))
+	m.OnShutdown(PhaseFlush, "views", record("views"))
+	m.OnShutdown(PhaseHTTP, "http", func(ctx context.Context) error {
+		if !m.ShuttingDown() {
+			t.Error("expected readiness to fail before draining")
+		}
+		order = append(order, "http")
+		return nil
+	})
+	m.OnShutdown(PhaseWorkers, "first", record("first"))
+	m.OnShutdown(PhaseWorkers, "second", record("second"))
+
+	if err := m.Shutdown(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"http", "views", "second", "first", "audit", "redis", "postgres"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected order %v, got %v", expected, order)
+	}
+
+	// Later calls are no-ops
+	m.Shutdown(context.Background(), 0)
+	if len(order) != len(expected) {
+		t.Errorf("expected hooks to run once, got %v", order)
+	}
+}
+
+func TestManager_StopsWorkers(t *testing.T) {
+	m := NewManager()
+
+	stopped := make(chan struct{})
+	m.Go("ticker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	closedAfterWorkers := false
+	m.OnShutdown(PhaseStore, "redis", func(ctx context.Context) error {
+		select {
+		case <-stopped:
+			closedAfterWorkers = true
+		default:
+		}
+		return nil
+	})
+
+	if err := m.Shutdown(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !closedAfterWorkers {
+		t.Error("expected workers to stop before the store is closed")
+	}
+}
+
+func TestManager_ShutdownDeadline(t *testing.T) {
+	m := NewManager()
+	m.Go("stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+
+	failing := errors.New("close failed")
+	m.OnShutdown(PhaseDatabase, "postgres", func(ctx context.Context) error {
+		return failing
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error for stuck worker, got %v", err)
+	}
+	if !errors.Is(err, failing) {
+		t.Errorf("expected later phases to still run, got %v", err)
+	}
+}
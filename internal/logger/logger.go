@@ -0,0 +1,176 @@
+// Package logger configures the slog-based application logger and carries
+// request-scoped fields through context.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gonotes/internal/config"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces the value of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+// jwtRegex matches JSON Web Tokens embedded in log values
+var jwtRegex = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// Fields are request-scoped values added to every record logged with the
+// request context. They are mutable so that later middleware (e.g. auth)
+// can fill in values that earlier middleware could not know.
+type Fields struct {
+	mu        sync.Mutex
+	requestID string
+	userID    string
+	clientIP  string
+}
+
+type fieldsKey struct{}
+
+// NewContext returns a context carrying fresh request fields
+func NewContext(ctx context.Context, requestID, clientIP string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &Fields{requestID: requestID, clientIP: clientIP})
+}
+
+// SetUserID records the authenticated user on the request fields in ctx
+func SetUserID(ctx context.Context, userID string) {
+	if f, ok := ctx.Value(fieldsKey{}).(*Fields); ok {
+		f.mu.Lock()
+		f.userID = userID
+		f.mu.Unlock()
+	}
+}
+
+// UserID returns the authenticated user recorded in ctx, if any
+func UserID(ctx context.Context) string {
+	if f, ok := ctx.Value(fieldsKey{}).(*Fields); ok {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.userID
+	}
+	return ""
+}
+
+// New creates a logger writing to w in the configured format and level
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (want json or text)", format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Setup creates the logger from config and installs it as the slog default,
+// which also routes the standard log package through it
+func Setup(cfg *config.Config) (*slog.Logger, error) {
+	logger, err := New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// contextHandler adds request fields, route and trace IDs from the context
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds context attributes to the record
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*Fields); ok {
+		f.mu.Lock()
+		if f.requestID != "" {
+			r.AddAttrs(slog.String("request_id", f.requestID))
+		}
+		if f.userID != "" {
+			r.AddAttrs(slog.String("user_id", f.userID))
+		}
+		if f.clientIP != "" {
+			r.AddAttrs(slog.String("client_ip", f.clientIP))
+		}
+		f.mu.Unlock()
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			r.AddAttrs(slog.String("route", pattern))
+		}
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context handler when attributes are added
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context handler when a group is opened
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// redact hides sensitive attributes and JWTs embedded in string values
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+
+	var value string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		value = a.Value.String()
+	case slog.KindAny:
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			return a
+		}
+		value = err.Error()
+	default:
+		return a
+	}
+
+	if strings.Contains(value, "eyJ") {
+		return slog.String(a.Key, jwtRegex.ReplaceAllString(value, Redacted))
+	}
+	return a
+}
@@ -0,0 +1,222 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gonotes"
+
+// Registry holds all application metrics plus Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts requests by chi route pattern, method and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration observes request latency by chi route pattern and method
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// HTTPInFlight tracks requests currently being served
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	// DBQueryDuration observes query latency per repository method
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database latency per repository method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	// DBReplicaUp reports whether each read replica is currently used for reads
+	DBReplicaUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_up",
+		Help:      "Whether a read replica is reachable and within the lag limit (1) or skipped (0).",
+	}, []string{"replica"})
+
+	// DBReads counts routed read-only queries by target (primary or replica)
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "routed_reads_total",
+		Help:      "Read-only queries by the database they were routed to.",
+	}, []string{"target"})
+
+	// RedisCommandDuration observes Redis command latency
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Redis command latency by command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+	}, []string{"command"})
+
+	// RedisErrors counts failed Redis commands
+	RedisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "errors_total",
+		Help:      "Failed Redis commands by command.",
+	}, []string{"command"})
+
+	// CacheRequests counts read-through cache lookups by cache and result
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Cache lookups by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	// RateLimitRejections counts requests rejected by a rate limit policy
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by rate limiting, by policy.",
+	}, []string{"policy"})
+
+	// NotesCreated counts created notes
+	NotesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notes_created_total",
+		Help:      "Notes created.",
+	})
+
+	// NoteViews counts note views by whether they were counted or deduplicated
+	NoteViews = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "note_views_total",
+		Help:      "Note views by non-owners, by result (counted or duplicate).",
+	}, []string{"result"})
+
+	// Logins counts successful logins
+	Logins = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Successful logins.",
+	})
+
+	// FailedLogins counts rejected login attempts by reason
+	FailedLogins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_logins_total",
+		Help:      "Rejected login attempts by reason.",
+	}, []string{"reason"})
+
+	// SessionsRevoked counts sessions invalidated by logout or revocation
+	SessionsRevoked = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_revoked_total",
+		Help:      "Sessions revoked, by how they were revoked.",
+	}, []string{"reason"})
+
+	// OutboxDeliveries counts outbox message deliveries by kind and result
+	OutboxDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "deliveries_total",
+		Help:      "Outbox message deliveries by kind and result (delivered or failed).",
+	}, []string{"kind", "result"})
+
+	// JobRuns counts scheduled job runs by job and result
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "runs_total",
+		Help:      "Scheduled job runs on this instance by job and result (succeeded or failed).",
+	}, []string{"job", "result"})
+
+	// JobDuration observes how long scheduled job runs take
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "run_duration_seconds",
+		Help:      "Scheduled job run duration by job.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"job"})
+
+	// QueueJobs counts queued jobs by type and outcome
+	QueueJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "jobs_total",
+		Help:      "Queued jobs by type and outcome (enqueued, succeeded, retried, released or dead).",
+	}, []string{"type", "result"})
+
+	// QueueJobDuration observes how long queued jobs take to run
+	QueueJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "job_duration_seconds",
+		Help:      "Queued job run duration by type.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"type"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		DBQueryDuration,
+		DBReplicaUp,
+		DBReads,
+		RedisCommandDuration,
+		RedisErrors,
+		CacheRequests,
+		RateLimitRejections,
+		NotesCreated,
+		NoteViews,
+		Logins,
+		FailedLogins,
+		SessionsRevoked,
+		OutboxDeliveries,
+		JobRuns,
+		JobDuration,
+		QueueJobs,
+		QueueJobDuration,
+	)
+}
+
+// RegisterDB exposes connection pool statistics of db under the given name
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveQuery records the duration of a repository method. Use as
+// defer metrics.ObserveQuery("notes", "Create", time.Now())
+func ObserveQuery(repository, method string, start time.Time) {
+	DBQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
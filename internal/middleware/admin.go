@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminTokenHeader carries the operator token for admin and diagnostic endpoints
+const AdminTokenHeader = "X-Admin-Token"
+
+// IsAdminRequest reports whether the request carries the admin token.
+// An empty token disables admin access entirely.
+func IsAdminRequest(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	provided := r.Header.Get(AdminTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// RequireAdminToken rejects requests that do not carry the admin token
+func RequireAdminToken(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !IsAdminRequest(r, token) {
+				sendErrorResponse(w, http.StatusUnauthorized, "Admin token required", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
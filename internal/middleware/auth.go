@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"gonotes/internal/config"
+	"gonotes/internal/logger"
 	"gonotes/internal/service"
 	"gonotes/internal/utils"
 
@@ -76,7 +77,7 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		}
 
 		// Validate access token
-		claims, err := am.sessionService.ValidateAccessToken(token)
+		claims, err := am.sessionService.ValidateAccessToken(r.Context(), token)
 		if err != nil {
 			sendErrorResponse(w, http.StatusUnauthorized, "Invalid or expired token", err.Error())
 			return
@@ -85,6 +86,7 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		// Add claims to context
 		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
 		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		logger.SetUserID(ctx, claims.UserID.String())
 
 		// Continue to next handler with user context
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -111,7 +113,7 @@ func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 		}
 
 		// Try to validate access token
-		claims, err := am.sessionService.ValidateAccessToken(token)
+		claims, err := am.sessionService.ValidateAccessToken(r.Context(), token)
 		if err != nil {
 			// Invalid token, continue without authentication
 			next.ServeHTTP(w, r)
@@ -121,11 +123,28 @@ func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 		// Valid token, add claims to context
 		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
 		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		logger.SetUserID(ctx, claims.UserID.String())
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// Identify resolves the user behind a request's bearer token without
+// rejecting the request, for middleware that runs before route-level auth
+func (am *AuthMiddleware) Identify(r *http.Request) (uuid.UUID, bool) {
+	token := utils.ExtractTokenFromHeader(r.Header.Get("Authorization"))
+	if token == "" {
+		return uuid.Nil, false
+	}
+
+	claims, err := am.sessionService.ValidateAccessToken(r.Context(), token)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return claims.UserID, true
+}
+
 // GetUserClaims extracts user claims from request context
 func GetUserClaims(r *http.Request) (*utils.JWTClaims, bool) {
 	claims, ok := r.Context().Value(UserClaimsKey).(*utils.JWTClaims)
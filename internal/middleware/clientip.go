@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"gonotes/internal/utils"
+)
+
+// ClientIPKey is the context key for the resolved client IP
+const ClientIPKey ContextKey = "client_ip"
+
+// ClientIPMiddleware resolves the client IP once per request using the
+// trusted proxy configuration and stores it in the request context
+func ClientIPMiddleware(resolver *utils.IPResolver) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ClientIPKey, resolver.ClientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientIP returns the client IP resolved by ClientIPMiddleware. When the
+// middleware is not installed, the immediate peer address is used and no
+// forwarding headers are trusted.
+func GetClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPKey).(string); ok && ip != "" {
+		return ip
+	}
+
+	if ip := utils.PeerIP(r); ip != nil {
+		return ip.String()
+	}
+
+	return r.RemoteAddr
+}
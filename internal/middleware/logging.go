@@ -2,14 +2,26 @@ package middleware
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"gonotes/internal/logger"
+
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/google/uuid"
 )
 
+// LogContextMiddleware attaches request fields (request ID, client IP, and
+// later the user ID) to the request context so every log record made while
+// serving the request can be correlated
+func LogContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logger.NewContext(r.Context(), middleware.GetReqID(r.Context()), GetClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // LoggingMiddleware creates a structured logging middleware
 func LoggingMiddleware() func(next http.Handler) http.Handler {
 	return middleware.RequestLogger(&StructuredLogger{})
@@ -20,91 +32,46 @@ type StructuredLogger struct{}
 
 // NewLogEntry creates a new log entry for each request
 func (l *StructuredLogger) NewLogEntry(r *http.Request) middleware.LogEntry {
-	entry := &StructuredLoggerEntry{
+	return &StructuredLoggerEntry{
 		request: r,
 	}
-
-	// Extract user information if available
-	if userID, ok := GetUserID(r); ok {
-		entry.userID = &userID
-	}
-
-	// Extract client information
-	entry.userAgent = r.Header.Get("User-Agent")
-	entry.clientIP = getClientIP(r)
-
-	return entry
 }
 
 // StructuredLoggerEntry represents a single log entry
 type StructuredLoggerEntry struct {
-	request   *http.Request
-	userID    *uuid.UUID
-	userAgent string
-	clientIP  string
+	request *http.Request
 }
 
-// Write logs the completed request
+// Write logs the completed request. Request ID, user ID, client IP and route
+// are added from the request context by the logger.
 func (l *StructuredLoggerEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
-	method := l.request.Method
-	uri := l.request.RequestURI
-	proto := l.request.Proto
-
 	// Determine log level based on status code
-	level := "INFO"
+	level := slog.LevelInfo
 	if status >= 400 && status < 500 {
-		level = "WARN"
+		level = slog.LevelWarn
 	} else if status >= 500 {
-		level = "ERROR"
+		level = slog.LevelError
 	}
 
-	// Build log message
-	logMsg := fmt.Sprintf("[%s] %s %s %s - %d - %dB - %v",
-		level,
-		method,
-		uri,
-		proto,
-		status,
-		bytes,
-		elapsed,
+	slog.LogAttrs(l.request.Context(), level, "request completed",
+		slog.String("method", l.request.Method),
+		slog.String("path", l.request.URL.Path),
+		slog.String("proto", l.request.Proto),
+		slog.Int("status", status),
+		slog.Int("bytes", bytes),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.String("user_agent", l.request.UserAgent()),
 	)
-
-	// Add user context if available
-	if l.userID != nil {
-		logMsg += fmt.Sprintf(" - UserID: %s", l.userID.String())
-	}
-
-	// Add client info
-	logMsg += fmt.Sprintf(" - IP: %s", l.clientIP)
-
-	// Add user agent for non-browser requests
-	if l.userAgent != "" && !strings.Contains(strings.ToLower(l.userAgent), "browser") {
-		logMsg += fmt.Sprintf(" - UA: %s", l.userAgent)
-	}
-
-	// Print the log (in production, this would go to a proper logger)
-	fmt.Println(logMsg)
 }
 
 // Panic logs panic information
 func (l *StructuredLoggerEntry) Panic(v interface{}, stack []byte) {
-	method := l.request.Method
-	uri := l.request.RequestURI
-
-	logMsg := fmt.Sprintf("[PANIC] %s %s - %v",
-		method,
-		uri,
-		v,
+	slog.ErrorContext(l.request.Context(), "request panicked",
+		"method", l.request.Method,
+		"path", l.request.URL.Path,
+		"panic", fmt.Sprint(v),
+		"stack", string(stack),
 	)
-
-	if l.userID != nil {
-		logMsg += fmt.Sprintf(" - UserID: %s", l.userID.String())
-	}
-
-	logMsg += fmt.Sprintf(" - IP: %s", l.clientIP)
-
-	// Print panic log with stack trace
-	fmt.Printf("%s\nStack Trace:\n%s\n", logMsg, string(stack))
 }
 
 // AuditLogMiddleware creates an audit logging middleware for sensitive operations
@@ -152,65 +119,22 @@ func shouldAuditLog(r *http.Request) bool {
 
 // logAuditEvent logs an audit event
 func logAuditEvent(r *http.Request, event string, extra ...map[string]interface{}) {
-	timestamp := time.Now().UTC().Format(time.RFC3339)
-	userID := "anonymous"
-
-	if uid, ok := GetUserID(r); ok {
-		userID = uid.String()
+	attrs := []slog.Attr{
+		slog.String("event", event),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("user_agent", r.UserAgent()),
 	}
 
-	clientIP := getClientIP(r)
-	userAgent := r.Header.Get("User-Agent")
-	method := r.Method
-	path := r.URL.Path
-
-	auditLog := fmt.Sprintf("[AUDIT] %s - %s - User: %s - %s %s - IP: %s",
-		timestamp,
-		event,
-		userID,
-		method,
-		path,
-		clientIP,
-	)
-
 	// Add extra information if provided
 	if len(extra) > 0 {
 		for key, value := range extra[0] {
-			auditLog += fmt.Sprintf(" - %s: %v", key, value)
-		}
-	}
-
-	// Add user agent for important operations
-	if userAgent != "" {
-		auditLog += fmt.Sprintf(" - UA: %s", userAgent)
-	}
-
-	// Print audit log (in production, this would go to a secure audit log system)
-	fmt.Println(auditLog)
-}
-
-// getClientIP extracts the real client IP address
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP in the chain
-		if ips := strings.Split(xff, ","); len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
+			attrs = append(attrs, slog.Any(key, value))
 		}
 	}
 
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Fall back to remote address
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
-	}
-
-	return ip
+	// Request fields (user, client IP) come from the request context
+	slog.LogAttrs(r.Context(), slog.LevelInfo, "audit", attrs...)
 }
 
 // SecurityHeadersMiddleware adds security headers to responses
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gonotes/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// MetricsMiddleware records request counts and latency by chi route pattern.
+// Patterns keep label cardinality bounded regardless of IDs in the path.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routePattern returns the chi route pattern of r. The pattern is only
+// complete once routing has finished; a request answered earlier, e.g.
+// rejected by the rate limiter, has no pattern or only that of the router it
+// was mounted on, and is matched against the routes here.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "unmatched"
+	}
+
+	pattern := rctx.RoutePattern()
+	if (pattern == "" || strings.HasSuffix(pattern, "*")) && rctx.Routes != nil {
+		match := chi.NewRouteContext()
+		if rctx.Routes.Match(match, r.Method, r.URL.Path) {
+			pattern = match.RoutePattern()
+		}
+	}
+	if pattern == "" {
+		return "unmatched"
+	}
+	return pattern
+}
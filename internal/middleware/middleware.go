@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -10,10 +10,7 @@ import (
 
 // Logger middleware for request logging
 func Logger(next http.Handler) http.Handler {
-	return middleware.RequestLogger(&middleware.DefaultLogFormatter{
-		Logger:  log.New(log.Writer(), "", log.LstdFlags),
-		NoColor: false,
-	})(next)
+	return middleware.RequestLogger(&StructuredLogger{})(next)
 }
 
 // Recoverer middleware for panic recovery
@@ -31,14 +28,13 @@ func CustomLogger(next http.Handler) http.Handler {
 
 		defer func() {
 			duration := time.Since(start)
-			log.Printf(
-				"[%s] %s %s %d %s %s",
-				r.Method,
-				r.URL.Path,
-				r.Proto,
-				ww.Status(),
-				duration,
-				r.RemoteAddr,
+			slog.InfoContext(r.Context(), "request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"proto", r.Proto,
+				"status", ww.Status(),
+				"duration", duration,
+				"remote_addr", r.RemoteAddr,
 			)
 		}()
 
@@ -4,48 +4,79 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"gonotes/internal/config"
+	"gonotes/internal/metrics"
+	"gonotes/internal/ratelimit"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
 )
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
-	// Global rate limits (per IP)
-	GlobalRequestsPerMinute int
-	GlobalBurstSize         int
-
-	// Authenticated user rate limits
-	UserRequestsPerMinute int
-	UserBurstSize         int
+	// Policies selects the policy applied to each request
+	Policies *ratelimit.Config
 
-	// Endpoint-specific rate limits
-	AuthEndpointRequestsPerMinute int
-	AuthEndpointBurstSize         int
+	// Limiter enforces the selected policy (rate limiting is disabled when nil)
+	Limiter ratelimit.Limiter
 
-	// Redis client for distributed rate limiting
-	RedisClient *redis.Client
+	// Identify resolves the authenticated user for a request. The rate limiter
+	// runs before route-level authentication, so it cannot rely on the context.
+	Identify func(r *http.Request) (uuid.UUID, bool)
 }
 
 // DefaultRateLimitConfig returns default rate limit configuration
-func DefaultRateLimitConfig(redisClient *redis.Client) *RateLimitConfig {
-	return &RateLimitConfig{
-		GlobalRequestsPerMinute:       100,
-		GlobalBurstSize:               20,
-		UserRequestsPerMinute:         300,
-		UserBurstSize:                 50,
-		AuthEndpointRequestsPerMinute: 10,
-		AuthEndpointBurstSize:         5,
-		RedisClient:                   redisClient,
+func DefaultRateLimitConfig(st store.Store) *RateLimitConfig {
+	config := &RateLimitConfig{
+		Policies: ratelimit.DefaultConfig(),
+	}
+	if st != nil {
+		config.Limiter = ratelimit.NewStoreLimiter(st)
+	}
+	return config
+}
+
+// NewRateLimitConfig builds rate limit configuration from application config.
+// RATE_LIMIT_REQUESTS and RATE_LIMIT_WINDOW override the anonymous policy and
+// RATE_LIMIT_CONFIG points to an optional JSON file with routes and plans.
+func NewRateLimitConfig(cfg *config.Config, limiter ratelimit.Limiter) (*RateLimitConfig, error) {
+	policies := ratelimit.DefaultConfig()
+
+	anonymous := policies.Policies[policies.Anonymous]
+	if cfg.RateLimitRequests > 0 {
+		anonymous.Limit = cfg.RateLimitRequests
+	}
+	if cfg.RateLimitWindow > 0 {
+		anonymous.Window = ratelimit.Duration{Duration: cfg.RateLimitWindow}
+	}
+	policies.Policies[policies.Anonymous] = anonymous
+
+	if cfg.RateLimitConfigFile != "" {
+		loaded, err := ratelimit.LoadConfig(cfg.RateLimitConfigFile, policies)
+		if err != nil {
+			return nil, err
+		}
+		policies = loaded
+	} else if err := policies.Validate(); err != nil {
+		return nil, err
 	}
+
+	return &RateLimitConfig{
+		Policies: policies,
+		Limiter:  limiter,
+	}, nil
 }
 
 // RateLimitMiddleware creates a rate limiting middleware
 func RateLimitMiddleware(config *RateLimitConfig) func(next http.Handler) http.Handler {
-	if config.RedisClient == nil {
-		// If no Redis client, skip rate limiting
+	if config.Limiter == nil {
+		// If no limiter, skip rate limiting
 		return func(next http.Handler) http.Handler {
 			return next
 		}
@@ -53,143 +84,56 @@ func RateLimitMiddleware(config *RateLimitConfig) func(next http.Handler) http.H
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Determine rate limit parameters based on request
-			key, limit, burst := getRateLimitParams(r, config)
+			// Determine rate limit policy based on request
+			decision := getRateLimitDecision(r, config)
 
 			// Check rate limit
-			allowed, resetTime, err := checkRateLimit(config.RedisClient, key, limit, burst)
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			result, err := config.Limiter.Allow(ctx, decision.Key, decision.Policy)
+			cancel()
 			if err != nil {
-				// Log error but don't block request if Redis is down
-				fmt.Printf("Rate limit check failed: %v\n", err)
+				// Log error but don't block request if the store is down
+				slog.WarnContext(r.Context(), "Rate limit check failed, allowing request", "policy", decision.Policy.Name, "error", err)
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			if !allowed {
-				// Set rate limit headers
-				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
-				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(resetTime).Seconds())))
+			// Set rate limit headers
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+			w.Header().Set("X-RateLimit-Policy", decision.Policy.Name)
+
+			if !result.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(decision.Policy.Name).Inc()
+
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, retryAfter)))
 
 				// Return rate limit error
 				sendRateLimitError(w)
 				return
 			}
 
-			// Set rate limit headers for successful requests
-			remaining, _ := getRemainingRequests(config.RedisClient, key, limit)
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
-
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// getRateLimitParams determines the rate limit key and parameters for a request
-func getRateLimitParams(r *http.Request, config *RateLimitConfig) (string, int, int) {
-	clientIP := getClientIP(r)
-
-	// Check if this is an auth endpoint
-	if isAuthEndpoint(r.URL.Path) {
-		key := fmt.Sprintf("rate_limit:auth:%s", clientIP)
-		return key, config.AuthEndpointRequestsPerMinute, config.AuthEndpointBurstSize
-	}
-
-	// Check if user is authenticated
-	if userID, ok := GetUserID(r); ok {
-		key := fmt.Sprintf("rate_limit:user:%s", userID.String())
-		return key, config.UserRequestsPerMinute, config.UserBurstSize
-	}
+// getRateLimitDecision determines the rate limit key and policy for a request
+func getRateLimitDecision(r *http.Request, config *RateLimitConfig) ratelimit.Decision {
+	clientIP := GetClientIP(r)
 
-	// Default to IP-based rate limiting
-	key := fmt.Sprintf("rate_limit:ip:%s", clientIP)
-	return key, config.GlobalRequestsPerMinute, config.GlobalBurstSize
-}
-
-// isAuthEndpoint checks if the request is to an authentication endpoint
-func isAuthEndpoint(path string) bool {
-	authPaths := []string{
-		"/api/v1/auth/login",
-		"/api/v1/auth/register",
-		"/api/v1/auth/refresh",
-	}
-
-	for _, authPath := range authPaths {
-		if path == authPath {
-			return true
+	userID := ""
+	if id, ok := GetUserID(r); ok {
+		userID = id.String()
+	} else if config.Identify != nil {
+		if id, ok := config.Identify(r); ok {
+			userID = id.String()
 		}
 	}
 
-	return false
-}
-
-// checkRateLimit implements sliding window rate limiting using Redis
-func checkRateLimit(redisClient *redis.Client, key string, limit, burst int) (bool, time.Time, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	now := time.Now()
-	windowStart := now.Add(-time.Minute)
-
-	// Redis Lua script for atomic rate limiting check
-	script := `
-		local key = KEYS[1]
-		local window_start = tonumber(ARGV[1])
-		local now = tonumber(ARGV[2])
-		local limit = tonumber(ARGV[3])
-		local burst = tonumber(ARGV[4])
-		
-		-- Remove expired entries
-		redis.call('ZREMRANGEBYSCORE', key, 0, window_start)
-		
-		-- Count current requests in window
-		local current = redis.call('ZCARD', key)
-		
-		-- Check if we can make the request
-		if current < limit then
-			-- Add current request
-			redis.call('ZADD', key, now, now)
-			redis.call('EXPIRE', key, 60)
-			return {1, current + 1}
-		else
-			return {0, current}
-		end
-	`
-
-	result, err := redisClient.Eval(ctx, script, []string{key},
-		windowStart.Unix(), now.Unix(), limit, burst).Result()
-	if err != nil {
-		return false, now.Add(time.Minute), err
-	}
-
-	resultSlice := result.([]interface{})
-	allowed := resultSlice[0].(int64) == 1
-
-	// Calculate reset time (next minute boundary)
-	resetTime := now.Truncate(time.Minute).Add(time.Minute)
-
-	return allowed, resetTime, nil
-}
-
-// getRemainingRequests gets the number of remaining requests in the current window
-func getRemainingRequests(redisClient *redis.Client, key string, limit int) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	current, err := redisClient.ZCard(ctx, key).Result()
-	if err != nil {
-		return limit, err
-	}
-
-	remaining := limit - int(current)
-	if remaining < 0 {
-		remaining = 0
-	}
-
-	return remaining, nil
+	return config.Policies.Resolve(r.Method, r.URL.Path, clientIP, userID)
 }
 
 // sendRateLimitError sends a rate limit exceeded error response
@@ -222,7 +166,7 @@ func IPWhitelistMiddleware(whitelistedIPs []string) func(next http.Handler) http
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := getClientIP(r)
+			clientIP := GetClientIP(r)
 
 			if !ipMap[clientIP] {
 				response := map[string]interface{}{
@@ -244,8 +188,8 @@ func IPWhitelistMiddleware(whitelistedIPs []string) func(next http.Handler) http
 }
 
 // DDoSProtectionMiddleware creates basic DDoS protection middleware
-func DDoSProtectionMiddleware(redisClient *redis.Client) func(next http.Handler) http.Handler {
-	if redisClient == nil {
+func DDoSProtectionMiddleware(st store.Store) func(next http.Handler) http.Handler {
+	if st == nil {
 		return func(next http.Handler) http.Handler {
 			return next
 		}
@@ -253,10 +197,12 @@ func DDoSProtectionMiddleware(redisClient *redis.Client) func(next http.Handler)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := getClientIP(r)
+			clientIP := GetClientIP(r)
 
 			// Check for suspicious patterns
-			if isSuspiciousRequest(redisClient, clientIP, r) {
+			if isSuspiciousRequest(r.Context(), st, clientIP) {
+				metrics.RateLimitRejections.WithLabelValues("ddos").Inc()
+
 				response := map[string]interface{}{
 					"status":  "error",
 					"code":    429,
@@ -276,25 +222,21 @@ func DDoSProtectionMiddleware(redisClient *redis.Client) func(next http.Handler)
 }
 
 // isSuspiciousRequest checks for suspicious request patterns
-func isSuspiciousRequest(redisClient *redis.Client, clientIP string, r *http.Request) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func isSuspiciousRequest(ctx context.Context, st store.Store, clientIP string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	// Check request frequency (more than 20 requests in 10 seconds)
 	key := fmt.Sprintf("ddos_protection:%s", clientIP)
-	current, err := redisClient.Incr(ctx, key).Result()
+	current, err := st.Incr(ctx, key, 10*time.Second)
 	if err != nil {
 		return false
 	}
 
-	if current == 1 {
-		redisClient.Expire(ctx, key, 10*time.Second)
-	}
-
 	// If more than 20 requests in 10 seconds, consider suspicious
 	if current > 20 {
 		// Extend the block time
-		redisClient.Expire(ctx, key, 60*time.Second)
+		st.Expire(ctx, key, 60*time.Second)
 		return true
 	}
 
@@ -0,0 +1,551 @@
+// Package migrate applies the embedded SQL migrations and records them in the
+// schema_migrations table. PostgreSQL and SQLite are supported.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// lockID is the advisory lock key held while migrating so that replicas
+// starting at the same time do not race each other
+const lockID int64 = 7_243_911_062
+
+// ErrChecksumMismatch is returned when an applied migration no longer matches its file
+var ErrChecksumMismatch = errors.New("applied migration has been modified")
+
+// ErrNotInitialized is returned by Status when schema_migrations does not exist
+var ErrNotInitialized = errors.New("database not initialised: schema_migrations does not exist")
+
+var fileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with its up and down SQL
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up SQL
+}
+
+// State is the state of a migration relative to the database
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateApplied  State = "applied"
+	StateModified State = "modified" // applied, but the file changed since
+	StateMissing  State = "missing"  // applied, but not known to this binary
+)
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   int
+	Name      string
+	State     State
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Dialect holds the database-specific statements used by the migrator
+type Dialect struct {
+	// lock and unlock take lockID; empty when the database serializes
+	// migrations by itself
+	lock   string
+	unlock string
+
+	// columns lists the column names of schema_migrations
+	columns string
+
+	createTable string
+}
+
+var (
+	// Postgres serializes migrations across replicas with an advisory lock
+	Postgres = Dialect{
+		lock:   "SELECT pg_advisory_lock($1)",
+		unlock: "SELECT pg_advisory_unlock($1)",
+		columns: `
+			SELECT column_name FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`,
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version BIGINT PRIMARY KEY,
+				name TEXT NOT NULL,
+				checksum TEXT NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+			)`,
+	}
+
+	// SQLite relies on the database file lock, each migration runs in its
+	// own write transaction
+	SQLite = Dialect{
+		columns: "SELECT name FROM pragma_table_info('schema_migrations')",
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				checksum TEXT NOT NULL,
+				applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	}
+)
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New creates a migrator for the migrations found in fsys
+func New(db *sql.DB, fsys fs.FS, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load reads NNN_name.up.sql / NNN_name.down.sql pairs from fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && count < steps; i-- {
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To migrates up or down so that exactly the migrations up to version are
+// applied. Version 0 rolls back everything.
+func (m *Migrator) To(ctx context.Context, version int) (int, error) {
+	if version < 0 || (version > 0 && m.find(version) == nil) {
+		return 0, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// Roll back newer migrations, most recent first
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+
+		// Apply pending migrations in order
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status reports every known and applied migration. It only reads: it
+// neither waits for the migration lock nor creates schema_migrations, and
+// returns ErrNotInitialized when the table does not exist yet.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	columns, err := m.columns(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, ErrNotInitialized
+	}
+	if columns["dirty"] && !columns["checksum"] {
+		return nil, errors.New("schema_migrations was created by golang-migrate, run migrate up to adopt it")
+	}
+
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.AppliedAt = &appliedAt
+			status.State = StateApplied
+			if row.checksum != migration.Checksum {
+				status.State = StateModified
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		if m.find(row.version) == nil {
+			appliedAt := row.appliedAt
+			statuses = append(statuses, Status{
+				Version:   row.version,
+				Name:      row.name,
+				State:     StateMissing,
+				AppliedAt: &appliedAt,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, 0 if none
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := m.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock, lockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			// Unlock even when ctx was cancelled, the lock is tied to this session
+			if _, err := conn.ExecContext(context.Background(), m.dialect.unlock, lockID); err != nil {
+				slog.Error("Failed to release migration lock", "error", err)
+			}
+		}()
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// querier is implemented by *sql.DB and *sql.Conn
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// columns returns the column names of schema_migrations, none if it does not exist
+func (m *Migrator) columns(ctx context.Context, q querier) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, m.dialect.columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to inspect schema_migrations: %w", err)
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
+
+// ensureTable creates schema_migrations, adopting a table left behind by
+// golang-migrate (version, dirty) if one exists
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	columns, err := m.columns(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if columns["dirty"] && !columns["checksum"] {
+		return m.adoptLegacy(ctx, conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// adoptLegacy replaces a golang-migrate version table, recording every
+// migration up to its version as applied
+func (m *Migrator) adoptLegacy(ctx context.Context, conn *sql.Conn) error {
+	var version int
+	var dirty bool
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read legacy schema_migrations: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("legacy schema_migrations is dirty at version %d, fix the schema manually first", version)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"DROP TABLE schema_migrations",
+		m.dialect.createTable,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to replace legacy schema_migrations: %w", err)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("Adopted legacy schema_migrations", "version", version)
+	return nil
+}
+
+// applied returns the rows of schema_migrations keyed by version
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[row.version] = row
+	}
+	return applied, rows.Err()
+}
+
+// verify returns the applied migrations, failing if any was edited after being applied
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		if row, ok := applied[migration.Version]; ok && row.checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs an up migration and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, migration.Checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+// rollback runs a down migration and removes its record in one transaction
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, version int) error {
+	migration := m.find(version)
+	if migration == nil {
+		return fmt.Errorf("applied migration %d is unknown to this binary", version)
+	}
+	if migration.Down == "" {
+		return fmt.Errorf("migration %03d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("rollback of %03d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+		return fmt.Errorf("failed to remove migration record %d: %w", version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollback %d: %w", version, err)
+	}
+
+	slog.Info("Rolled back migration", "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+// find returns the migration with the given version
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// sortedVersions returns the applied versions in ascending order
+func sortedVersions(applied map[int]appliedMigration) []int {
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
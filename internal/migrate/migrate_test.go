@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"gonotes/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_notes.up.sql":    {Data: []byte("CREATE TABLE notes ();")},
+		"002_add_notes.down.sql":  {Data: []byte("DROP TABLE notes;")},
+		"001_add_users.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"001_add_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"README.md":               {Data: []byte("not a migration")},
+		"010_seed_only_up.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	loaded, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("expected migrations to load, got %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(loaded))
+	}
+
+	expected := []int{1, 2, 10}
+	for i, m := range loaded {
+		if m.Version != expected[i] {
+			t.Errorf("expected version %d at %d, got %d", expected[i], i, m.Version)
+		}
+	}
+	if loaded[0].Name != "add_users" || loaded[0].Down != "DROP TABLE users;" {
+		t.Errorf("unexpected migration %+v", loaded[0])
+	}
+	if loaded[0].Checksum == "" || loaded[0].Checksum == loaded[1].Checksum {
+		t.Error("expected distinct checksums")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"001_users.down.sql": {Data: []byte("DROP TABLE users;")}},
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"001_users.up.sql": {Data: []byte("SELECT 1;")},
+				"001_notes.up.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	for name, fsys := range map[string]fs.FS{"postgres": migrations.FS, "sqlite": migrations.SQLiteFS} {
+		loaded, err := Load(fsys)
+		if err != nil {
+			t.Fatalf("expected embedded %s migrations to load, got %v", name, err)
+		}
+		if len(loaded) == 0 {
+			t.Errorf("no embedded %s migrations", name)
+		}
+		for i, m := range loaded {
+			if m.Version != i+1 {
+				t.Errorf("expected contiguous %s versions, got %d at %d", name, m.Version, i)
+			}
+			if m.Down == "" {
+				t.Errorf("%s migration %03d_%s has no down file", name, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, migrations.SQLiteFS, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Status only reads, so a fresh database is reported as not initialised
+	if _, err := migrator.Status(ctx); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Status() before Up error = %v, want ErrNotInitialized", err)
+	}
+
+	count, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if count != migrator.Latest() {
+		t.Errorf("applied %d migrations, want %d", count, migrator.Latest())
+	}
+	if version, err := migrator.Version(ctx); err != nil || version != migrator.Latest() {
+		t.Errorf("Version() = %d, %v, want %d", version, err, migrator.Latest())
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, s := range statuses {
+		if s.State != StateApplied || s.AppliedAt == nil {
+			t.Errorf("migration %d is %s, want applied", s.Version, s.State)
+		}
+	}
+
+	// Everything rolls back cleanly and applies again
+	if _, err := migrator.To(ctx, 0); err != nil {
+		t.Fatalf("To(0) error = %v", err)
+	}
+	if count, err := migrator.Up(ctx); err != nil || count != migrator.Latest() {
+		t.Errorf("Up() after rollback = %d, %v", count, err)
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoteViewStat is a row of the daily view rollup of a note
+type NoteViewStat struct {
+	NoteID        uuid.UUID `json:"note_id" db:"note_id"`
+	Day           time.Time `json:"day" db:"day"`           // UTC midnight
+	Referrer      string    `json:"referrer" db:"referrer"` // Domain, empty for direct views
+	Views         int64     `json:"views" db:"views"`
+	UniqueViewers int64     `json:"unique_viewers" db:"unique_viewers"`
+}
+
+// DailyViews is one day of a view series
+type DailyViews struct {
+	Date          string `json:"date"` // YYYY-MM-DD
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"unique_viewers"`
+}
+
+// ReferrerViews counts views coming from a referrer domain
+type ReferrerViews struct {
+	Referrer string `json:"referrer"` // "direct" when the view had no referrer
+	Views    int64  `json:"views"`
+}
+
+// ViewAnalytics summarizes views over the last Days days
+type ViewAnalytics struct {
+	NoteID                *uuid.UUID      `json:"note_id,omitempty"`
+	Days                  int             `json:"days"`
+	TotalViews            int64           `json:"total_views"`
+	DailyUniqueViewersSum int64           `json:"daily_unique_viewers_sum"` // A viewer is counted once per day they viewed
+	Daily                 []DailyViews    `json:"daily,omitempty"`
+	Referrers             []ReferrerViews `json:"referrers"`
+}
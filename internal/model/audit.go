@@ -59,6 +59,8 @@ const (
 	ActionNoteView   = "note_view"
 	ActionNoteUpdate = "note_update"
 	ActionNoteDelete = "note_delete"
+	ActionNotePurge  = "note_purge"
+	ActionTrashEmpty = "trash_empty"
 
 	// Security actions
 	ActionRateLimitExceeded  = "rate_limit_exceeded"
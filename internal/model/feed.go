@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feed is a list of public notes, rendered as Atom or JSON Feed
+type Feed struct {
+	Title   string
+	Author  string    // Set for per-user feeds
+	Updated time.Time // Latest update of any entry
+	Entries []FeedEntry
+}
+
+// FeedEntry is a public note in a feed
+type FeedEntry struct {
+	ID        uuid.UUID
+	Title     string
+	Content   string
+	Tags      []string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+}
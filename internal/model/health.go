@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Health statuses of a dependency check or the whole service
+const (
+	HealthStatusUp       = "up"
+	HealthStatusDown     = "down"
+	HealthStatusDegraded = "degraded"
+
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// HealthCheck is the result of checking a single dependency
+type HealthCheck struct {
+	Status    string      `json:"status"`
+	LatencyMS float64     `json:"latency_ms"`
+	Required  bool        `json:"required"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// HealthReport is the detailed health of the service and its dependencies
+type HealthReport struct {
+	Status       string                  `json:"status"`
+	ShuttingDown bool                    `json:"shutting_down"`
+	Uptime       string                  `json:"uptime"`
+	Timestamp    time.Time               `json:"timestamp"`
+	Checks       map[string]*HealthCheck `json:"checks"`
+}
+
+// Ready reports whether the service should receive traffic: not shutting
+// down and every required dependency up
+func (r *HealthReport) Ready() bool {
+	if r.ShuttingDown {
+		return false
+	}
+	for _, check := range r.Checks {
+		if check.Required && check.Status == HealthStatusDown {
+			return false
+		}
+	}
+	return true
+}
+
+// FailedChecks returns the names of required checks that are down
+func (r *HealthReport) FailedChecks() []string {
+	var failed []string
+	for name, check := range r.Checks {
+		if check.Required && check.Status == HealthStatusDown {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// DatabasePoolStats mirrors sql.DBStats for health reports
+type DatabasePoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMS     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+// MigrationStatus reports the applied schema version against the binary's
+type MigrationStatus struct {
+	Version  int `json:"version"`
+	Expected int `json:"expected"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// JobRun is one run of a scheduled maintenance job
+type JobRun struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMS  float64   `json:"duration_ms"`
+	Instance    string    `json:"instance"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// JobStatus describes a scheduled job and its recent runs, newest first.
+// Running only reflects the instance that answered.
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Timeout  string    `json:"timeout"`
+	NextRun  time.Time `json:"next_run"`
+	Running  bool      `json:"running"`
+	Runs     []JobRun  `json:"runs"`
+}
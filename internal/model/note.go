@@ -29,6 +29,10 @@ type Note struct {
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// PublishAt and UnpublishAt are applied by the scheduler, then cleared.
+	// Deleting or restoring the note clears them too.
+	PublishAt   *time.Time `json:"publish_at,omitempty" db:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty" db:"unpublish_at"`
 }
 
 // ToResponse converts Note to response format (without sensitive data)
@@ -43,6 +47,31 @@ func (n *Note) ToResponse() *NoteResponse {
 		ViewCount: n.ViewCount,
 		CreatedAt: n.CreatedAt,
 		UpdatedAt: n.UpdatedAt,
+		Schedule:  n.Schedule(),
+	}
+}
+
+// Schedule returns the pending publishing changes of the note, or nil
+func (n *Note) Schedule() *NoteSchedule {
+	if n.PublishAt == nil && n.UnpublishAt == nil {
+		return nil
+	}
+	return &NoteSchedule{
+		PublishAt:   n.PublishAt,
+		UnpublishAt: n.UnpublishAt,
+	}
+}
+
+// ToPublicResponse converts Note to the public format, credited to author
+func (n *Note) ToPublicResponse(author *User) *PublicNoteResponse {
+	return &PublicNoteResponse{
+		ID:        n.ID,
+		Title:     n.Title,
+		Content:   n.Content,
+		Tags:      n.GetTagsArray(),
+		Author:    *author.AsAuthor(),
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
 	}
 }
 
@@ -68,6 +97,7 @@ func (n *Note) ToListItem() *NoteListItem {
 		ViewCount: n.ViewCount,
 		CreatedAt: n.CreatedAt,
 		UpdatedAt: n.UpdatedAt,
+		DeletedAt: n.DeletedAt,
 	}
 }
 
@@ -115,6 +145,39 @@ type NoteResponse struct {
 	ViewCount int64      `json:"view_count"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+	// Schedule is only set while a change is pending
+	Schedule *NoteSchedule `json:"schedule,omitempty"`
+}
+
+// NoteSchedule holds the pending publishing changes of a note
+type NoteSchedule struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// ScheduleNoteRequest sets when a note is published and unpublished.
+// It replaces the whole schedule; omitted or null times are cleared.
+type ScheduleNoteRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// PublicNoteResponse represents a public note as served to anyone. It has no
+// view count, so it only changes when the note or its author does.
+type PublicNoteResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Title     string     `json:"title"`
+	Content   *string    `json:"content"`
+	Tags      []string   `json:"tags"`
+	Author    NoteAuthor `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NoteAuthor is the public information about the author of a note
+type NoteAuthor struct {
+	DisplayName string  `json:"display_name"`
+	Handle      *string `json:"handle,omitempty"` // Set while the author's profile is public
 }
 
 // NoteListItem represents a note in list view (minimal data)
@@ -128,6 +191,11 @@ type NoteListItem struct {
 	ViewCount int64      `json:"view_count"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+	// Author is only set in public listings
+	Author *NoteAuthor `json:"author,omitempty"`
+	// DeletedAt and DaysUntilPurge are only set for notes in the trash
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	DaysUntilPurge *int       `json:"days_until_purge,omitempty"`
 }
 
 // CreateNoteRequest represents a request to create a note
@@ -192,6 +260,9 @@ func (req *UpdateNoteRequest) ApplyToNote(note *Note) {
 	if req.Status != nil {
 		note.Status = NoteStatus(*req.Status)
 		// Set deleted_at when status changes to deleted
+		if note.Status == NoteStatusDeleted {
+			note.PublishAt, note.UnpublishAt = nil, nil
+		}
 		if note.Status == NoteStatusDeleted && note.DeletedAt == nil {
 			now := time.Now()
 			note.DeletedAt = &now
@@ -227,6 +298,8 @@ type GetNotesParams struct {
 	IsPublic *bool  `json:"is_public"`
 	SortBy   string `json:"sort_by" validate:"omitempty,oneof=created_at updated_at title view_count"`
 	SortDir  string `json:"sort_dir" validate:"omitempty,oneof=asc desc"`
+	// AuthorID limits public listings to the notes of one user
+	AuthorID *uuid.UUID `json:"author_id,omitempty"`
 }
 
 // SetDefaults sets default values for GetNotesParams
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// OutboxMessage is a side effect, such as a token registry update, recorded
+// in the same transaction as the change that caused it and delivered after
+// the transaction commits
+type OutboxMessage struct {
+	ID            int64      `json:"id" db:"id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Payload       []byte     `json:"payload" db:"payload"` // JSON
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	ProcessedAt   *time.Time `json:"processed_at" db:"processed_at"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// PublicProfile is the profile of a user as shown to anyone
+type PublicProfile struct {
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	Bio         *string   `json:"bio"`
+	AvatarURL   *string   `json:"avatar_url"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// ProfileResponse is a user's own view of their public profile
+type ProfileResponse struct {
+	Handle      *string `json:"handle"`
+	DisplayName string  `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+	Public      bool    `json:"public"`
+}
+
+// UpdatePublicProfileRequest represents a profile update; omitted fields are
+// unchanged and an empty bio or avatar_url clears it
+type UpdatePublicProfileRequest struct {
+	Handle    *string `json:"handle" validate:"omitempty,min=3,max=30"`
+	Bio       *string `json:"bio" validate:"omitempty,max=500"`
+	AvatarURL *string `json:"avatar_url" validate:"omitempty,max=2048"`
+	Public    *bool   `json:"public"`
+}
+
+// ToProfileResponse returns the user's own view of their public profile
+func (u *User) ToProfileResponse() *ProfileResponse {
+	return &ProfileResponse{
+		Handle:      u.Handle,
+		DisplayName: u.FullName,
+		Bio:         u.Bio,
+		AvatarURL:   u.AvatarURL,
+		Public:      u.ProfilePublic,
+	}
+}
+
+// ToPublicProfile returns the profile as shown to anyone; callers check
+// ProfilePublic first
+func (u *User) ToPublicProfile() *PublicProfile {
+	profile := &PublicProfile{
+		DisplayName: u.FullName,
+		Bio:         u.Bio,
+		AvatarURL:   u.AvatarURL,
+		JoinedAt:    u.CreatedAt,
+	}
+	if u.Handle != nil {
+		profile.Handle = *u.Handle
+	}
+	return profile
+}
+
+// AsAuthor returns the public credit of the user on their notes. The handle
+// is only included while the profile is public.
+func (u *User) AsAuthor() *NoteAuthor {
+	author := &NoteAuthor{DisplayName: u.FullName}
+	if u.ProfilePublic {
+		author.Handle = u.Handle
+	}
+	return author
+}
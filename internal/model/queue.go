@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// QueuedJob is a unit of background work on the job queue. Attempts counts
+// the failed runs so far.
+type QueuedJob struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+}
+
+// DeadJob is a job that failed for good, identified by its dead-letter entry
+type DeadJob struct {
+	EntryID string `json:"entry_id"`
+	QueuedJob
+}
+
+// QueueStats is the depth of the job queue
+type QueueStats struct {
+	Waiting int64 `json:"waiting"` // ready to be picked up
+	Running int64 `json:"running"` // picked up, not finished yet
+	Delayed int64 `json:"delayed"` // waiting for a retry
+	Dead    int64 `json:"dead"`
+}
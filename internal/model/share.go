@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareLink gives unauthenticated access to a note through a random slug
+type ShareLink struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	NoteID       uuid.UUID  `json:"note_id" db:"note_id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Slug         string     `json:"slug" db:"slug"`
+	PasswordHash *string    `json:"-" db:"password_hash"`
+	ExpiresAt    *time.Time `json:"expires_at" db:"expires_at"`
+	MaxViews     *int64     `json:"max_views" db:"max_views"`
+	ViewCount    int64      `json:"view_count" db:"view_count"`
+	LastViewedAt *time.Time `json:"last_viewed_at" db:"last_viewed_at"`
+	RevokedAt    *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateShareLinkRequest represents a request to share a note
+type CreateShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  *string    `json:"password" validate:"omitempty,min=4,max=72"`
+	MaxViews  *int64     `json:"max_views" validate:"omitempty,min=1"`
+}
+
+// ShareLinkResponse represents a share link in API responses
+type ShareLinkResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	NoteID       uuid.UUID  `json:"note_id"`
+	Slug         string     `json:"slug"`
+	Path         string     `json:"path"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxViews     *int64     `json:"max_views"`
+	ViewCount    int64      `json:"view_count"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// SharedNoteResponse is the note served through a share link
+type SharedNoteResponse struct {
+	Title     string    `json:"title"`
+	Content   *string   `json:"content"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Active reports whether the link can still be opened at now
+func (l *ShareLink) Active(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return false
+	}
+	return l.MaxViews == nil || l.ViewCount < *l.MaxViews
+}
+
+// ToResponse converts ShareLink to response format (without the password hash)
+func (l *ShareLink) ToResponse() *ShareLinkResponse {
+	return &ShareLinkResponse{
+		ID:           l.ID,
+		NoteID:       l.NoteID,
+		Slug:         l.Slug,
+		Path:         "/s/" + l.Slug,
+		HasPassword:  l.PasswordHash != nil,
+		ExpiresAt:    l.ExpiresAt,
+		MaxViews:     l.MaxViews,
+		ViewCount:    l.ViewCount,
+		LastViewedAt: l.LastViewedAt,
+		RevokedAt:    l.RevokedAt,
+		Active:       l.Active(time.Now()),
+		CreatedAt:    l.CreatedAt,
+	}
+}
+
+// ToSharedResponse converts Note to the format served through share links
+func (n *Note) ToSharedResponse() *SharedNoteResponse {
+	return &SharedNoteResponse{
+		Title:     n.Title,
+		Content:   n.Content,
+		Tags:      n.GetTagsArray(),
+		UpdatedAt: n.UpdatedAt,
+	}
+}
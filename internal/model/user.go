@@ -8,12 +8,22 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password"` // Never expose password in JSON
-	FullName  string    `json:"full_name" db:"full_name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID       uuid.UUID `json:"id" db:"id"`
+	Email    string    `json:"email" db:"email"`
+	Password string    `json:"-" db:"password"` // Never expose password in JSON
+	FullName string    `json:"full_name" db:"full_name"`
+	// FeedOptOut hides the user's public notes feed
+	FeedOptOut bool `json:"feed_opt_out" db:"feed_opt_out"`
+	// Handle names the public profile; nil until the user picks one
+	Handle    *string `json:"handle" db:"handle"`
+	Bio       *string `json:"bio" db:"bio"`
+	AvatarURL *string `json:"avatar_url" db:"avatar_url"`
+	// ProfilePublic shows the profile and credits public notes to the handle
+	ProfilePublic bool `json:"profile_public" db:"profile_public"`
+	// TrashRetentionDays overrides how long deleted notes are kept; nil uses the default
+	TrashRetentionDays *int      `json:"trash_retention_days" db:"trash_retention_days"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // RegisterRequest represents a user registration request
@@ -44,6 +54,28 @@ type UpdateProfileRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 }
 
+// UserSettings holds a user's preferences
+type UserSettings struct {
+	FeedEnabled bool `json:"feed_enabled"` // Public notes feed at /feeds/users/{id}
+	// Days deleted notes stay in the trash; null uses the server default
+	TrashRetentionDays *int `json:"trash_retention_days"`
+}
+
+// UpdateSettingsRequest represents a settings update; omitted fields are unchanged
+type UpdateSettingsRequest struct {
+	FeedEnabled *bool `json:"feed_enabled"`
+	// 0 resets the trash retention to the server default
+	TrashRetentionDays *int `json:"trash_retention_days" validate:"omitempty,min=0,max=3650"`
+}
+
+// Settings returns the user's preferences
+func (u *User) Settings() *UserSettings {
+	return &UserSettings{
+		FeedEnabled:        !u.FeedOptOut,
+		TrashRetentionDays: u.TrashRetentionDays,
+	}
+}
+
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"gonotes/internal/model"
+)
+
+// deadLetterMaxLen bounds the dead-letter list; the oldest entries go first
+const deadLetterMaxLen = 10000
+
+// Message is a job delivered to a consumer, identified by the entry that
+// holds it until it is acknowledged
+type Message struct {
+	EntryID string
+	Job     model.QueuedJob
+}
+
+// Broker stores queued jobs. A delivered message stays pending until it is
+// acknowledged, retried or buried; pending messages of a consumer that went
+// away are claimed by another one. Implementations must be safe for
+// concurrent use.
+type Broker interface {
+	// Add queues a job for delivery
+	Add(ctx context.Context, job *model.QueuedJob) error
+	// Read delivers up to count waiting messages to consumer, waiting up to
+	// block for one to arrive. No messages and no error means none arrived.
+	Read(ctx context.Context, consumer string, count int, block time.Duration) ([]Message, error)
+	// ClaimStuck hands up to count messages pending for longer than minIdle
+	// over to consumer
+	ClaimStuck(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]Message, error)
+	// Ack removes a finished message
+	Ack(ctx context.Context, msg Message) error
+	// Retry replaces a pending message with msg.Job, delivered again at at
+	Retry(ctx context.Context, msg Message, at time.Time) error
+	// PromoteDue makes up to limit retries due by now deliverable again and
+	// returns how many were promoted
+	PromoteDue(ctx context.Context, now time.Time, limit int) (int, error)
+	// Bury moves a pending message to the dead-letter list as msg.Job
+	Bury(ctx context.Context, msg Message) error
+
+	// DeadJobs returns up to limit dead jobs, newest first
+	DeadJobs(ctx context.Context, limit int) ([]model.DeadJob, error)
+	// Revive queues the dead job in entryID again with its attempts reset
+	// and reports whether it was found
+	Revive(ctx context.Context, entryID string) (bool, error)
+	// Stats returns the depth of the queue
+	Stats(ctx context.Context) (*model.QueueStats, error)
+	// PruneConsumers forgets consumers without pending messages that have
+	// been idle for longer than maxIdle and returns how many were removed
+	PruneConsumers(ctx context.Context, maxIdle time.Duration) (int, error)
+
+	// Close releases resources held by the broker
+	Close() error
+}
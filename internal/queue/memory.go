@@ -0,0 +1,229 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gonotes/internal/model"
+)
+
+// memoryDelivery is a message delivered to a consumer and not finished yet
+type memoryDelivery struct {
+	msg         Message
+	deliveredAt time.Time
+}
+
+// memoryRetry is a job waiting for its retry
+type memoryRetry struct {
+	job model.QueuedJob
+	at  time.Time
+}
+
+// MemoryBroker implements Broker in process memory. Jobs are lost on
+// restart; it is intended for single-instance deployments and tests.
+type MemoryBroker struct {
+	mu      sync.Mutex
+	seq     int64
+	waiting []Message
+	pending map[string]*memoryDelivery
+	delayed []memoryRetry
+	dead    []model.DeadJob // oldest first
+
+	// added is closed and replaced whenever a message becomes deliverable
+	added chan struct{}
+}
+
+// NewMemoryBroker creates an empty in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		pending: make(map[string]*memoryDelivery),
+		added:   make(chan struct{}),
+	}
+}
+
+// Add queues a job for delivery
+func (b *MemoryBroker) Add(ctx context.Context, job *model.QueuedJob) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.push(*job)
+	return nil
+}
+
+// Read delivers waiting messages, waiting up to block for one to arrive
+func (b *MemoryBroker) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]Message, error) {
+	timer := time.NewTimer(block)
+	defer timer.Stop()
+
+	for {
+		b.mu.Lock()
+		if len(b.waiting) > 0 {
+			n := count
+			if n > len(b.waiting) {
+				n = len(b.waiting)
+			}
+			messages := append([]Message(nil), b.waiting[:n]...)
+			b.waiting = b.waiting[n:]
+
+			now := time.Now()
+			for _, msg := range messages {
+				b.pending[msg.EntryID] = &memoryDelivery{msg: msg, deliveredAt: now}
+			}
+			b.mu.Unlock()
+			return messages, nil
+		}
+		added := b.added
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, nil
+		case <-added:
+		}
+	}
+}
+
+// ClaimStuck hands messages pending for longer than minIdle over to the caller
+func (b *MemoryBroker) ClaimStuck(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var stuck []*memoryDelivery
+	for _, delivery := range b.pending {
+		if now.Sub(delivery.deliveredAt) >= minIdle {
+			stuck = append(stuck, delivery)
+		}
+	}
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].deliveredAt.Before(stuck[j].deliveredAt)
+	})
+	if len(stuck) > count {
+		stuck = stuck[:count]
+	}
+
+	messages := make([]Message, len(stuck))
+	for i, delivery := range stuck {
+		delivery.deliveredAt = now
+		messages[i] = delivery.msg
+	}
+	return messages, nil
+}
+
+// Ack removes a finished message
+func (b *MemoryBroker) Ack(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.pending, msg.EntryID)
+	return nil
+}
+
+// Retry replaces a pending message with msg.Job, delivered again at at
+func (b *MemoryBroker) Retry(ctx context.Context, msg Message, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.pending, msg.EntryID)
+	b.delayed = append(b.delayed, memoryRetry{job: msg.Job, at: at})
+	return nil
+}
+
+// PromoteDue makes retries due by now deliverable again
+func (b *MemoryBroker) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sort.SliceStable(b.delayed, func(i, j int) bool {
+		return b.delayed[i].at.Before(b.delayed[j].at)
+	})
+
+	promoted := 0
+	for promoted < len(b.delayed) && promoted < limit && !b.delayed[promoted].at.After(now) {
+		b.push(b.delayed[promoted].job)
+		promoted++
+	}
+	b.delayed = b.delayed[promoted:]
+	return promoted, nil
+}
+
+// Bury moves a pending message to the dead-letter list
+func (b *MemoryBroker) Bury(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.pending, msg.EntryID)
+	b.dead = append(b.dead, model.DeadJob{EntryID: b.nextID(), QueuedJob: msg.Job})
+	if len(b.dead) > deadLetterMaxLen {
+		b.dead = b.dead[len(b.dead)-deadLetterMaxLen:]
+	}
+	return nil
+}
+
+// DeadJobs returns up to limit dead jobs, newest first
+func (b *MemoryBroker) DeadJobs(ctx context.Context, limit int) ([]model.DeadJob, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	jobs := make([]model.DeadJob, 0, limit)
+	for i := len(b.dead) - 1; i >= 0 && len(jobs) < limit; i-- {
+		jobs = append(jobs, b.dead[i])
+	}
+	return jobs, nil
+}
+
+// Revive queues the dead job in entryID again with its attempts reset
+func (b *MemoryBroker) Revive(ctx context.Context, entryID string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, dead := range b.dead {
+		if dead.EntryID == entryID {
+			b.dead = append(b.dead[:i], b.dead[i+1:]...)
+			b.push(revived(dead.QueuedJob))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Stats returns the depth of the queue
+func (b *MemoryBroker) Stats(ctx context.Context) (*model.QueueStats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &model.QueueStats{
+		Waiting: int64(len(b.waiting)),
+		Running: int64(len(b.pending)),
+		Delayed: int64(len(b.delayed)),
+		Dead:    int64(len(b.dead)),
+	}, nil
+}
+
+// PruneConsumers does nothing: the in-memory broker does not track consumers
+func (b *MemoryBroker) PruneConsumers(ctx context.Context, maxIdle time.Duration) (int, error) {
+	return 0, nil
+}
+
+// Close does nothing for the in-memory broker
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// push makes job deliverable and wakes up readers. Caller must hold the lock.
+func (b *MemoryBroker) push(job model.QueuedJob) {
+	b.waiting = append(b.waiting, Message{EntryID: b.nextID(), Job: job})
+	close(b.added)
+	b.added = make(chan struct{})
+}
+
+// nextID returns a new entry ID in the form of a stream ID. Caller must hold the lock.
+func (b *MemoryBroker) nextID() string {
+	b.seq++
+	return fmt.Sprintf("%d-%d", time.Now().UnixMilli(), b.seq)
+}
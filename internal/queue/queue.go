@@ -0,0 +1,405 @@
+// Package queue is a durable queue for background work that should not run
+// inside an HTTP request. Jobs are typed by name, carry a JSON payload and
+// are retried with exponential backoff until they succeed or run out of
+// attempts, when they move to a dead-letter list for inspection and manual
+// retry. Handlers must tolerate running more than once for the same job.
+//
+// Register handlers before Run, then enqueue from anywhere:
+//
+//	queue.Handle(q, "export.notes", func(ctx context.Context, req ExportRequest) error { ... })
+//	q.Enqueue(ctx, "export.notes", ExportRequest{UserID: userID})
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+	"gonotes/internal/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Queue loop settings
+const (
+	readBlock    = 2 * time.Second  // longest a worker waits for a job before checking for shutdown
+	readBackoff  = 5 * time.Second  // pause after a failed read, e.g. while Redis is down
+	claimGrace   = 30 * time.Second // time a timed out job has to finish before it counts as stuck
+	promoteBatch = 100
+	claimBatch   = 10
+)
+
+// Options configures a Queue
+type Options struct {
+	// Workers is the number of jobs run concurrently by this instance
+	Workers int
+	// MaxAttempts is the number of failed runs after which a job is dead
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles every retry
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+	// VisibilityTimeout is the longest a job may run. A job picked up by an
+	// instance that stopped is retried once it is this much overdue.
+	VisibilityTimeout time.Duration
+	// PollInterval is how often due retries and stuck jobs are checked
+	PollInterval time.Duration
+}
+
+// DefaultOptions returns the default queue settings
+func DefaultOptions() Options {
+	return Options{
+		Workers:           4,
+		MaxAttempts:       5,
+		Backoff:           5 * time.Second,
+		MaxBackoff:        time.Hour,
+		VisibilityTimeout: 5 * time.Minute,
+		PollInterval:      time.Second,
+	}
+}
+
+// handlerFunc runs a job from its encoded payload
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails without being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Queue runs queued jobs with their registered handlers
+type Queue struct {
+	broker   Broker
+	consumer string
+	opts     Options
+	handlers map[string]handlerFunc
+}
+
+// New creates a queue on broker consuming as consumer (e.g. the hostname).
+// Unset options take their default.
+func New(broker Broker, consumer string, opts Options) *Queue {
+	defaults := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaults.Backoff
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = opts.Backoff
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = defaults.VisibilityTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaults.PollInterval
+	}
+
+	return &Queue{
+		broker:   broker,
+		consumer: consumer,
+		opts:     opts,
+		handlers: make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers fn to run jobs of jobType, decoding their payload into T.
+// A payload that does not decode fails the job without retries. Handlers
+// must be registered before Run.
+func Handle[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T) error) {
+	q.handlers[jobType] = func(ctx context.Context, data json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Enqueue queues a job of jobType with payload encoded as JSON and returns
+// the job ID
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) (string, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return "", fmt.Errorf("unknown job type: %s", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	job := &model.QueuedJob{
+		ID:         uuid.NewString(),
+		Type:       jobType,
+		Payload:    data,
+		EnqueuedAt: time.Now().UTC(),
+	}
+	if err := q.broker.Add(ctx, job); err != nil {
+		return "", err
+	}
+	metrics.QueueJobs.WithLabelValues(jobType, "enqueued").Inc()
+	return job.ID, nil
+}
+
+// Run runs jobs until ctx is cancelled. Jobs still running then are
+// cancelled and put back for another instance; Run returns once they have
+// been handed back. Without registered handlers no job can be queued, so
+// Run does not poll the broker and only waits for ctx.
+func (q *Queue) Run(ctx context.Context) {
+	if len(q.handlers) == 0 {
+		slog.InfoContext(ctx, "No job handlers registered, queue workers not started")
+		<-ctx.Done()
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.maintain(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// Stats returns the depth of the queue
+func (q *Queue) Stats(ctx context.Context) (*model.QueueStats, error) {
+	return q.broker.Stats(ctx)
+}
+
+// DeadJobs returns up to limit dead jobs, newest first
+func (q *Queue) DeadJobs(ctx context.Context, limit int) ([]model.DeadJob, error) {
+	return q.broker.DeadJobs(ctx, limit)
+}
+
+// RetryDead queues the dead job in entryID again with a fresh set of attempts
+func (q *Queue) RetryDead(ctx context.Context, entryID string) error {
+	found, err := q.broker.Revive(ctx, entryID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("dead job not found")
+	}
+	return nil
+}
+
+// RetryAllDead queues every dead job again and returns how many were queued
+func (q *Queue) RetryAllDead(ctx context.Context) (int, error) {
+	retried := 0
+	for {
+		dead, err := q.broker.DeadJobs(ctx, promoteBatch)
+		if err != nil {
+			return retried, err
+		}
+		if len(dead) == 0 {
+			return retried, nil
+		}
+		batch := 0
+		for _, job := range dead {
+			found, err := q.broker.Revive(ctx, job.EntryID)
+			if err != nil {
+				return retried, err
+			}
+			if found {
+				batch++
+			}
+		}
+		retried += batch
+		if batch == 0 {
+			return retried, nil
+		}
+	}
+}
+
+// PruneConsumers forgets consumers of replicas gone for a day. It is run by
+// the job scheduler, and does nothing while no handlers are registered.
+func (q *Queue) PruneConsumers(ctx context.Context) error {
+	if len(q.handlers) == 0 {
+		return nil
+	}
+	removed, err := q.broker.PruneConsumers(ctx, 24*time.Hour)
+	if removed > 0 {
+		slog.InfoContext(ctx, "Pruned idle queue consumers", "consumers", removed)
+	}
+	return err
+}
+
+// work runs jobs one at a time until ctx is cancelled
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := q.broker.Read(ctx, q.consumer, 1, readBlock)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.WarnContext(ctx, "Failed to read jobs", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(readBackoff):
+			}
+			continue
+		}
+
+		for _, msg := range messages {
+			q.process(ctx, msg)
+		}
+	}
+}
+
+// maintain promotes due retries and fails jobs stuck on a stopped instance
+func (q *Queue) maintain(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := q.broker.PromoteDue(ctx, time.Now(), promoteBatch); err != nil {
+			slog.WarnContext(ctx, "Failed to promote job retries", "error", err)
+			continue
+		}
+
+		stuck, err := q.broker.ClaimStuck(ctx, q.consumer, q.opts.VisibilityTimeout+claimGrace, claimBatch)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to claim stuck jobs", "error", err)
+			continue
+		}
+		for _, msg := range stuck {
+			q.fail(ctx, msg, fmt.Errorf("visibility timeout exceeded"))
+		}
+	}
+}
+
+// process runs the handler of a delivered job and settles the message
+func (q *Queue) process(ctx context.Context, msg Message) {
+	handler, ok := q.handlers[msg.Job.Type]
+	if !ok {
+		q.fail(ctx, msg, Permanent(fmt.Errorf("no handler for job type %q", msg.Job.Type)))
+		return
+	}
+
+	start := time.Now()
+	jobCtx, cancel := context.WithTimeout(ctx, q.opts.VisibilityTimeout)
+	jobCtx, span := tracing.Start(jobCtx, "queue "+msg.Job.Type,
+		attribute.String("gonotes.job.id", msg.Job.ID),
+		attribute.Int("gonotes.job.attempt", msg.Job.Attempts+1))
+	err := call(jobCtx, handler, msg.Job.Payload)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	cancel()
+	metrics.QueueJobDuration.WithLabelValues(msg.Job.Type).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err == nil:
+		if err := q.broker.Ack(context.WithoutCancel(ctx), msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to acknowledge job", "job_id", msg.Job.ID, "error", err)
+		}
+		metrics.QueueJobs.WithLabelValues(msg.Job.Type, "succeeded").Inc()
+	case ctx.Err() != nil:
+		// Shutting down: hand the job back without counting an attempt
+		if err := q.broker.Retry(context.WithoutCancel(ctx), msg, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Failed to release job", "job_id", msg.Job.ID, "error", err)
+		}
+		metrics.QueueJobs.WithLabelValues(msg.Job.Type, "released").Inc()
+	default:
+		q.fail(ctx, msg, err)
+	}
+}
+
+// fail records a failed attempt and schedules a retry, or buries the job
+// once it is out of attempts or failed permanently
+func (q *Queue) fail(ctx context.Context, msg Message, cause error) {
+	// Settle the message even when shutdown cancelled ctx
+	ctx = context.WithoutCancel(ctx)
+
+	msg.Job.Attempts++
+	msg.Job.LastError = cause.Error()
+
+	var permanent *permanentError
+	if errors.As(cause, &permanent) || msg.Job.Attempts >= q.opts.MaxAttempts {
+		now := time.Now().UTC()
+		msg.Job.FailedAt = &now
+		if err := q.broker.Bury(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to bury job", "job_id", msg.Job.ID, "error", err)
+			return
+		}
+		metrics.QueueJobs.WithLabelValues(msg.Job.Type, "dead").Inc()
+		slog.ErrorContext(ctx, "Job failed for good", "job_id", msg.Job.ID, "job_type", msg.Job.Type, "attempts", msg.Job.Attempts, "error", cause)
+		return
+	}
+
+	delay := q.backoff(msg.Job.Attempts)
+	if err := q.broker.Retry(ctx, msg, time.Now().Add(delay)); err != nil {
+		slog.ErrorContext(ctx, "Failed to schedule job retry", "job_id", msg.Job.ID, "error", err)
+		return
+	}
+	metrics.QueueJobs.WithLabelValues(msg.Job.Type, "retried").Inc()
+	slog.WarnContext(ctx, "Job failed, retrying", "job_id", msg.Job.ID, "job_type", msg.Job.Type, "attempts", msg.Job.Attempts, "retry_in", delay.String(), "error", cause)
+}
+
+// backoff returns the delay before the retry following attempt failures:
+// doubling from Backoff up to MaxBackoff, with jitter so jobs that failed
+// together do not retry together
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.Backoff
+	for i := 1; i < attempts && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		delay = q.opts.MaxBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// call runs handler, turning a panic into an error
+func call(ctx context.Context, handler handlerFunc, payload json.RawMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// revived returns job ready to be queued again with a fresh set of attempts
+func revived(job model.QueuedJob) model.QueuedJob {
+	job.Attempts = 0
+	job.LastError = ""
+	job.FailedAt = nil
+	return job
+}
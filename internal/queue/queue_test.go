@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func newTestQueue(broker Broker) *Queue {
+	return New(broker, "test", Options{
+		MaxAttempts:       3,
+		Backoff:           time.Second,
+		MaxBackoff:        4 * time.Second,
+		VisibilityTimeout: time.Minute,
+	})
+}
+
+// deliver reads the next message or fails the test
+func deliver(t *testing.T, broker Broker) Message {
+	t.Helper()
+	messages, err := broker.Read(context.Background(), "test", 1, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Read() returned %d messages, want 1", len(messages))
+	}
+	return messages[0]
+}
+
+func TestQueue_RunsTypedHandler(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	q := newTestQueue(broker)
+
+	var got string
+	Handle(q, "greet", func(ctx context.Context, payload greeting) error {
+		got = payload.Name
+		return nil
+	})
+
+	if _, err := q.Enqueue(ctx, "greet", greeting{Name: "alice"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := q.Enqueue(ctx, "unknown", nil); err == nil {
+		t.Error("expected error enqueueing an unknown job type")
+	}
+
+	q.process(ctx, deliver(t, broker))
+	if got != "alice" {
+		t.Errorf("handler got %q, want alice", got)
+	}
+
+	stats, _ := q.Stats(ctx)
+	if stats.Waiting != 0 || stats.Running != 0 || stats.Delayed != 0 || stats.Dead != 0 {
+		t.Errorf("stats after success = %+v, want empty queue", stats)
+	}
+}
+
+func TestQueue_RetriesThenBuries(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	q := newTestQueue(broker)
+
+	runs := 0
+	Handle(q, "flaky", func(ctx context.Context, payload greeting) error {
+		runs++
+		return errors.New("smtp unavailable")
+	})
+	q.Enqueue(ctx, "flaky", greeting{Name: "bob"})
+
+	for attempt := 1; attempt < 3; attempt++ {
+		q.process(ctx, deliver(t, broker))
+
+		stats, _ := q.Stats(ctx)
+		if stats.Delayed != 1 {
+			t.Fatalf("attempt %d: stats = %+v, want one delayed retry", attempt, stats)
+		}
+		// Not due yet
+		if promoted, _ := broker.PromoteDue(ctx, time.Now(), 10); promoted != 0 {
+			t.Fatalf("attempt %d: retry promoted before its backoff", attempt)
+		}
+		if promoted, _ := broker.PromoteDue(ctx, time.Now().Add(time.Minute), 10); promoted != 1 {
+			t.Fatalf("attempt %d: retry not promoted after its backoff", attempt)
+		}
+	}
+
+	q.process(ctx, deliver(t, broker))
+	if runs != 3 {
+		t.Errorf("handler ran %d times, want 3", runs)
+	}
+
+	dead, _ := q.DeadJobs(ctx, 10)
+	if len(dead) != 1 {
+		t.Fatalf("got %d dead jobs, want 1", len(dead))
+	}
+	if dead[0].Attempts != 3 || dead[0].LastError != "smtp unavailable" || dead[0].FailedAt == nil {
+		t.Errorf("dead job = %+v", dead[0])
+	}
+
+	// Retrying a dead job queues it with fresh attempts
+	if err := q.RetryDead(ctx, dead[0].EntryID); err != nil {
+		t.Fatalf("RetryDead() error = %v", err)
+	}
+	if err := q.RetryDead(ctx, dead[0].EntryID); err == nil || err.Error() != "dead job not found" {
+		t.Errorf("second RetryDead() error = %v, want dead job not found", err)
+	}
+	if msg := deliver(t, broker); msg.Job.Attempts != 0 || msg.Job.LastError != "" || msg.Job.ID != dead[0].ID {
+		t.Errorf("revived job = %+v", msg.Job)
+	}
+}
+
+func TestQueue_PermanentFailures(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	q := newTestQueue(broker)
+
+	Handle(q, "strict", func(ctx context.Context, payload greeting) error {
+		if payload.Name == "" {
+			return Permanent(errors.New("name is required"))
+		}
+		return nil
+	})
+
+	q.Enqueue(ctx, "strict", greeting{})
+	q.Enqueue(ctx, "strict", "not an object")
+	q.process(ctx, deliver(t, broker))
+	q.process(ctx, deliver(t, broker))
+
+	stats, _ := q.Stats(ctx)
+	if stats.Dead != 2 || stats.Delayed != 0 {
+		t.Errorf("stats = %+v, want 2 dead jobs and no retries", stats)
+	}
+
+	if retried, err := q.RetryAllDead(ctx); err != nil || retried != 2 {
+		t.Errorf("RetryAllDead() = %d, %v, want 2", retried, err)
+	}
+}
+
+func TestQueue_StuckJobsAreRetried(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	q := newTestQueue(broker)
+	Handle(q, "greet", func(ctx context.Context, payload greeting) error { return nil })
+
+	q.Enqueue(ctx, "greet", greeting{Name: "carol"})
+	deliver(t, broker) // picked up by an instance that never finishes
+
+	if stuck, _ := broker.ClaimStuck(ctx, "other", time.Minute, 10); len(stuck) != 0 {
+		t.Fatalf("claimed %d jobs before the visibility timeout", len(stuck))
+	}
+	stuck, _ := broker.ClaimStuck(ctx, "other", 0, 10)
+	if len(stuck) != 1 {
+		t.Fatalf("claimed %d stuck jobs, want 1", len(stuck))
+	}
+	q.fail(ctx, stuck[0], errors.New("visibility timeout exceeded"))
+
+	stats, _ := q.Stats(ctx)
+	if stats.Running != 0 || stats.Delayed != 1 {
+		t.Errorf("stats = %+v, want the stuck job waiting for a retry", stats)
+	}
+}
+
+func TestQueue_ShutdownReleasesRunningJobs(t *testing.T) {
+	broker := NewMemoryBroker()
+	q := newTestQueue(broker)
+
+	started := make(chan struct{})
+	Handle(q, "slow", func(ctx context.Context, payload greeting) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q.Enqueue(context.Background(), "slow", greeting{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	// Handed back due immediately, without counting an attempt
+	broker.PromoteDue(context.Background(), time.Now(), 10)
+	if msg := deliver(t, broker); msg.Job.Attempts != 0 {
+		t.Errorf("released job attempts = %d, want 0", msg.Job.Attempts)
+	}
+}
+
+func TestQueue_Backoff(t *testing.T) {
+	q := newTestQueue(NewMemoryBroker())
+
+	for attempts, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 4 * time.Second} {
+		for i := 0; i < 20; i++ {
+			delay := q.backoff(attempts)
+			if delay < max/2 || delay > max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempts, delay, max/2, max)
+			}
+		}
+	}
+}
+
+// countingBroker counts reads of the broker it wraps
+type countingBroker struct {
+	*MemoryBroker
+	reads atomic.Int32
+}
+
+func (b *countingBroker) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]Message, error) {
+	b.reads.Add(1)
+	return b.MemoryBroker.Read(ctx, consumer, count, block)
+}
+
+func TestQueue_RunWithoutHandlersDoesNotPoll(t *testing.T) {
+	broker := &countingBroker{MemoryBroker: NewMemoryBroker()}
+	q := newTestQueue(broker)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	q.Run(ctx)
+
+	if n := broker.reads.Load(); n != 0 {
+		t.Errorf("Run() without handlers read the broker %d times", n)
+	}
+}
@@ -0,0 +1,319 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys and consumer group of the queue
+const (
+	streamKey  = "queue:jobs"    // stream of waiting and pending jobs
+	delayedKey = "queue:delayed" // sorted set of retries by due time (ms)
+	deadKey    = "queue:dead"    // stream of dead jobs
+	groupName  = "workers"
+)
+
+// promoteScript moves retries due by ARGV[1] (ms) from the delayed set
+// KEYS[1] to the stream KEYS[2], at most ARGV[2] at a time
+var promoteScript = redis.NewScript(`
+	local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+	for _, job in ipairs(due) do
+		redis.call('ZREM', KEYS[1], job)
+		redis.call('XADD', KEYS[2], '*', 'job', job)
+	end
+	return #due
+`)
+
+// reviveScript deletes the dead entry ARGV[1] from KEYS[1] and, if it was
+// still there, queues the job ARGV[2] on KEYS[2]. Returns 1 if revived.
+var reviveScript = redis.NewScript(`
+	if redis.call('XDEL', KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call('XADD', KEYS[2], '*', 'job', ARGV[2])
+	return 1
+`)
+
+// RedisBroker implements Broker on Redis Streams. Jobs wait in a stream read
+// by a consumer group; acknowledged entries are deleted, so the stream only
+// holds waiting and pending jobs. Retries wait in a sorted set by due time
+// and dead jobs in a capped stream.
+type RedisBroker struct {
+	rdb      *redis.Client
+	hasGroup atomic.Bool
+}
+
+// NewRedisBroker creates a broker on rdb. The consumer group is created on
+// first use, so the broker can be created while Redis is down.
+func NewRedisBroker(rdb *redis.Client) *RedisBroker {
+	return &RedisBroker{rdb: rdb}
+}
+
+// Add queues a job for delivery
+func (b *RedisBroker) Add(ctx context.Context, job *model.QueuedJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	if err := b.rdb.XAdd(ctx, &redis.XAddArgs{Stream: streamKey, Values: []interface{}{"job", data}}).Err(); err != nil {
+		return fmt.Errorf("failed to add job: %w", err)
+	}
+	return nil
+}
+
+// Read delivers waiting messages to consumer
+func (b *RedisBroker) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]Message, error) {
+	if err := b.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	streams, err := b.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    groupName,
+		Consumer: consumer,
+		Streams:  []string{streamKey, ">"},
+		Count:    int64(count),
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		if isNoGroup(err) {
+			// The stream was deleted; recreate the group on the next read
+			b.hasGroup.Store(false)
+		}
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+
+	var messages []Message
+	for _, stream := range streams {
+		for _, entry := range stream.Messages {
+			messages = append(messages, decodeMessage(entry))
+		}
+	}
+	return messages, nil
+}
+
+// ClaimStuck hands messages pending for longer than minIdle over to consumer
+func (b *RedisBroker) ClaimStuck(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]Message, error) {
+	if err := b.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, _, err := b.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   streamKey,
+		Group:    groupName,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim stuck jobs: %w", err)
+	}
+
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, decodeMessage(entry))
+	}
+	return messages, nil
+}
+
+// Ack removes a finished message
+func (b *RedisBroker) Ack(ctx context.Context, msg Message) error {
+	pipe := b.rdb.TxPipeline()
+	pipe.XAck(ctx, streamKey, groupName, msg.EntryID)
+	pipe.XDel(ctx, streamKey, msg.EntryID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to acknowledge job: %w", err)
+	}
+	return nil
+}
+
+// Retry replaces a pending message with msg.Job, delivered again at at
+func (b *RedisBroker) Retry(ctx context.Context, msg Message, at time.Time) error {
+	data, err := json.Marshal(msg.Job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	pipe := b.rdb.TxPipeline()
+	pipe.XAck(ctx, streamKey, groupName, msg.EntryID)
+	pipe.XDel(ctx, streamKey, msg.EntryID)
+	pipe.ZAdd(ctx, delayedKey, redis.Z{Score: float64(at.UnixMilli()), Member: string(data)})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to schedule job retry: %w", err)
+	}
+	return nil
+}
+
+// PromoteDue makes retries due by now deliverable again
+func (b *RedisBroker) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	promoted, err := promoteScript.Run(ctx, b.rdb, []string{delayedKey, streamKey}, now.UnixMilli(), limit).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote job retries: %w", err)
+	}
+	return promoted, nil
+}
+
+// Bury moves a pending message to the dead-letter stream
+func (b *RedisBroker) Bury(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg.Job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	pipe := b.rdb.TxPipeline()
+	pipe.XAck(ctx, streamKey, groupName, msg.EntryID)
+	pipe.XDel(ctx, streamKey, msg.EntryID)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: deadKey,
+		MaxLen: deadLetterMaxLen,
+		Approx: true,
+		Values: []interface{}{"job", data},
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to bury job: %w", err)
+	}
+	return nil
+}
+
+// DeadJobs returns up to limit dead jobs, newest first
+func (b *RedisBroker) DeadJobs(ctx context.Context, limit int) ([]model.DeadJob, error) {
+	entries, err := b.rdb.XRevRangeN(ctx, deadKey, "+", "-", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+
+	jobs := make([]model.DeadJob, 0, len(entries))
+	for _, entry := range entries {
+		msg := decodeMessage(entry)
+		jobs = append(jobs, model.DeadJob{EntryID: msg.EntryID, QueuedJob: msg.Job})
+	}
+	return jobs, nil
+}
+
+// Revive queues the dead job in entryID again with its attempts reset
+func (b *RedisBroker) Revive(ctx context.Context, entryID string) (bool, error) {
+	entries, err := b.rdb.XRange(ctx, deadKey, entryID, entryID).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid stream ID") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get dead job: %w", err)
+	}
+	if len(entries) == 0 {
+		return false, nil
+	}
+
+	job := revived(decodeMessage(entries[0]).Job)
+	data, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	done, err := reviveScript.Run(ctx, b.rdb, []string{deadKey, streamKey}, entryID, data).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to revive job: %w", err)
+	}
+	return done == 1, nil
+}
+
+// Stats returns the depth of the queue
+func (b *RedisBroker) Stats(ctx context.Context) (*model.QueueStats, error) {
+	if err := b.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	pipe := b.rdb.Pipeline()
+	length := pipe.XLen(ctx, streamKey)
+	pending := pipe.XPending(ctx, streamKey, groupName)
+	delayed := pipe.ZCard(ctx, delayedKey)
+	dead := pipe.XLen(ctx, deadKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+
+	running := pending.Val().Count
+	return &model.QueueStats{
+		Waiting: length.Val() - running,
+		Running: running,
+		Delayed: delayed.Val(),
+		Dead:    dead.Val(),
+	}, nil
+}
+
+// PruneConsumers removes idle consumers without pending messages, e.g. of
+// replicas that were replaced
+func (b *RedisBroker) PruneConsumers(ctx context.Context, maxIdle time.Duration) (int, error) {
+	if err := b.ensureGroup(ctx); err != nil {
+		return 0, err
+	}
+
+	consumers, err := b.rdb.XInfoConsumers(ctx, streamKey, groupName).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list consumers: %w", err)
+	}
+
+	removed := 0
+	for _, consumer := range consumers {
+		if consumer.Pending > 0 || consumer.Idle < maxIdle {
+			continue
+		}
+		if err := b.rdb.XGroupDelConsumer(ctx, streamKey, groupName, consumer.Name).Err(); err != nil {
+			return removed, fmt.Errorf("failed to remove consumer %s: %w", consumer.Name, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Close closes the Redis client
+func (b *RedisBroker) Close() error {
+	return b.rdb.Close()
+}
+
+// ensureGroup creates the stream and its consumer group if needed. Jobs
+// added before the group existed are delivered too.
+func (b *RedisBroker) ensureGroup(ctx context.Context) error {
+	if b.hasGroup.Load() {
+		return nil
+	}
+
+	err := b.rdb.XGroupCreateMkStream(ctx, streamKey, groupName, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	b.hasGroup.Store(true)
+	return nil
+}
+
+// isNoGroup reports whether err says the consumer group does not exist
+func isNoGroup(err error) bool {
+	var redisErr redis.Error
+	return errors.As(err, &redisErr) && strings.HasPrefix(redisErr.Error(), "NOGROUP")
+}
+
+// decodeMessage decodes a stream entry. An entry that cannot be decoded
+// yields a job without a type, which no handler accepts.
+func decodeMessage(entry redis.XMessage) Message {
+	msg := Message{EntryID: entry.ID}
+	if data, ok := entry.Values["job"].(string); ok {
+		if err := json.Unmarshal([]byte(data), &msg.Job); err != nil {
+			msg.Job = model.QueuedJob{}
+		}
+	}
+	if msg.Job.ID == "" {
+		msg.Job.ID = entry.ID
+	}
+	return msg
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Algorithm identifies a rate limiting algorithm
+type Algorithm string
+
+const (
+	// AlgorithmTokenBucket refills tokens continuously and allows bursts up to the bucket size
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+	// AlgorithmSlidingWindow counts every request within a rolling window
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+)
+
+// Policy describes how many requests are allowed for a subject
+type Policy struct {
+	Name      string    `json:"name"`
+	Algorithm Algorithm `json:"algorithm"`
+	Limit     int       `json:"limit"`  // requests per window
+	Window    Duration  `json:"window"` // window length (sliding window) or refill period (token bucket)
+	Burst     int       `json:"burst"`  // bucket capacity for token bucket, defaults to Limit
+}
+
+// Validate checks that a policy is usable
+func (p Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("policy name is required")
+	}
+	if p.Limit <= 0 {
+		return fmt.Errorf("policy %s: limit must be positive", p.Name)
+	}
+	if p.Window.Duration <= 0 {
+		return fmt.Errorf("policy %s: window must be positive", p.Name)
+	}
+	switch p.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmSlidingWindow:
+	default:
+		return fmt.Errorf("policy %s: unknown algorithm %q", p.Name, p.Algorithm)
+	}
+	return nil
+}
+
+// Capacity returns the token bucket size for the policy
+func (p Policy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Scaled returns a copy of the policy with limit and burst multiplied by factor
+func (p Policy) Scaled(factor float64) Policy {
+	if factor <= 0 || factor == 1 {
+		return p
+	}
+	scaled := p
+	scaled.Limit = max(1, int(float64(p.Limit)*factor))
+	if p.Burst > 0 {
+		scaled.Burst = max(1, int(float64(p.Burst)*factor))
+	}
+	return scaled
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time     // when the subject is back to full allowance
+	RetryAfter time.Duration // how long to wait before retrying when not allowed
+}
+
+// Limiter checks and records requests against a policy
+type Limiter interface {
+	Allow(ctx context.Context, key string, policy Policy) (*Result, error)
+}
+
+// Duration is a time.Duration that unmarshals from strings such as "1m"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		d.Duration = parsed
+	case float64:
+		d.Duration = time.Duration(value * float64(time.Second))
+	default:
+		return fmt.Errorf("invalid duration: %s", string(data))
+	}
+
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
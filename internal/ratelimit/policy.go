@@ -0,0 +1,324 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Scope determines which identity a route rule is keyed by
+type Scope string
+
+const (
+	// ScopeIP always limits by client IP, even for authenticated users
+	ScopeIP Scope = "ip"
+	// ScopeUser limits by user ID when authenticated and by client IP otherwise
+	ScopeUser Scope = "user"
+)
+
+// RouteRule applies a policy to requests matching a path pattern and methods
+type RouteRule struct {
+	// Pattern uses chi-style segments: "{param}" matches a single segment and
+	// a trailing "*" matches the remainder of the path
+	Pattern string   `json:"pattern"`
+	Methods []string `json:"methods,omitempty"` // empty matches every method
+	Policy  string   `json:"policy"`
+	Scope   Scope    `json:"scope,omitempty"`
+}
+
+// Plan adjusts the policies applied to users assigned to it
+type Plan struct {
+	// Multiplier scales the limit and burst of any policy not overridden below
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Policies replaces named policies for users on this plan
+	Policies map[string]Policy `json:"policies,omitempty"`
+}
+
+// Config holds all rate limit policies, route rules and plan assignments
+type Config struct {
+	// Anonymous is the policy name applied to unauthenticated requests
+	Anonymous string `json:"anonymous"`
+	// Authenticated is the policy name applied to authenticated requests
+	Authenticated string `json:"authenticated"`
+
+	Policies map[string]Policy `json:"policies"`
+	Routes   []RouteRule       `json:"routes"`
+	Plans    map[string]Plan   `json:"plans,omitempty"`
+	// Users maps user IDs to plan names
+	Users map[string]string `json:"users,omitempty"`
+}
+
+// Decision is the policy selected for a request
+type Decision struct {
+	Policy Policy
+	Key    string
+}
+
+// DefaultConfig returns the built-in policies
+func DefaultConfig() *Config {
+	return &Config{
+		Anonymous:     "ip",
+		Authenticated: "user",
+		Policies: map[string]Policy{
+			"ip": {
+				Name:      "ip",
+				Algorithm: AlgorithmSlidingWindow,
+				Limit:     100,
+				Window:    Duration{time.Minute},
+			},
+			"user": {
+				Name:      "user",
+				Algorithm: AlgorithmTokenBucket,
+				Limit:     300,
+				Window:    Duration{time.Minute},
+				Burst:     50,
+			},
+			"auth": {
+				Name:      "auth",
+				Algorithm: AlgorithmSlidingWindow,
+				Limit:     10,
+				Window:    Duration{time.Minute},
+			},
+			"notes-search": {
+				Name:      "notes-search",
+				Algorithm: AlgorithmTokenBucket,
+				Limit:     30,
+				Window:    Duration{time.Minute},
+				Burst:     10,
+			},
+			"notes-bulk": {
+				Name:      "notes-bulk",
+				Algorithm: AlgorithmTokenBucket,
+				Limit:     10,
+				Window:    Duration{time.Minute},
+				Burst:     5,
+			},
+			"share": {
+				Name:      "share",
+				Algorithm: AlgorithmSlidingWindow,
+				Limit:     30,
+				Window:    Duration{time.Minute},
+			},
+		},
+		Routes: []RouteRule{
+			{Pattern: "/api/v1/auth/login", Methods: []string{"POST"}, Policy: "auth", Scope: ScopeIP},
+			{Pattern: "/api/v1/auth/register", Methods: []string{"POST"}, Policy: "auth", Scope: ScopeIP},
+			{Pattern: "/api/v1/auth/refresh", Methods: []string{"POST"}, Policy: "auth", Scope: ScopeIP},
+			{Pattern: "/api/v1/notes/search", Methods: []string{"POST"}, Policy: "notes-search"},
+			{Pattern: "/api/v1/notes/bulk", Methods: []string{"POST"}, Policy: "notes-bulk"},
+			// Share links are unauthenticated and may be password protected
+			{Pattern: "/s/{slug}", Policy: "share", Scope: ScopeIP},
+		},
+	}
+}
+
+// LoadConfig reads a JSON policy file and merges it over the defaults.
+// Policies and plans are merged by name; routes from the file are matched
+// before the built-in routes.
+func LoadConfig(path string, base *Config) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit config: %w", err)
+	}
+
+	var fileCfg Config
+	if err := json.Unmarshal(data, &fileCfg); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit config: %w", err)
+	}
+
+	cfg := base.clone()
+	if fileCfg.Anonymous != "" {
+		cfg.Anonymous = fileCfg.Anonymous
+	}
+	if fileCfg.Authenticated != "" {
+		cfg.Authenticated = fileCfg.Authenticated
+	}
+	for name, policy := range fileCfg.Policies {
+		if policy.Name == "" {
+			policy.Name = name
+		}
+		cfg.Policies[name] = policy
+	}
+	cfg.Routes = append(fileCfg.Routes, cfg.Routes...)
+	for name, plan := range fileCfg.Plans {
+		cfg.Plans[name] = plan
+	}
+	for userID, plan := range fileCfg.Users {
+		cfg.Users[userID] = plan
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that every referenced policy and plan exists
+func (c *Config) Validate() error {
+	for name, policy := range c.Policies {
+		if policy.Name != name {
+			return fmt.Errorf("policy key %s does not match policy name %s", name, policy.Name)
+		}
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := c.Policies[c.Anonymous]; !ok {
+		return fmt.Errorf("anonymous policy %s is not defined", c.Anonymous)
+	}
+	if _, ok := c.Policies[c.Authenticated]; !ok {
+		return fmt.Errorf("authenticated policy %s is not defined", c.Authenticated)
+	}
+
+	for _, route := range c.Routes {
+		if route.Pattern == "" {
+			return fmt.Errorf("route pattern is required")
+		}
+		if _, ok := c.Policies[route.Policy]; !ok {
+			return fmt.Errorf("route %s references unknown policy %s", route.Pattern, route.Policy)
+		}
+		if route.Scope != "" && route.Scope != ScopeIP && route.Scope != ScopeUser {
+			return fmt.Errorf("route %s has unknown scope %s", route.Pattern, route.Scope)
+		}
+	}
+
+	for name, plan := range c.Plans {
+		for policyName, policy := range plan.Policies {
+			if policy.Name == "" {
+				policy.Name = policyName
+			}
+			if err := policy.Validate(); err != nil {
+				return fmt.Errorf("plan %s: %w", name, err)
+			}
+		}
+	}
+
+	for userID, plan := range c.Users {
+		if _, ok := c.Plans[plan]; !ok {
+			return fmt.Errorf("user %s is assigned unknown plan %s", userID, plan)
+		}
+	}
+
+	return nil
+}
+
+// Resolve selects the policy and limiter key for a request. userID is empty
+// for unauthenticated requests.
+func (c *Config) Resolve(method, path, clientIP, userID string) Decision {
+	policyName := c.Anonymous
+	if userID != "" {
+		policyName = c.Authenticated
+	}
+	scope := ScopeUser
+
+	for _, route := range c.Routes {
+		if route.matches(method, path) {
+			policyName = route.Policy
+			if route.Scope != "" {
+				scope = route.Scope
+			}
+			break
+		}
+	}
+
+	subject := "ip:" + clientIP
+	if scope == ScopeUser && userID != "" {
+		subject = "user:" + userID
+	}
+
+	policy := c.Policies[policyName]
+	if userID != "" && scope == ScopeUser {
+		policy = c.applyPlan(userID, policy)
+	}
+
+	return Decision{
+		Policy: policy,
+		Key:    fmt.Sprintf("rate_limit:%s:%s", policyName, subject),
+	}
+}
+
+// applyPlan applies the user's plan overrides to a policy
+func (c *Config) applyPlan(userID string, policy Policy) Policy {
+	planName, ok := c.Users[userID]
+	if !ok {
+		return policy
+	}
+	plan := c.Plans[planName]
+
+	if override, ok := plan.Policies[policy.Name]; ok {
+		override.Name = policy.Name
+		return override
+	}
+
+	return policy.Scaled(plan.Multiplier)
+}
+
+// clone returns a deep copy of the config
+func (c *Config) clone() *Config {
+	cfg := &Config{
+		Anonymous:     c.Anonymous,
+		Authenticated: c.Authenticated,
+		Policies:      make(map[string]Policy, len(c.Policies)),
+		Routes:        append([]RouteRule(nil), c.Routes...),
+		Plans:         make(map[string]Plan, len(c.Plans)),
+		Users:         make(map[string]string, len(c.Users)),
+	}
+	for name, policy := range c.Policies {
+		cfg.Policies[name] = policy
+	}
+	for name, plan := range c.Plans {
+		cfg.Plans[name] = plan
+	}
+	for userID, plan := range c.Users {
+		cfg.Users[userID] = plan
+	}
+	return cfg
+}
+
+// matches checks if a rule applies to a request
+func (r RouteRule) matches(method, path string) bool {
+	if len(r.Methods) > 0 {
+		found := false
+		for _, m := range r.Methods {
+			if strings.EqualFold(m, method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return matchPattern(r.Pattern, path)
+}
+
+// matchPattern matches a path against a chi-style route pattern
+func matchPattern(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
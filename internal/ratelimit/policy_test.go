@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig_Resolve(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Plans = map[string]Plan{
+		"pro": {Multiplier: 2},
+		"enterprise": {Policies: map[string]Policy{
+			"notes-search": {Algorithm: AlgorithmSlidingWindow, Limit: 500, Window: cfg.Policies["user"].Window},
+		}},
+	}
+	cfg.Users = map[string]string{
+		"pro-user":        "pro",
+		"enterprise-user": "enterprise",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		userID      string
+		expectKey   string
+		expectLimit int
+	}{
+		{
+			name:        "anonymous request uses ip policy",
+			method:      "GET",
+			path:        "/api/v1/notes/public",
+			expectKey:   "rate_limit:ip:ip:1.2.3.4",
+			expectLimit: 100,
+		},
+		{
+			name:        "authenticated request uses user policy",
+			method:      "GET",
+			path:        "/api/v1/notes",
+			userID:      "u1",
+			expectKey:   "rate_limit:user:user:u1",
+			expectLimit: 300,
+		},
+		{
+			name:        "auth route is always keyed by ip",
+			method:      "POST",
+			path:        "/api/v1/auth/login",
+			userID:      "u1",
+			expectKey:   "rate_limit:auth:ip:1.2.3.4",
+			expectLimit: 10,
+		},
+		{
+			name:        "route rule only matches its methods",
+			method:      "GET",
+			path:        "/api/v1/notes/search",
+			userID:      "u1",
+			expectKey:   "rate_limit:user:user:u1",
+			expectLimit: 300,
+		},
+		{
+			name:        "stricter search policy",
+			method:      "POST",
+			path:        "/api/v1/notes/search",
+			userID:      "u1",
+			expectKey:   "rate_limit:notes-search:user:u1",
+			expectLimit: 30,
+		},
+		{
+			name:        "plan multiplier scales policy",
+			method:      "POST",
+			path:        "/api/v1/notes/bulk",
+			userID:      "pro-user",
+			expectKey:   "rate_limit:notes-bulk:user:pro-user",
+			expectLimit: 20,
+		},
+		{
+			name:        "plan override replaces named policy",
+			method:      "POST",
+			path:        "/api/v1/notes/search",
+			userID:      "enterprise-user",
+			expectKey:   "rate_limit:notes-search:user:enterprise-user",
+			expectLimit: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := cfg.Resolve(tt.method, tt.path, "1.2.3.4", tt.userID)
+			if decision.Key != tt.expectKey {
+				t.Errorf("expected key %s, got %s", tt.expectKey, decision.Key)
+			}
+			if decision.Policy.Limit != tt.expectLimit {
+				t.Errorf("expected limit %d, got %d", tt.expectLimit, decision.Policy.Limit)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/api/v1/notes/search", "/api/v1/notes/search", true},
+		{"/api/v1/notes/search", "/api/v1/notes/search/", true},
+		{"/api/v1/notes/{id}", "/api/v1/notes/123", true},
+		{"/api/v1/notes/{id}", "/api/v1/notes/123/restore", false},
+		{"/api/v1/notes/{id}/restore", "/api/v1/notes/123/restore", true},
+		{"/api/v1/notes/*", "/api/v1/notes/123/restore", true},
+		{"/api/v1/notes/*", "/api/v1/user", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.path); got != tt.match {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	data := `{
+		"policies": {"export": {"algorithm": "token_bucket", "limit": 5, "window": "1m"}},
+		"routes": [{"pattern": "/api/v1/notes/{id}/duplicate", "methods": ["POST"], "policy": "export"}],
+		"plans": {"pro": {"multiplier": 3}},
+		"users": {"u1": "pro"}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path, DefaultConfig())
+	if err != nil {
+		t.Fatalf("expected config to load, got %v", err)
+	}
+
+	decision := cfg.Resolve("POST", "/api/v1/notes/abc/duplicate", "1.2.3.4", "u1")
+	if decision.Policy.Name != "export" || decision.Policy.Limit != 15 {
+		t.Errorf("expected scaled export policy, got %+v", decision.Policy)
+	}
+
+	// Built-in routes remain after the file's routes
+	if decision := cfg.Resolve("POST", "/api/v1/auth/login", "1.2.3.4", ""); decision.Policy.Name != "auth" {
+		t.Errorf("expected auth policy, got %s", decision.Policy.Name)
+	}
+
+	badPath := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(badPath, []byte(`{"users": {"u1": "missing"}}`), 0o600)
+	if _, err := LoadConfig(badPath, DefaultConfig()); err == nil {
+		t.Error("expected error for unknown plan")
+	}
+}
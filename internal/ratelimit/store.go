@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gonotes/internal/store"
+	"gonotes/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StoreLimiter enforces policies using the atomic primitives of a store, so
+// limits are distributed with Redis and local with the in-memory store
+type StoreLimiter struct {
+	store store.Store
+}
+
+// NewStoreLimiter creates a new store-backed limiter
+func NewStoreLimiter(st store.Store) *StoreLimiter {
+	return &StoreLimiter{store: st}
+}
+
+// Allow checks a request against the policy and records it when allowed
+func (l *StoreLimiter) Allow(ctx context.Context, key string, policy Policy) (*Result, error) {
+	ctx, span := tracing.Start(ctx, "ratelimit.Allow",
+		attribute.String("ratelimit.policy", policy.Name),
+		attribute.String("ratelimit.algorithm", string(policy.Algorithm)),
+	)
+	defer span.End()
+
+	var (
+		limitResult *store.LimitResult
+		limit       int
+		err         error
+	)
+
+	switch policy.Algorithm {
+	case AlgorithmTokenBucket:
+		limit = policy.Capacity()
+		limitResult, err = l.store.TokenBucket(ctx, key, limit, policy.Limit, policy.Window.Duration)
+	case AlgorithmSlidingWindow:
+		limit = policy.Limit
+		limitResult, err = l.store.SlidingWindow(ctx, key, limit, policy.Window.Duration)
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm: %s", policy.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Bool("ratelimit.allowed", limitResult.Allowed))
+
+	return &Result{
+		Allowed:    limitResult.Allowed,
+		Limit:      limit,
+		Remaining:  limitResult.Remaining,
+		ResetAt:    time.Now().Add(limitResult.ResetIn),
+		RetryAfter: limitResult.RetryAfter,
+	}, nil
+}
@@ -0,0 +1,593 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// MemoryNoteRepository keeps notes in memory. Filtering, search, sorting and
+// pagination follow the PostgreSQL implementation so services behave the
+// same against either backend.
+type MemoryNoteRepository struct {
+	mu    sync.RWMutex
+	notes map[uuid.UUID]*model.Note
+	order []uuid.UUID // insertion order, used as a stable tie-breaker
+	users *MemoryUserRepository
+}
+
+// NewMemoryNoteRepository creates a new in-memory note repository
+func NewMemoryNoteRepository() *MemoryNoteRepository {
+	return &MemoryNoteRepository{
+		notes: make(map[uuid.UUID]*model.Note),
+	}
+}
+
+// SetUsers gives PurgeDeleted the users whose trash retention it honours,
+// as the SQL implementations join the users table. Without it every user
+// gets the default retention.
+func (r *MemoryNoteRepository) SetUsers(users *MemoryUserRepository) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users = users
+}
+
+// Create creates a new note
+func (r *MemoryNoteRepository) Create(ctx context.Context, note *model.Note) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.notes[note.ID]; exists {
+		return fmt.Errorf("failed to create note: duplicate id %s", note.ID)
+	}
+
+	now := time.Now()
+	note.CreatedAt = now
+	note.UpdatedAt = now
+
+	r.notes[note.ID] = cloneNote(note)
+	r.order = append(r.order, note.ID)
+	return nil
+}
+
+// GetByID retrieves a note by ID
+func (r *MemoryNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	note, exists := r.notes[id]
+	if !exists {
+		return nil, nil
+	}
+	return cloneNote(note), nil
+}
+
+// GetByIDAndUserID retrieves a note by ID and user ID (for security)
+func (r *MemoryNoteRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*model.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	note, exists := r.notes[id]
+	if !exists || note.UserID != userID {
+		return nil, nil
+	}
+	return cloneNote(note), nil
+}
+
+// Update updates an existing note
+func (r *MemoryNoteRepository) Update(ctx context.Context, note *model.Note) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.notes[note.ID]
+	if !exists || existing.UserID != note.UserID {
+		return fmt.Errorf("note not found or no permission to update")
+	}
+
+	note.UpdatedAt = time.Now()
+
+	updated := cloneNote(note)
+	updated.CreatedAt = existing.CreatedAt
+	updated.ViewCount = existing.ViewCount
+	r.notes[note.ID] = updated
+	return nil
+}
+
+// Delete soft deletes a note (sets status to deleted and deleted_at timestamp)
+func (r *MemoryNoteRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists || note.UserID != userID || note.Status == model.NoteStatusDeleted {
+		return fmt.Errorf("note not found or already deleted")
+	}
+
+	now := time.Now()
+	note.Status = model.NoteStatusDeleted
+	note.DeletedAt = &now
+	note.UpdatedAt = now
+	note.PublishAt, note.UnpublishAt = nil, nil
+	return nil
+}
+
+// Restore restores a soft-deleted note
+func (r *MemoryNoteRepository) Restore(ctx context.Context, id, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists || note.UserID != userID || note.Status != model.NoteStatusDeleted {
+		return fmt.Errorf("note not found or not deleted")
+	}
+
+	note.Status = model.NoteStatusActive
+	note.DeletedAt = nil
+	note.UpdatedAt = time.Now()
+	note.PublishAt, note.UnpublishAt = nil, nil
+	return nil
+}
+
+// HardDelete permanently deletes a note
+func (r *MemoryNoteRepository) HardDelete(ctx context.Context, id, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, exists := r.notes[id]
+	if !exists || note.UserID != userID {
+		return fmt.Errorf("note not found")
+	}
+
+	r.remove(id)
+	return nil
+}
+
+// remove drops a note and its place in the insertion order; callers hold the lock
+func (r *MemoryNoteRepository) remove(id uuid.UUID) {
+	delete(r.notes, id)
+	for i, noteID := range r.order {
+		if noteID == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// PurgeDeleted hard deletes up to limit notes that have been in the trash
+// longer than their owner's retention, or defaultDays when the owner has
+// none, and returns them with ID, UserID and DeletedAt set
+func (r *MemoryNoteRepository) PurgeDeleted(ctx context.Context, now time.Time, defaultDays, limit int) ([]model.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*model.Note
+	for _, note := range r.notes {
+		if note.Status != model.NoteStatusDeleted || note.DeletedAt == nil {
+			continue
+		}
+		days := defaultDays
+		if r.users != nil {
+			if user, _ := r.users.GetByID(ctx, note.UserID); user != nil && user.TrashRetentionDays != nil {
+				days = *user.TrashRetentionDays
+			}
+		}
+		if note.DeletedAt.Before(now.AddDate(0, 0, -days)) {
+			due = append(due, note)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].DeletedAt.Before(*due[j].DeletedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	purged := make([]model.Note, 0, len(due))
+	for _, note := range due {
+		purged = append(purged, model.Note{ID: note.ID, UserID: note.UserID, DeletedAt: note.DeletedAt})
+		r.remove(note.ID)
+	}
+	return purged, nil
+}
+
+// EmptyTrash hard deletes every deleted note of a user and returns their IDs
+func (r *MemoryNoteRepository) EmptyTrash(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []uuid.UUID
+	for id, note := range r.notes {
+		if note.UserID == userID && note.Status == model.NoteStatusDeleted {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		r.remove(id)
+	}
+	return ids, nil
+}
+
+// GetByUserID retrieves notes by user ID with pagination and filtering
+func (r *MemoryNoteRepository) GetByUserID(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	params.SetDefaults()
+	tags := params.GetTagsArray()
+
+	notes := r.filter(func(note *model.Note) bool {
+		return note.UserID == userID &&
+			(params.Status == "all" || string(note.Status) == params.Status) &&
+			(params.IsPublic == nil || note.IsPublic == *params.IsPublic) &&
+			(params.Search == "" || matchesSearch(note, params.Search)) &&
+			matchesTags(note, tags)
+	})
+
+	sortNotes(notes, params.SortBy, params.SortDir)
+	page, total := paginate(notes, params.Page, params.PageSize)
+	return page, total, nil
+}
+
+// Search performs advanced search across notes
+func (r *MemoryNoteRepository) Search(ctx context.Context, userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error) {
+	req.SetDefaults()
+
+	var dateFrom, dateTo *time.Time
+	if req.DateFrom != nil {
+		from, err := time.Parse("2006-01-02", *req.DateFrom)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count search results: invalid date_from: %w", err)
+		}
+		dateFrom = &from
+	}
+	if req.DateTo != nil {
+		to, err := time.Parse("2006-01-02", *req.DateTo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count search results: invalid date_to: %w", err)
+		}
+		dateTo = &to
+	}
+
+	terms := words(req.Query)
+	notes := r.filter(func(note *model.Note) bool {
+		return note.UserID == userID &&
+			(req.Status == "all" || string(note.Status) == req.Status) &&
+			(req.IsPublic == nil || note.IsPublic == *req.IsPublic) &&
+			(req.Query == "" || matchesTerms(note, terms)) &&
+			matchesTags(note, req.Tags) &&
+			(dateFrom == nil || !note.CreatedAt.Before(*dateFrom)) &&
+			(dateTo == nil || !note.CreatedAt.After(*dateTo))
+	})
+
+	// Rank by how often the terms occur, then most recently updated
+	ranks := make(map[uuid.UUID]int, len(notes))
+	for i := range notes {
+		ranks[notes[i].ID] = rankTerms(&notes[i], terms)
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		if ranks[notes[i].ID] != ranks[notes[j].ID] {
+			return ranks[notes[i].ID] > ranks[notes[j].ID]
+		}
+		return notes[i].UpdatedAt.After(notes[j].UpdatedAt)
+	})
+
+	page, total := paginate(notes, req.Page, req.PageSize)
+	if !req.IncludeContent {
+		for i := range page {
+			page[i].Content = nil
+		}
+	}
+	return page, total, nil
+}
+
+// AddViewCounts adds views to several notes
+func (r *MemoryNoteRepository) AddViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, views := range counts {
+		if note, exists := r.notes[id]; exists {
+			note.ViewCount += views
+		}
+	}
+	return nil
+}
+
+// PublishDue makes public the notes whose publish_at has passed, turning
+// drafts active, and returns their IDs
+func (r *MemoryNoteRepository) PublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []uuid.UUID
+	for _, note := range r.notes {
+		if note.PublishAt == nil || note.PublishAt.After(now) || note.Status == model.NoteStatusDeleted {
+			continue
+		}
+		note.IsPublic = true
+		note.Status = model.NoteStatusActive
+		note.PublishAt = nil
+		note.UpdatedAt = now
+		ids = append(ids, note.ID)
+	}
+	return ids, nil
+}
+
+// UnpublishDue makes private the notes whose unpublish_at has passed and
+// returns their IDs
+func (r *MemoryNoteRepository) UnpublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []uuid.UUID
+	for _, note := range r.notes {
+		if note.UnpublishAt == nil || note.UnpublishAt.After(now) || note.Status == model.NoteStatusDeleted {
+			continue
+		}
+		note.IsPublic = false
+		note.UnpublishAt = nil
+		note.UpdatedAt = now
+		ids = append(ids, note.ID)
+	}
+	return ids, nil
+}
+
+// GetPublicNotes retrieves public notes with pagination
+func (r *MemoryNoteRepository) GetPublicNotes(ctx context.Context, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	params.SetDefaults()
+	tags := params.GetTagsArray()
+
+	notes := r.filter(func(note *model.Note) bool {
+		return note.IsPublic && note.Status == model.NoteStatusActive &&
+			(params.Search == "" || matchesSearch(note, params.Search)) &&
+			(params.AuthorID == nil || note.UserID == *params.AuthorID) &&
+			matchesTags(note, tags)
+	})
+
+	sortNotes(notes, params.SortBy, params.SortDir)
+	page, total := paginate(notes, params.Page, params.PageSize)
+	return page, total, nil
+}
+
+// BulkUpdateStatus updates status for multiple notes and returns how many
+// were updated; IDs of other users' notes are skipped, so none updated is 0.
+// MemoryTxManager cannot roll back, so when any ID is missing nothing is
+// changed, keeping the all-or-none outcome of the SQL repositories.
+func (r *MemoryNoteRepository) BulkUpdateStatus(ctx context.Context, userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) (int64, error) {
+	if len(noteIDs) == 0 {
+		return 0, fmt.Errorf("no note IDs provided")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := make(map[uuid.UUID]*model.Note)
+	for _, id := range noteIDs {
+		note, exists := r.notes[id]
+		if !exists || note.UserID != userID {
+			return 0, nil
+		}
+		found[id] = note
+	}
+
+	now := time.Now()
+	for _, note := range found {
+		// Schedules do not survive deleting or restoring a note
+		if status == model.NoteStatusDeleted || note.Status == model.NoteStatusDeleted {
+			note.PublishAt, note.UnpublishAt = nil, nil
+		}
+		// deleted_at follows the status, as the trigger does in PostgreSQL
+		if status == model.NoteStatusDeleted && note.DeletedAt == nil {
+			note.DeletedAt = &now
+		} else if status != model.NoteStatusDeleted {
+			note.DeletedAt = nil
+		}
+		note.Status = status
+		note.UpdatedAt = now
+	}
+	return int64(len(found)), nil
+}
+
+// GetNoteStats returns statistics for user's notes
+func (r *MemoryNoteRepository) GetNoteStats(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total, active, drafts, deleted, public, totalViews int64
+	for _, note := range r.notes {
+		if note.UserID != userID {
+			continue
+		}
+		total++
+		totalViews += note.ViewCount
+		switch note.Status {
+		case model.NoteStatusActive:
+			active++
+			if note.IsPublic {
+				public++
+			}
+		case model.NoteStatusDraft:
+			drafts++
+		case model.NoteStatusDeleted:
+			deleted++
+		}
+	}
+
+	return map[string]interface{}{
+		"total":       total,
+		"active":      active,
+		"drafts":      drafts,
+		"deleted":     deleted,
+		"public":      public,
+		"total_views": totalViews,
+	}, nil
+}
+
+// filter returns copies of the notes matching keep, in insertion order
+func (r *MemoryNoteRepository) filter(keep func(note *model.Note) bool) []model.Note {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notes []model.Note
+	for _, id := range r.order {
+		if note := r.notes[id]; keep(note) {
+			notes = append(notes, *cloneNote(note))
+		}
+	}
+	return notes
+}
+
+// cloneNote copies a note so callers cannot mutate stored state
+func cloneNote(note *model.Note) *model.Note {
+	clone := *note
+	if note.DeletedAt != nil {
+		deletedAt := *note.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	if note.PublishAt != nil {
+		publishAt := *note.PublishAt
+		clone.PublishAt = &publishAt
+	}
+	if note.UnpublishAt != nil {
+		unpublishAt := *note.UnpublishAt
+		clone.UnpublishAt = &unpublishAt
+	}
+	return &clone
+}
+
+// sortNotes orders notes like ORDER BY sortBy sortDir
+func sortNotes(notes []model.Note, sortBy, sortDir string) {
+	less := func(a, b *model.Note) int {
+		switch sortBy {
+		case "created_at":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case "title":
+			return strings.Compare(a.Title, b.Title)
+		case "view_count":
+			return compareInt64(a.ViewCount, b.ViewCount)
+		default:
+			return a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		if sortDir == "asc" {
+			return less(&notes[i], &notes[j]) < 0
+		}
+		return less(&notes[i], &notes[j]) > 0
+	})
+}
+
+// compareInt64 returns -1, 0 or +1 like strings.Compare
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// paginate returns the requested page and the total count
+func paginate(notes []model.Note, page, pageSize int) ([]model.Note, int64) {
+	total := int64(len(notes))
+	start := (page - 1) * pageSize
+	if start >= len(notes) {
+		return nil, total
+	}
+	end := start + pageSize
+	if end > len(notes) {
+		end = len(notes)
+	}
+	return notes[start:end], total
+}
+
+// matchesSearch mirrors the list filter: full-text match or ILIKE on title/content
+func matchesSearch(note *model.Note, search string) bool {
+	needle := strings.ToLower(search)
+	if strings.Contains(strings.ToLower(note.Title), needle) {
+		return true
+	}
+	if note.Content != nil && strings.Contains(strings.ToLower(*note.Content), needle) {
+		return true
+	}
+	return matchesTerms(note, words(search))
+}
+
+// matchesTerms approximates plainto_tsquery: every term must appear in the
+// title, or every term in the content. Terms match word prefixes to stand in
+// for stemming ("program" matches "programming").
+func matchesTerms(note *model.Note, terms []string) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	if containsAllTerms(words(note.Title), terms) {
+		return true
+	}
+	return note.Content != nil && containsAllTerms(words(*note.Content), terms)
+}
+
+// rankTerms counts term occurrences in title and content
+func rankTerms(note *model.Note, terms []string) int {
+	docWords := words(note.Title)
+	if note.Content != nil {
+		docWords = append(docWords, words(*note.Content)...)
+	}
+
+	rank := 0
+	for _, word := range docWords {
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				rank++
+			}
+		}
+	}
+	return rank
+}
+
+// containsAllTerms reports whether every term prefixes at least one word
+func containsAllTerms(docWords, terms []string) bool {
+	for _, term := range terms {
+		found := false
+		for _, word := range docWords {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// words splits text into lower-case words on anything but letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchesTags mirrors the OR of "tags ILIKE %tag%" conditions
+func matchesTags(note *model.Note, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if note.Tags == nil {
+		return false
+	}
+
+	noteTags := strings.ToLower(*note.Tags)
+	for _, tag := range tags {
+		if strings.Contains(noteTags, strings.ToLower(tag)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func seedNotes(t *testing.T, repo NoteRepository, userID uuid.UUID) {
+	t.Helper()
+
+	notes := []struct {
+		title, content, tags string
+		status               model.NoteStatus
+		public               bool
+	}{
+		{"Go Programming", "Learning the Go language", "go,dev", model.NoteStatusActive, true},
+		{"Database Design", "Indexes and normalization", "db", model.NoteStatusActive, false},
+		{"Go Advanced", "Concurrency in Go with channels", "go", model.NoteStatusDraft, false},
+		{"Old Idea", "Something deleted", "misc", model.NoteStatusDeleted, true},
+	}
+	for _, n := range notes {
+		content, tags := n.content, n.tags
+		note := &model.Note{
+			ID:       uuid.New(),
+			UserID:   userID,
+			Title:    n.title,
+			Content:  &content,
+			Tags:     &tags,
+			Status:   n.status,
+			IsPublic: n.public,
+		}
+		// Notes are created live and deleted afterwards, as through the API
+		if n.status == model.NoteStatusDeleted {
+			note.Status = model.NoteStatusActive
+		}
+		if err := repo.Create(context.Background(), note); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if n.status == model.NoteStatusDeleted {
+			if err := repo.Delete(context.Background(), note.ID, userID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+		}
+	}
+}
+
+func TestMemoryNoteRepository_GetByUserID(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryNoteRepository()
+	userID := uuid.New()
+	seedNotes(t, repo, userID)
+
+	tests := []struct {
+		name      string
+		params    model.GetNotesParams
+		wantTotal int64
+		wantFirst string
+	}{
+		{"defaults to active", model.GetNotesParams{}, 2, "Database Design"},
+		{"all statuses", model.GetNotesParams{Status: "all"}, 4, "Old Idea"},
+		{"sort by title asc", model.GetNotesParams{Status: "all", SortBy: "title", SortDir: "asc"}, 4, "Database Design"},
+		{"tag filter", model.GetNotesParams{Status: "all", Tags: "GO"}, 2, "Go Advanced"},
+		{"search matches words and substrings", model.GetNotesParams{Status: "all", Search: "concurr"}, 1, "Go Advanced"},
+		{"public filter", model.GetNotesParams{IsPublic: boolPtr(true)}, 1, "Go Programming"},
+		{"pagination", model.GetNotesParams{Status: "all", Page: 2, PageSize: 3}, 4, "Go Programming"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			notes, total, err := repo.GetByUserID(ctx, userID, &params)
+			if err != nil {
+				t.Fatalf("GetByUserID() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+			if len(notes) == 0 || notes[0].Title != tt.wantFirst {
+				t.Errorf("first note = %v, want %q", notes, tt.wantFirst)
+			}
+		})
+	}
+
+	if notes, total, _ := repo.GetByUserID(ctx, uuid.New(), &model.GetNotesParams{}); total != 0 || len(notes) != 0 {
+		t.Errorf("other user sees %d notes", total)
+	}
+}
+
+func TestMemoryNoteRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryNoteRepository()
+	userID := uuid.New()
+	seedNotes(t, repo, userID)
+
+	notes, total, err := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "go", Status: "all"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("total = %d, want 2", total)
+	}
+	// "Go Advanced" mentions go twice and ranks first
+	if notes[0].Title != "Go Advanced" {
+		t.Errorf("first result = %q, want Go Advanced", notes[0].Title)
+	}
+	if notes[0].Content != nil {
+		t.Errorf("content returned without include_content")
+	}
+
+	// All terms must match within the title or within the content
+	if _, total, _ := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "go database", Status: "all"}); total != 0 {
+		t.Errorf("terms split across notes matched %d notes", total)
+	}
+}
+
+func TestMemoryNoteRepository_DeleteRestoreStats(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryNoteRepository()
+	userID := uuid.New()
+	seedNotes(t, repo, userID)
+
+	notes, _, _ := repo.GetByUserID(ctx, userID, &model.GetNotesParams{})
+	id := notes[0].ID
+
+	if err := repo.Delete(ctx, id, uuid.New()); err == nil {
+		t.Error("Delete() by another user succeeded")
+	}
+	if err := repo.Delete(ctx, id, userID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete(ctx, id, userID); err == nil {
+		t.Error("Delete() of a deleted note succeeded")
+	}
+	if err := repo.AddViewCounts(ctx, map[uuid.UUID]int64{id: 1}); err != nil {
+		t.Fatalf("AddViewCounts() error = %v", err)
+	}
+
+	stats, err := repo.GetNoteStats(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetNoteStats() error = %v", err)
+	}
+	want := map[string]int64{"total": 4, "active": 1, "drafts": 1, "deleted": 2, "public": 1, "total_views": 1}
+	for key, value := range want {
+		if stats[key] != value {
+			t.Errorf("stats[%s] = %v, want %d", key, stats[key], value)
+		}
+	}
+
+	if err := repo.Restore(ctx, id, userID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if note, _ := repo.GetByID(ctx, id); note.IsDeleted() {
+		t.Error("note still deleted after Restore()")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gonotes/internal/model"
+)
+
+// MemoryOutboxRepository keeps outbox messages in memory
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages []*model.OutboxMessage
+}
+
+// NewMemoryOutboxRepository creates a new in-memory outbox repository
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+// Add records a message
+func (r *MemoryOutboxRepository) Add(ctx context.Context, msg *model.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	msg.ID = r.nextID
+	msg.CreatedAt = time.Now()
+
+	clone := *msg
+	r.messages = append(r.messages, &clone)
+	return nil
+}
+
+// ClaimDue leases up to limit pending messages that are due
+func (r *MemoryOutboxRepository) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimed []model.OutboxMessage
+	for _, msg := range r.messages {
+		if len(claimed) >= limit {
+			break
+		}
+		if msg.ProcessedAt == nil && !msg.NextAttemptAt.After(now) {
+			msg.NextAttemptAt = leaseUntil
+			claimed = append(claimed, *msg)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkProcessed records that a message was delivered
+func (r *MemoryOutboxRepository) MarkProcessed(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.find(id)
+	if msg == nil {
+		return fmt.Errorf("outbox message %d not found", id)
+	}
+	now := time.Now()
+	msg.ProcessedAt = &now
+	msg.LastError = nil
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to retry it
+func (r *MemoryOutboxRepository) MarkFailed(ctx context.Context, id int64, errMsg string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.find(id)
+	if msg == nil {
+		return fmt.Errorf("outbox message %d not found", id)
+	}
+	msg.Attempts++
+	msg.LastError = &errMsg
+	msg.NextAttemptAt = retryAt
+	return nil
+}
+
+// DeleteProcessedBefore removes messages delivered before the given time
+func (r *MemoryOutboxRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.messages[:0]
+	for _, msg := range r.messages {
+		if msg.ProcessedAt == nil || !msg.ProcessedAt.Before(before) {
+			kept = append(kept, msg)
+		}
+	}
+	deleted := int64(len(r.messages) - len(kept))
+	r.messages = kept
+	return deleted, nil
+}
+
+// Pending returns the messages not delivered yet
+func (r *MemoryOutboxRepository) Pending() []model.OutboxMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending []model.OutboxMessage
+	for _, msg := range r.messages {
+		if msg.ProcessedAt == nil {
+			pending = append(pending, *msg)
+		}
+	}
+	return pending
+}
+
+// find returns the stored message with id; callers hold the lock
+func (r *MemoryOutboxRepository) find(id int64) *model.OutboxMessage {
+	for _, msg := range r.messages {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// MemorySessionRepository keeps sessions in memory
+type MemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]*model.Session
+}
+
+// NewMemorySessionRepository creates a new in-memory session repository
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[uuid.UUID]*model.Session),
+	}
+}
+
+// Create creates a new session
+func (r *MemorySessionRepository) Create(ctx context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.ID]; exists {
+		return fmt.Errorf("failed to create session: duplicate id %s", session.ID)
+	}
+
+	r.sessions[session.ID] = cloneSession(session)
+	return nil
+}
+
+// GetByRefreshToken retrieves a valid session by refresh token
+func (r *MemorySessionRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, session := range r.sessions {
+		if session.RefreshToken == refreshToken && session.IsValid {
+			return cloneSession(session), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByUserID retrieves all valid sessions for a user, newest first
+func (r *MemorySessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []model.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsValid {
+			sessions = append(sessions, *cloneSession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// GetUserSessions retrieves all active sessions for a user
+func (r *MemorySessionRepository) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
+// GetSessionByIDAndUserID retrieves a valid session owned by the user
+func (r *MemorySessionRepository) GetSessionByIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) (*model.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[sessionID]
+	if !exists || session.UserID != userID || !session.IsValid {
+		return nil, nil
+	}
+	return cloneSession(session), nil
+}
+
+// InvalidateByRefreshToken marks a session as invalid by refresh token
+func (r *MemorySessionRepository) InvalidateByRefreshToken(ctx context.Context, refreshToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := false
+	for _, session := range r.sessions {
+		if session.RefreshToken == refreshToken {
+			session.IsValid = false
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// InvalidateBySessionID marks a session as invalid by session ID
+func (r *MemorySessionRepository) InvalidateBySessionID(ctx context.Context, sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+	session.IsValid = false
+	return nil
+}
+
+// InvalidateBySessionIDAndUserID marks a valid session owned by the user as invalid
+func (r *MemorySessionRepository) InvalidateBySessionIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[sessionID]
+	if !exists || session.UserID != userID || !session.IsValid {
+		return fmt.Errorf("session not found or not owned by user")
+	}
+	session.IsValid = false
+	return nil
+}
+
+// InvalidateAllByUserID marks all sessions as invalid for a user
+func (r *MemorySessionRepository) InvalidateAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			session.IsValid = false
+		}
+	}
+	return nil
+}
+
+// CleanupExpiredSessions removes expired sessions, and sessions without an
+// expiry that are older than 30 days
+func (r *MemorySessionRepository) CleanupExpiredSessions(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	thirtyDaysAgo := now.AddDate(0, 0, -30)
+	for id, session := range r.sessions {
+		if (session.ExpiresAt != nil && session.ExpiresAt.Before(now)) ||
+			(session.ExpiresAt == nil && session.CreatedAt.Before(thirtyDaysAgo)) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+// cloneSession copies a session so callers cannot mutate stored state
+func cloneSession(session *model.Session) *model.Session {
+	clone := *session
+	if session.ExpiresAt != nil {
+		expiresAt := *session.ExpiresAt
+		clone.ExpiresAt = &expiresAt
+	}
+	return &clone
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// MemoryShareLinkRepository keeps share links in memory
+type MemoryShareLinkRepository struct {
+	mu    sync.RWMutex
+	links map[uuid.UUID]*model.ShareLink
+}
+
+// NewMemoryShareLinkRepository creates a new in-memory share link repository
+func NewMemoryShareLinkRepository() *MemoryShareLinkRepository {
+	return &MemoryShareLinkRepository{
+		links: make(map[uuid.UUID]*model.ShareLink),
+	}
+}
+
+// Create creates a new share link
+func (r *MemoryShareLinkRepository) Create(ctx context.Context, link *model.ShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.links {
+		if existing.ID == link.ID || existing.Slug == link.Slug {
+			return fmt.Errorf("failed to create share link: duplicate id or slug")
+		}
+	}
+
+	clone := *link
+	r.links[link.ID] = &clone
+	return nil
+}
+
+// GetBySlug retrieves a share link by slug
+func (r *MemoryShareLinkRepository) GetBySlug(ctx context.Context, slug string) (*model.ShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, link := range r.links {
+		if link.Slug == slug {
+			clone := *link
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByNoteID retrieves every share link of a note, newest first
+func (r *MemoryShareLinkRepository) GetByNoteID(ctx context.Context, noteID uuid.UUID) ([]model.ShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var links []model.ShareLink
+	for _, link := range r.links {
+		if link.NoteID == noteID {
+			links = append(links, *link)
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+	return links, nil
+}
+
+// Revoke revokes a share link of a note
+func (r *MemoryShareLinkRepository) Revoke(ctx context.Context, id, noteID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, exists := r.links[id]
+	if !exists || link.NoteID != noteID || link.RevokedAt != nil {
+		return fmt.Errorf("share link not found or already revoked")
+	}
+	now := time.Now()
+	link.RevokedAt = &now
+	return nil
+}
+
+// RecordView counts a view of an active share link
+func (r *MemoryShareLinkRepository) RecordView(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	link, exists := r.links[id]
+	if !exists || !link.Active(now) {
+		return false, nil
+	}
+	link.ViewCount++
+	link.LastViewedAt = &now
+	return true, nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// MemoryUserRepository keeps users in memory. Emails and handles are unique
+// and compared exactly, as with the UNIQUE constraints in PostgreSQL.
+type MemoryUserRepository struct {
+	mu        sync.RWMutex
+	users     map[uuid.UUID]*model.User
+	redirects map[string]uuid.UUID // Previous handle -> user
+}
+
+// NewMemoryUserRepository creates a new in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:     make(map[uuid.UUID]*model.User),
+		redirects: make(map[string]uuid.UUID),
+	}
+}
+
+// Create creates a new user
+func (r *MemoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.users[user.ID]; exists {
+		return fmt.Errorf("failed to create user: duplicate id %s", user.ID)
+	}
+	if r.findByEmail(user.Email) != nil {
+		return fmt.Errorf("failed to create user: email already exists")
+	}
+	if user.Handle != nil && r.findByHandle(*user.Handle) != nil {
+		return fmt.Errorf("failed to create user: handle already exists")
+	}
+
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+// GetByEmail retrieves a user by email
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, nil
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, nil
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// EmailExists checks if an email is already registered
+func (r *MemoryUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.findByEmail(email) != nil, nil
+}
+
+// EmailExistsExcludingUser checks if an email is registered to another user
+func (r *MemoryUserRepository) EmailExistsExcludingUser(ctx context.Context, email string, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	return user != nil && user.ID != userID, nil
+}
+
+// Update updates a user's profile
+func (r *MemoryUserRepository) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+	if other := r.findByEmail(user.Email); other != nil && other.ID != user.ID {
+		return fmt.Errorf("failed to update user: email already exists")
+	}
+	if user.Handle != nil {
+		if other := r.findByHandle(*user.Handle); other != nil && other.ID != user.ID {
+			return fmt.Errorf("failed to update user: handle already exists")
+		}
+	}
+
+	existing.Email = user.Email
+	existing.FullName = user.FullName
+	existing.FeedOptOut = user.FeedOptOut
+	existing.Handle = user.Handle
+	existing.Bio = user.Bio
+	existing.AvatarURL = user.AvatarURL
+	existing.ProfilePublic = user.ProfilePublic
+	existing.TrashRetentionDays = user.TrashRetentionDays
+	existing.UpdatedAt = user.UpdatedAt
+	return nil
+}
+
+// GetByIDs retrieves the users with the given IDs; missing users are skipped
+func (r *MemoryUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []model.User
+	for _, id := range ids {
+		if user, exists := r.users[id]; exists {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+// GetByHandle retrieves the user whose current or previous handle is handle
+func (r *MemoryUserRepository) GetByHandle(ctx context.Context, handle string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findByHandle(handle)
+	if user == nil {
+		id, exists := r.redirects[handle]
+		if !exists {
+			return nil, nil
+		}
+		if user = r.users[id]; user == nil {
+			return nil, nil
+		}
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// HandleTaken checks if a handle is used or was used by another user
+func (r *MemoryUserRepository) HandleTaken(ctx context.Context, handle string, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if user := r.findByHandle(handle); user != nil && user.ID != userID {
+		return true, nil
+	}
+	if id, exists := r.redirects[handle]; exists && id != userID {
+		return true, nil
+	}
+	return false, nil
+}
+
+// RecordHandleChange keeps oldHandle redirecting to the user and drops the
+// redirect of newHandle when the user takes back a previous handle
+func (r *MemoryUserRepository) RecordHandleChange(ctx context.Context, userID uuid.UUID, oldHandle, newHandle string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.redirects[newHandle] == userID {
+		delete(r.redirects, newHandle)
+	}
+	if oldHandle != "" {
+		r.redirects[oldHandle] = userID
+	}
+	return nil
+}
+
+// findByHandle returns the stored user with handle; callers hold the lock
+func (r *MemoryUserRepository) findByHandle(handle string) *model.User {
+	for _, user := range r.users {
+		if user.Handle != nil && *user.Handle == handle {
+			return user
+		}
+	}
+	return nil
+}
+
+// findByEmail returns the stored user with email; callers hold the lock
+func (r *MemoryUserRepository) findByEmail(email string) *model.User {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// viewStatKey identifies a row of the view rollup
+type viewStatKey struct {
+	noteID   uuid.UUID
+	day      time.Time
+	referrer string
+}
+
+// MemoryViewStatsRepository keeps the view rollup in memory. It reads notes
+// from the note repository to drop views of deleted notes and to find a
+// user's public notes.
+type MemoryViewStatsRepository struct {
+	notes *MemoryNoteRepository
+
+	mu    sync.Mutex
+	stats map[viewStatKey]*model.NoteViewStat
+}
+
+// NewMemoryViewStatsRepository creates a new in-memory view stats repository
+func NewMemoryViewStatsRepository(notes *MemoryNoteRepository) *MemoryViewStatsRepository {
+	return &MemoryViewStatsRepository{
+		notes: notes,
+		stats: make(map[viewStatKey]*model.NoteViewStat),
+	}
+}
+
+// Add adds the views of each row to the rollup
+func (r *MemoryViewStatsRepository) Add(ctx context.Context, stats []model.NoteViewStat) error {
+	for _, stat := range stats {
+		if note, _ := r.notes.GetByID(ctx, stat.NoteID); note == nil {
+			continue
+		}
+
+		r.mu.Lock()
+		key := viewStatKey{noteID: stat.NoteID, day: stat.Day.UTC().Truncate(24 * time.Hour), referrer: stat.Referrer}
+		row, ok := r.stats[key]
+		if !ok {
+			row = &model.NoteViewStat{NoteID: key.noteID, Day: key.day, Referrer: key.referrer}
+			r.stats[key] = row
+		}
+		row.Views += stat.Views
+		row.UniqueViewers += stat.UniqueViewers
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// GetByNote returns the rows of a note from the day since on
+func (r *MemoryViewStatsRepository) GetByNote(ctx context.Context, noteID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stats []model.NoteViewStat
+	for key, row := range r.stats {
+		if key.noteID == noteID && !key.day.Before(since) {
+			stats = append(stats, *row)
+		}
+	}
+	sortViewStatsByDay(stats)
+	return stats, nil
+}
+
+// GetByUser returns the rows of a user's public notes from the day since on, summed per day and referrer
+func (r *MemoryViewStatsRepository) GetByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	public := make(map[uuid.UUID]bool)
+	for _, note := range r.notes.filter(func(note *model.Note) bool {
+		return note.UserID == userID && note.IsPublic
+	}) {
+		public[note.ID] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sums := make(map[viewStatKey]*model.NoteViewStat)
+	for key, row := range r.stats {
+		if !public[key.noteID] || key.day.Before(since) {
+			continue
+		}
+		sumKey := viewStatKey{day: key.day, referrer: key.referrer}
+		sum, ok := sums[sumKey]
+		if !ok {
+			sum = &model.NoteViewStat{Day: key.day, Referrer: key.referrer}
+			sums[sumKey] = sum
+		}
+		sum.Views += row.Views
+		sum.UniqueViewers += row.UniqueViewers
+	}
+
+	stats := make([]model.NoteViewStat, 0, len(sums))
+	for _, sum := range sums {
+		stats = append(stats, *sum)
+	}
+	sortViewStatsByDay(stats)
+	return stats, nil
+}
+
+// sortViewStatsByDay orders rows by day and referrer like the SQL implementations
+func sortViewStatsByDay(stats []model.NoteViewStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Day.Equal(stats[j].Day) {
+			return stats[i].Day.Before(stats[j].Day)
+		}
+		return stats[i].Referrer < stats[j].Referrer
+	})
+}
@@ -1,30 +1,41 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 
+	"gonotes/internal/metrics"
 	"gonotes/internal/model"
 
 	"github.com/google/uuid"
 )
 
-// NoteRepository handles database operations for notes
-type NoteRepository struct {
-	db *sql.DB
+// PostgresNoteRepository handles PostgreSQL operations for notes. Public
+// listings, search and stats may be served by read replicas.
+type PostgresNoteRepository struct {
+	db      *sql.DB
+	router  *DBRouter
+	timeout time.Duration
 }
 
-// NewNoteRepository creates a new note repository
-func NewNoteRepository(db *sql.DB) *NoteRepository {
-	return &NoteRepository{
-		db: db,
+// NewPostgresNoteRepository creates a new PostgreSQL note repository
+func NewPostgresNoteRepository(router *DBRouter, queryTimeout time.Duration) *PostgresNoteRepository {
+	return &PostgresNoteRepository{
+		db:      router.Primary(),
+		router:  router,
+		timeout: queryTimeout,
 	}
 }
 
 // Create creates a new note
-func (r *NoteRepository) Create(note *model.Note) error {
+func (r *PostgresNoteRepository) Create(ctx context.Context, note *model.Note) error {
+	defer metrics.ObserveQuery("notes", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		INSERT INTO notes (id, user_id, title, content, status, tags, is_public, view_count, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
@@ -34,7 +45,7 @@ func (r *NoteRepository) Create(note *model.Note) error {
 	note.CreatedAt = now
 	note.UpdatedAt = now
 
-	_, err := r.db.Exec(query,
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
 		note.ID,
 		note.UserID,
 		note.Title,
@@ -51,20 +62,25 @@ func (r *NoteRepository) Create(note *model.Note) error {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
 
+	r.router.recordWrite(ctx, note.UserID)
 	return nil
 }
 
 // GetByID retrieves a note by ID
-func (r *NoteRepository) GetByID(id uuid.UUID) (*model.Note, error) {
+func (r *PostgresNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Note, error) {
+	defer metrics.ObserveQuery("notes", "GetByID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
-			   created_at, updated_at, deleted_at
+			   created_at, updated_at, deleted_at, publish_at, unpublish_at
 		FROM notes 
 		WHERE id = $1
 	`
 
 	var note model.Note
-	err := r.db.QueryRow(query, id).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&note.ID,
 		&note.UserID,
 		&note.Title,
@@ -76,6 +92,8 @@ func (r *NoteRepository) GetByID(id uuid.UUID) (*model.Note, error) {
 		&note.CreatedAt,
 		&note.UpdatedAt,
 		&note.DeletedAt,
+		&note.PublishAt,
+		&note.UnpublishAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -89,16 +107,20 @@ func (r *NoteRepository) GetByID(id uuid.UUID) (*model.Note, error) {
 }
 
 // GetByIDAndUserID retrieves a note by ID and user ID (for security)
-func (r *NoteRepository) GetByIDAndUserID(id, userID uuid.UUID) (*model.Note, error) {
+func (r *PostgresNoteRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*model.Note, error) {
+	defer metrics.ObserveQuery("notes", "GetByIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
-			   created_at, updated_at, deleted_at
+			   created_at, updated_at, deleted_at, publish_at, unpublish_at
 		FROM notes 
 		WHERE id = $1 AND user_id = $2
 	`
 
 	var note model.Note
-	err := r.db.QueryRow(query, id, userID).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id, userID).Scan(
 		&note.ID,
 		&note.UserID,
 		&note.Title,
@@ -110,6 +132,8 @@ func (r *NoteRepository) GetByIDAndUserID(id, userID uuid.UUID) (*model.Note, er
 		&note.CreatedAt,
 		&note.UpdatedAt,
 		&note.DeletedAt,
+		&note.PublishAt,
+		&note.UnpublishAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -123,17 +147,21 @@ func (r *NoteRepository) GetByIDAndUserID(id, userID uuid.UUID) (*model.Note, er
 }
 
 // Update updates an existing note
-func (r *NoteRepository) Update(note *model.Note) error {
+func (r *PostgresNoteRepository) Update(ctx context.Context, note *model.Note) error {
+	defer metrics.ObserveQuery("notes", "Update", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE notes 
 		SET title = $2, content = $3, status = $4, tags = $5, is_public = $6, 
-			updated_at = $7, deleted_at = $8
+			updated_at = $7, deleted_at = $8, publish_at = $10, unpublish_at = $11
 		WHERE id = $1 AND user_id = $9
 	`
 
 	note.UpdatedAt = time.Now()
 
-	result, err := r.db.Exec(query,
+	result, err := executor(ctx, r.db).ExecContext(ctx, query,
 		note.ID,
 		note.Title,
 		note.Content,
@@ -143,6 +171,8 @@ func (r *NoteRepository) Update(note *model.Note) error {
 		note.UpdatedAt,
 		note.DeletedAt,
 		note.UserID,
+		note.PublishAt,
+		note.UnpublishAt,
 	)
 
 	if err != nil {
@@ -158,18 +188,23 @@ func (r *NoteRepository) Update(note *model.Note) error {
 		return fmt.Errorf("note not found or no permission to update")
 	}
 
+	r.router.recordWrite(ctx, note.UserID)
 	return nil
 }
 
 // Delete soft deletes a note (sets status to deleted and deleted_at timestamp)
-func (r *NoteRepository) Delete(id, userID uuid.UUID) error {
+func (r *PostgresNoteRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "Delete", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE notes 
-		SET status = 'deleted', deleted_at = NOW(), updated_at = NOW()
+		SET status = 'deleted', deleted_at = NOW(), updated_at = NOW(), publish_at = NULL, unpublish_at = NULL
 		WHERE id = $1 AND user_id = $2 AND status != 'deleted'
 	`
 
-	result, err := r.db.Exec(query, id, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
@@ -183,18 +218,23 @@ func (r *NoteRepository) Delete(id, userID uuid.UUID) error {
 		return fmt.Errorf("note not found or already deleted")
 	}
 
+	r.router.recordWrite(ctx, userID)
 	return nil
 }
 
 // Restore restores a soft-deleted note
-func (r *NoteRepository) Restore(id, userID uuid.UUID) error {
+func (r *PostgresNoteRepository) Restore(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "Restore", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE notes 
-		SET status = 'active', deleted_at = NULL, updated_at = NOW()
+		SET status = 'active', deleted_at = NULL, updated_at = NOW(), publish_at = NULL, unpublish_at = NULL
 		WHERE id = $1 AND user_id = $2 AND status = 'deleted'
 	`
 
-	result, err := r.db.Exec(query, id, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to restore note: %w", err)
 	}
@@ -208,14 +248,19 @@ func (r *NoteRepository) Restore(id, userID uuid.UUID) error {
 		return fmt.Errorf("note not found or not deleted")
 	}
 
+	r.router.recordWrite(ctx, userID)
 	return nil
 }
 
 // HardDelete permanently deletes a note from database
-func (r *NoteRepository) HardDelete(id, userID uuid.UUID) error {
+func (r *PostgresNoteRepository) HardDelete(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "HardDelete", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `DELETE FROM notes WHERE id = $1 AND user_id = $2`
 
-	result, err := r.db.Exec(query, id, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to hard delete note: %w", err)
 	}
@@ -229,11 +274,88 @@ func (r *NoteRepository) HardDelete(id, userID uuid.UUID) error {
 		return fmt.Errorf("note not found")
 	}
 
+	r.router.recordWrite(ctx, userID)
 	return nil
 }
 
+// PurgeDeleted hard deletes up to limit notes that have been in the trash
+// longer than their owner's retention, or defaultDays when the owner has
+// none, and returns them with ID, UserID and DeletedAt set
+func (r *PostgresNoteRepository) PurgeDeleted(ctx context.Context, now time.Time, defaultDays, limit int) ([]model.Note, error) {
+	defer metrics.ObserveQuery("notes", "PurgeDeleted", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM notes
+		WHERE id IN (
+			SELECT n.id FROM notes n JOIN users u ON u.id = n.user_id
+			WHERE n.status = 'deleted' AND n.deleted_at < $1 - make_interval(days => COALESCE(u.trash_retention_days, $2::int))
+			ORDER BY n.deleted_at
+			LIMIT $3
+			FOR UPDATE OF n SKIP LOCKED
+		)
+		RETURNING id, user_id, deleted_at
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, now, defaultDays, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted notes: %w", err)
+	}
+	defer rows.Close()
+
+	var purged []model.Note
+	for rows.Next() {
+		var note model.Note
+		if err := rows.Scan(&note.ID, &note.UserID, &note.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan purged note: %w", err)
+		}
+		purged = append(purged, note)
+		r.router.recordWrite(ctx, note.UserID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate purged notes: %w", err)
+	}
+
+	return purged, nil
+}
+
+// EmptyTrash hard deletes every deleted note of a user and returns their IDs
+func (r *PostgresNoteRepository) EmptyTrash(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "EmptyTrash", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM notes WHERE user_id = $1 AND status = 'deleted' RETURNING id`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted note: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate deleted notes: %w", err)
+	}
+
+	r.router.recordWrite(ctx, userID)
+	return ids, nil
+}
+
 // GetByUserID retrieves notes by user ID with pagination and filtering
-func (r *NoteRepository) GetByUserID(userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error) {
+func (r *PostgresNoteRepository) GetByUserID(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "GetByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	// Set defaults
 	params.SetDefaults()
 
@@ -290,7 +412,7 @@ func (r *NoteRepository) GetByUserID(userID uuid.UUID, params *model.GetNotesPar
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
 	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	err := executor(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count notes: %w", err)
 	}
@@ -308,7 +430,7 @@ func (r *NoteRepository) GetByUserID(userID uuid.UUID, params *model.GetNotesPar
 
 	args = append(args, params.PageSize, offset)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query notes: %w", err)
 	}
@@ -344,7 +466,13 @@ func (r *NoteRepository) GetByUserID(userID uuid.UUID, params *model.GetNotesPar
 }
 
 // Search performs advanced search across notes
-func (r *NoteRepository) Search(userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error) {
+func (r *PostgresNoteRepository) Search(ctx context.Context, userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "Search", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.router.reader(ctx, userID)
+
 	// Set defaults
 	req.SetDefaults()
 
@@ -408,7 +536,7 @@ func (r *NoteRepository) Search(userID uuid.UUID, req *model.NoteSearchRequest)
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
 	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	err := db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
 	}
@@ -448,7 +576,7 @@ func (r *NoteRepository) Search(userID uuid.UUID, req *model.NoteSearchRequest)
 		args = append(args, req.PageSize, offset)
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search notes: %w", err)
 	}
@@ -485,20 +613,119 @@ func (r *NoteRepository) Search(userID uuid.UUID, req *model.NoteSearchRequest)
 	return notes, total, nil
 }
 
-// IncrementViewCount increments the view count for a note
-func (r *NoteRepository) IncrementViewCount(id uuid.UUID) error {
-	query := `UPDATE notes SET view_count = view_count + 1, updated_at = NOW() WHERE id = $1`
+// AddViewCounts adds views to several notes in statements of up to
+// maxBatchRows notes. Rows are updated in ID order so concurrent flushes do
+// not deadlock; callers batch the statements in a transaction.
+func (r *PostgresNoteRepository) AddViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	defer metrics.ObserveQuery("notes", "AddViewCounts", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ids := sortedIDs(counts)
+	return inBatches(len(ids), maxBatchRows, func(start, end int) error {
+		query, args := viewCountsUpdate(ids[start:end], counts)
+		if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to add view counts: %w", err)
+		}
+		return nil
+	})
+}
+
+// viewCountsUpdate builds the statement adding counts to the notes in ids
+func viewCountsUpdate(ids []uuid.UUID, counts map[uuid.UUID]int64) (string, []interface{}) {
+	values := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)*2)
+	for i, id := range ids {
+		values[i] = fmt.Sprintf("($%d::uuid, $%d::bigint)", i*2+1, i*2+2)
+		args = append(args, id, counts[id])
+	}
+
+	query := fmt.Sprintf(`
+		WITH v(id, views) AS (VALUES %s),
+		locked AS (
+			SELECT n.id FROM notes n JOIN v ON n.id = v.id
+			ORDER BY n.id
+			FOR UPDATE OF n
+		)
+		UPDATE notes SET view_count = notes.view_count + v.views
+		FROM v JOIN locked ON locked.id = v.id
+		WHERE notes.id = v.id
+	`, strings.Join(values, ", "))
+	return query, args
+}
+
+// PublishDue makes public the notes whose publish_at has passed, turning
+// drafts active, and returns their IDs
+func (r *PostgresNoteRepository) PublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "PublishDue", time.Now())
 
-	_, err := r.db.Exec(query, id)
+	query := `
+		UPDATE notes
+		SET is_public = TRUE, status = 'active', publish_at = NULL, updated_at = $1
+		WHERE publish_at <= $1 AND status IN ('active', 'draft')
+		RETURNING id, user_id
+	`
+	ids, err := r.updateDue(ctx, query, now)
 	if err != nil {
-		return fmt.Errorf("failed to increment view count: %w", err)
+		return nil, fmt.Errorf("failed to publish scheduled notes: %w", err)
 	}
+	return ids, nil
+}
 
-	return nil
+// UnpublishDue makes private the notes whose unpublish_at has passed and
+// returns their IDs
+func (r *PostgresNoteRepository) UnpublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "UnpublishDue", time.Now())
+
+	query := `
+		UPDATE notes
+		SET is_public = FALSE, unpublish_at = NULL, updated_at = $1
+		WHERE unpublish_at <= $1 AND status != 'deleted'
+		RETURNING id, user_id
+	`
+	ids, err := r.updateDue(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpublish scheduled notes: %w", err)
+	}
+	return ids, nil
+}
+
+// updateDue runs a scheduled update returning id and user_id, and pins the
+// owners of the changed notes to the primary
+func (r *PostgresNoteRepository) updateDue(ctx context.Context, query string, now interface{}) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id, userID uuid.UUID
+		if err := rows.Scan(&id, &userID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		r.router.recordWrite(ctx, userID)
+	}
+	return ids, rows.Err()
 }
 
 // GetPublicNotes retrieves public notes with pagination
-func (r *NoteRepository) GetPublicNotes(params *model.GetNotesParams) ([]model.Note, int64, error) {
+func (r *PostgresNoteRepository) GetPublicNotes(ctx context.Context, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "GetPublicNotes", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.router.reader(ctx, callerID(ctx))
+
 	// Set defaults
 	params.SetDefaults()
 
@@ -536,12 +763,19 @@ func (r *NoteRepository) GetPublicNotes(params *model.GetNotesParams) ([]model.N
 		}
 	}
 
+	// Author filter
+	if params.AuthorID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, *params.AuthorID)
+		argIndex++
+	}
+
 	whereClause := strings.Join(whereConditions, " AND ")
 
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
 	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	err := db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count public notes: %w", err)
 	}
@@ -559,7 +793,7 @@ func (r *NoteRepository) GetPublicNotes(params *model.GetNotesParams) ([]model.N
 
 	args = append(args, params.PageSize, offset)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query public notes: %w", err)
 	}
@@ -594,10 +828,15 @@ func (r *NoteRepository) GetPublicNotes(params *model.GetNotesParams) ([]model.N
 	return notes, total, nil
 }
 
-// BulkUpdateStatus updates status for multiple notes
-func (r *NoteRepository) BulkUpdateStatus(userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) error {
+// BulkUpdateStatus updates status for multiple notes and returns how many
+// were updated; IDs of other users' notes are skipped, so none updated is 0
+func (r *PostgresNoteRepository) BulkUpdateStatus(ctx context.Context, userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) (int64, error) {
+	defer metrics.ObserveQuery("notes", "BulkUpdateStatus", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	if len(noteIDs) == 0 {
-		return fmt.Errorf("no note IDs provided")
+		return 0, fmt.Errorf("no note IDs provided")
 	}
 
 	// Create placeholders for note IDs
@@ -611,29 +850,34 @@ func (r *NoteRepository) BulkUpdateStatus(userID uuid.UUID, noteIDs []uuid.UUID,
 
 	query := fmt.Sprintf(`
 		UPDATE notes 
-		SET status = $2, updated_at = NOW()
+		SET status = $2, updated_at = NOW(),
+			publish_at = CASE WHEN $2 = 'deleted' OR status = 'deleted' THEN NULL ELSE publish_at END,
+			unpublish_at = CASE WHEN $2 = 'deleted' OR status = 'deleted' THEN NULL ELSE unpublish_at END
 		WHERE user_id = $1 AND id IN (%s)
 	`, strings.Join(placeholders, ","))
 
-	result, err := r.db.Exec(query, args...)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to bulk update status: %w", err)
+		return 0, fmt.Errorf("failed to bulk update status: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("no notes updated")
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
 	}
 
-	return nil
+	r.router.recordWrite(ctx, userID)
+	return rowsAffected, nil
 }
 
 // GetNoteStats returns statistics for user's notes
-func (r *NoteRepository) GetNoteStats(userID uuid.UUID) (map[string]interface{}, error) {
+func (r *PostgresNoteRepository) GetNoteStats(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
+	defer metrics.ObserveQuery("notes", "GetNoteStats", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.router.reader(ctx, userID)
+
 	query := `
 		SELECT 
 			COUNT(*) as total,
@@ -647,7 +891,7 @@ func (r *NoteRepository) GetNoteStats(userID uuid.UUID) (map[string]interface{},
 	`
 
 	var total, active, drafts, deleted, public, totalViews int64
-	err := r.db.QueryRow(query, userID).Scan(&total, &active, &drafts, &deleted, &public, &totalViews)
+	err := db.QueryRowContext(ctx, query, userID).Scan(&total, &active, &drafts, &deleted, &public, &totalViews)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note stats: %w", err)
 	}
@@ -1,33 +1,40 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
+	"gonotes/internal/metrics"
 	"gonotes/internal/model"
 
 	"github.com/google/uuid"
 )
 
-// SessionRepository handles database operations for sessions
-type SessionRepository struct {
-	db *sql.DB
+// PostgresSessionRepository handles PostgreSQL operations for sessions
+type PostgresSessionRepository struct {
+	db      *sql.DB
+	timeout time.Duration
 }
 
-// NewSessionRepository creates a new session repository
-func NewSessionRepository(db *sql.DB) *SessionRepository {
-	return &SessionRepository{db: db}
+// NewPostgresSessionRepository creates a new PostgreSQL session repository
+func NewPostgresSessionRepository(db *sql.DB, queryTimeout time.Duration) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db, timeout: queryTimeout}
 }
 
 // Create creates a new session in the database
-func (r *SessionRepository) Create(session *model.Session) error {
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *model.Session) error {
+	defer metrics.ObserveQuery("sessions", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		INSERT INTO sessions (id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.Exec(
+	_, err := executor(ctx, r.db).ExecContext(ctx,
 		query,
 		session.ID,
 		session.UserID,
@@ -47,7 +54,11 @@ func (r *SessionRepository) Create(session *model.Session) error {
 }
 
 // GetByRefreshToken retrieves a session by refresh token
-func (r *SessionRepository) GetByRefreshToken(refreshToken string) (*model.Session, error) {
+func (r *PostgresSessionRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetByRefreshToken", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at
 		FROM sessions
@@ -56,7 +67,7 @@ func (r *SessionRepository) GetByRefreshToken(refreshToken string) (*model.Sessi
 
 	session := &model.Session{}
 
-	err := r.db.QueryRow(query, refreshToken).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, refreshToken).Scan(
 		&session.ID,
 		&session.UserID,
 		&session.RefreshToken,
@@ -78,7 +89,11 @@ func (r *SessionRepository) GetByRefreshToken(refreshToken string) (*model.Sessi
 }
 
 // GetByUserID retrieves all valid sessions for a user
-func (r *SessionRepository) GetByUserID(userID uuid.UUID) ([]model.Session, error) {
+func (r *PostgresSessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at
 		FROM sessions
@@ -86,7 +101,7 @@ func (r *SessionRepository) GetByUserID(userID uuid.UUID) ([]model.Session, erro
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions by user ID: %w", err)
 	}
@@ -115,14 +130,18 @@ func (r *SessionRepository) GetByUserID(userID uuid.UUID) ([]model.Session, erro
 }
 
 // InvalidateByRefreshToken marks a session as invalid by refresh token
-func (r *SessionRepository) InvalidateByRefreshToken(refreshToken string) error {
+func (r *PostgresSessionRepository) InvalidateByRefreshToken(ctx context.Context, refreshToken string) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateByRefreshToken", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE sessions
 		SET is_valid = false
 		WHERE refresh_token = $1
 	`
 
-	result, err := r.db.Exec(query, refreshToken)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, refreshToken)
 	if err != nil {
 		return fmt.Errorf("failed to invalidate session: %w", err)
 	}
@@ -140,14 +159,18 @@ func (r *SessionRepository) InvalidateByRefreshToken(refreshToken string) error
 }
 
 // InvalidateBySessionID marks a session as invalid by session ID
-func (r *SessionRepository) InvalidateBySessionID(sessionID uuid.UUID) error {
+func (r *PostgresSessionRepository) InvalidateBySessionID(ctx context.Context, sessionID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateBySessionID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE sessions
 		SET is_valid = false
 		WHERE id = $1
 	`
 
-	result, err := r.db.Exec(query, sessionID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to invalidate session: %w", err)
 	}
@@ -165,14 +188,18 @@ func (r *SessionRepository) InvalidateBySessionID(sessionID uuid.UUID) error {
 }
 
 // InvalidateAllByUserID marks all sessions as invalid for a user
-func (r *SessionRepository) InvalidateAllByUserID(userID uuid.UUID) error {
+func (r *PostgresSessionRepository) InvalidateAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateAllByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE sessions
 		SET is_valid = false
 		WHERE user_id = $1 AND is_valid = true
 	`
 
-	_, err := r.db.Exec(query, userID)
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to invalidate all sessions: %w", err)
 	}
@@ -181,7 +208,11 @@ func (r *SessionRepository) InvalidateAllByUserID(userID uuid.UUID) error {
 }
 
 // CleanupExpiredSessions removes expired sessions from database
-func (r *SessionRepository) CleanupExpiredSessions() error {
+func (r *PostgresSessionRepository) CleanupExpiredSessions(ctx context.Context) error {
+	defer metrics.ObserveQuery("sessions", "CleanupExpiredSessions", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		DELETE FROM sessions
 		WHERE expires_at < $1 OR (expires_at IS NULL AND created_at < $2)
@@ -190,7 +221,7 @@ func (r *SessionRepository) CleanupExpiredSessions() error {
 	// Remove sessions expired or older than 30 days if no expires_at
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 
-	_, err := r.db.Exec(query, time.Now(), thirtyDaysAgo)
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), thirtyDaysAgo)
 	if err != nil {
 		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
 	}
@@ -199,7 +230,11 @@ func (r *SessionRepository) CleanupExpiredSessions() error {
 }
 
 // GetUserSessions retrieves all active sessions for a user
-func (r *SessionRepository) GetUserSessions(userID uuid.UUID) ([]model.Session, error) {
+func (r *PostgresSessionRepository) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetUserSessions", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, refresh_token, user_agent, ip_address, 
 			   is_valid, created_at, expires_at
@@ -208,7 +243,7 @@ func (r *SessionRepository) GetUserSessions(userID uuid.UUID) ([]model.Session,
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query user sessions: %w", err)
 	}
@@ -241,7 +276,11 @@ func (r *SessionRepository) GetUserSessions(userID uuid.UUID) ([]model.Session,
 }
 
 // GetSessionByIDAndUserID retrieves a specific session by ID and user ID
-func (r *SessionRepository) GetSessionByIDAndUserID(sessionID, userID uuid.UUID) (*model.Session, error) {
+func (r *PostgresSessionRepository) GetSessionByIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) (*model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetSessionByIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, refresh_token, user_agent, ip_address, 
 			   is_valid, created_at, expires_at
@@ -250,7 +289,7 @@ func (r *SessionRepository) GetSessionByIDAndUserID(sessionID, userID uuid.UUID)
 	`
 
 	session := &model.Session{}
-	err := r.db.QueryRow(query, sessionID, userID).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, sessionID, userID).Scan(
 		&session.ID,
 		&session.UserID,
 		&session.RefreshToken,
@@ -272,14 +311,18 @@ func (r *SessionRepository) GetSessionByIDAndUserID(sessionID, userID uuid.UUID)
 }
 
 // InvalidateBySessionIDAndUserID invalidates a specific session for a user
-func (r *SessionRepository) InvalidateBySessionIDAndUserID(sessionID, userID uuid.UUID) error {
+func (r *PostgresSessionRepository) InvalidateBySessionIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateBySessionIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE sessions
 		SET is_valid = false
 		WHERE id = $1 AND user_id = $2 AND is_valid = true
 	`
 
-	result, err := r.db.Exec(query, sessionID, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to invalidate session: %w", err)
 	}
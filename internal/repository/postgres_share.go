@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// PostgresShareLinkRepository handles PostgreSQL operations for share links
+type PostgresShareLinkRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewPostgresShareLinkRepository creates a new PostgreSQL share link repository
+func NewPostgresShareLinkRepository(db *sql.DB, queryTimeout time.Duration) *PostgresShareLinkRepository {
+	return &PostgresShareLinkRepository{db: db, timeout: queryTimeout}
+}
+
+// shareLinkColumns lists the columns scanned by scanShareLink
+const shareLinkColumns = `id, note_id, user_id, slug, password_hash, expires_at, max_views, view_count, last_viewed_at, revoked_at, created_at`
+
+// Create creates a new share link in the database
+func (r *PostgresShareLinkRepository) Create(ctx context.Context, link *model.ShareLink) error {
+	defer metrics.ObserveQuery("share_links", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO share_links (id, note_id, user_id, slug, password_hash, expires_at, max_views, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		link.ID, link.NoteID, link.UserID, link.Slug, link.PasswordHash, link.ExpiresAt, link.MaxViews, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return nil
+}
+
+// GetBySlug retrieves a share link by slug
+func (r *PostgresShareLinkRepository) GetBySlug(ctx context.Context, slug string) (*model.ShareLink, error) {
+	defer metrics.ObserveQuery("share_links", "GetBySlug", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links WHERE slug = $1`
+
+	link, err := scanShareLink(executor(ctx, r.db).QueryRowContext(ctx, query, slug))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Share link not found
+		}
+		return nil, fmt.Errorf("failed to get share link by slug: %w", err)
+	}
+
+	return link, nil
+}
+
+// GetByNoteID retrieves every share link of a note, newest first
+func (r *PostgresShareLinkRepository) GetByNoteID(ctx context.Context, noteID uuid.UUID) ([]model.ShareLink, error) {
+	defer metrics.ObserveQuery("share_links", "GetByNoteID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links WHERE note_id = $1 ORDER BY created_at DESC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []model.ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+// Revoke revokes a share link of a note
+func (r *PostgresShareLinkRepository) Revoke(ctx context.Context, id, noteID uuid.UUID) error {
+	defer metrics.ObserveQuery("share_links", "Revoke", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE share_links
+		SET revoked_at = NOW()
+		WHERE id = $1 AND note_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("share link not found or already revoked")
+	}
+
+	return nil
+}
+
+// RecordView counts a view of an active share link. The checks run in the
+// update so concurrent views cannot exceed the view limit.
+func (r *PostgresShareLinkRepository) RecordView(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("share_links", "RecordView", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE share_links
+		SET view_count = view_count + 1, last_viewed_at = NOW()
+		WHERE id = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND (max_views IS NULL OR view_count < max_views)
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to record share link view: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanShareLink reads a row of shareLinkColumns
+func scanShareLink(row rowScanner) (*model.ShareLink, error) {
+	link := &model.ShareLink{}
+	err := row.Scan(
+		&link.ID,
+		&link.NoteID,
+		&link.UserID,
+		&link.Slug,
+		&link.PasswordHash,
+		&link.ExpiresAt,
+		&link.MaxViews,
+		&link.ViewCount,
+		&link.LastViewedAt,
+		&link.RevokedAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// PostgresViewStatsRepository handles PostgreSQL operations for note view stats
+type PostgresViewStatsRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewPostgresViewStatsRepository creates a new PostgreSQL view stats repository
+func NewPostgresViewStatsRepository(db *sql.DB, queryTimeout time.Duration) *PostgresViewStatsRepository {
+	return &PostgresViewStatsRepository{db: db, timeout: queryTimeout}
+}
+
+// Add upserts the rows in statements of up to maxBatchRows rows, in key order
+// so concurrent flushes do not deadlock. Callers batch the statements in a
+// transaction.
+func (r *PostgresViewStatsRepository) Add(ctx context.Context, stats []model.NoteViewStat) error {
+	defer metrics.ObserveQuery("note_view_stats", "Add", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if len(stats) == 0 {
+		return nil
+	}
+
+	sorted := sortedViewStats(stats)
+	return inBatches(len(sorted), maxBatchRows, func(start, end int) error {
+		query, args := viewStatsUpsert(sorted[start:end])
+		if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to add view stats: %w", err)
+		}
+		return nil
+	})
+}
+
+// viewStatsUpsert builds the statement adding stats to the rollup
+func viewStatsUpsert(stats []model.NoteViewStat) (string, []interface{}) {
+	values := make([]string, len(stats))
+	args := make([]interface{}, 0, len(stats)*5)
+	for i, stat := range stats {
+		n := i * 5
+		values[i] = fmt.Sprintf("($%d::uuid, $%d::date, $%d, $%d::bigint, $%d::bigint)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, stat.NoteID, stat.Day.Format("2006-01-02"), stat.Referrer, stat.Views, stat.UniqueViewers)
+	}
+
+	// Joining notes drops views of notes deleted before the flush
+	query := fmt.Sprintf(`
+		INSERT INTO note_view_stats (note_id, day, referrer, views, unique_viewers)
+		SELECT v.note_id, v.day, v.referrer, v.views, v.unique_viewers
+		FROM (VALUES %s) AS v(note_id, day, referrer, views, unique_viewers)
+		JOIN notes n ON n.id = v.note_id
+		ON CONFLICT (note_id, day, referrer) DO UPDATE SET
+			views = note_view_stats.views + EXCLUDED.views,
+			unique_viewers = note_view_stats.unique_viewers + EXCLUDED.unique_viewers
+	`, strings.Join(values, ", "))
+	return query, args
+}
+
+// GetByNote returns the rows of a note from the day since on
+func (r *PostgresViewStatsRepository) GetByNote(ctx context.Context, noteID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	defer metrics.ObserveQuery("note_view_stats", "GetByNote", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT note_id, day, referrer, views, unique_viewers
+		FROM note_view_stats
+		WHERE note_id = $1 AND day >= $2::date
+		ORDER BY day, referrer
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, noteID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note view stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []model.NoteViewStat
+	for rows.Next() {
+		var stat model.NoteViewStat
+		if err := rows.Scan(&stat.NoteID, &stat.Day, &stat.Referrer, &stat.Views, &stat.UniqueViewers); err != nil {
+			return nil, fmt.Errorf("failed to scan note view stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetByUser returns the rows of a user's public notes from the day since on, summed per day and referrer
+func (r *PostgresViewStatsRepository) GetByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	defer metrics.ObserveQuery("note_view_stats", "GetByUser", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT s.day, s.referrer, SUM(s.views), SUM(s.unique_viewers)
+		FROM note_view_stats s
+		JOIN notes n ON n.id = s.note_id
+		WHERE n.user_id = $1 AND n.is_public = true AND s.day >= $2::date
+		GROUP BY s.day, s.referrer
+		ORDER BY s.day, s.referrer
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user view stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []model.NoteViewStat
+	for rows.Next() {
+		var stat model.NoteViewStat
+		if err := rows.Scan(&stat.Day, &stat.Referrer, &stat.Views, &stat.UniqueViewers); err != nil {
+			return nil, fmt.Errorf("failed to scan user view stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// sortedViewStats returns a copy of stats ordered by primary key
+func sortedViewStats(stats []model.NoteViewStat) []model.NoteViewStat {
+	sorted := append([]model.NoteViewStat(nil), stats...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.NoteID != b.NoteID {
+			return strings.Compare(a.NoteID.String(), b.NoteID.String()) < 0
+		}
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		return a.Referrer < b.Referrer
+	})
+	return sorted
+}
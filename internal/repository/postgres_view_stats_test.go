@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// postgresMaxParams is the bind parameter limit of a PostgreSQL statement
+const postgresMaxParams = 65535
+
+func TestPostgresViewFlush_LargeBatch(t *testing.T) {
+	// More keys than fit in one statement, as after an outage
+	const n = 20000
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	stats := make([]model.NoteViewStat, n)
+	counts := make(map[uuid.UUID]int64, n)
+	for i := range stats {
+		id := uuid.New()
+		stats[i] = model.NoteViewStat{NoteID: id, Day: day, Referrer: "example.com", Views: 1, UniqueViewers: 1}
+		counts[id] = 1
+	}
+
+	rows, statements := 0, 0
+	sorted := sortedViewStats(stats)
+	err := inBatches(len(sorted), maxBatchRows, func(start, end int) error {
+		query, args := viewStatsUpsert(sorted[start:end])
+		if len(args) > postgresMaxParams {
+			t.Errorf("view stats statement binds %d parameters, over %d", len(args), postgresMaxParams)
+		}
+		if got := strings.Count(query, "::uuid"); got != end-start {
+			t.Errorf("view stats statement has %d rows, want %d", got, end-start)
+		}
+		rows += end - start
+		statements++
+		return nil
+	})
+	if err != nil || rows != n || statements != (n+maxBatchRows-1)/maxBatchRows {
+		t.Errorf("view stats batches = %d rows in %d statements, %v", rows, statements, err)
+	}
+
+	rows = 0
+	ids := sortedIDs(counts)
+	err = inBatches(len(ids), maxBatchRows, func(start, end int) error {
+		_, args := viewCountsUpdate(ids[start:end], counts)
+		if len(args) > postgresMaxParams {
+			t.Errorf("view counts statement binds %d parameters, over %d", len(args), postgresMaxParams)
+		}
+		rows += end - start
+		return nil
+	})
+	if err != nil || rows != n {
+		t.Errorf("view counts batches = %d rows, %v, want %d", rows, err, n)
+	}
+}
@@ -0,0 +1,184 @@
+// Package repository implements data access for users, sessions and notes.
+//
+// Services depend on the interfaces below. PostgreSQL is the production
+// backend, SQLite serves single-instance deployments, and the in-memory
+// implementations back unit tests and demo mode.
+package repository
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// UserRepository stores user accounts. Lookups return nil, nil when the
+// user does not exist.
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	EmailExistsExcludingUser(ctx context.Context, email string, userID uuid.UUID) (bool, error)
+	Update(ctx context.Context, user *model.User) error
+	// GetByIDs returns the users that exist among ids, in no particular order
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error)
+	// GetByHandle resolves a current or previous handle
+	GetByHandle(ctx context.Context, handle string) (*model.User, error)
+	// HandleTaken reports whether another user has or had the handle
+	HandleTaken(ctx context.Context, handle string, userID uuid.UUID) (bool, error)
+	RecordHandleChange(ctx context.Context, userID uuid.UUID, oldHandle, newHandle string) error
+}
+
+// SessionRepository stores refresh token sessions. Only valid sessions are
+// returned by lookups.
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Session, error)
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error)
+	GetSessionByIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) (*model.Session, error)
+	InvalidateByRefreshToken(ctx context.Context, refreshToken string) error
+	InvalidateBySessionID(ctx context.Context, sessionID uuid.UUID) error
+	InvalidateBySessionIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) error
+	InvalidateAllByUserID(ctx context.Context, userID uuid.UUID) error
+	CleanupExpiredSessions(ctx context.Context) error
+}
+
+// NoteRepository stores notes. Lookups return nil, nil when the note does
+// not exist; Delete is a soft delete and HardDelete removes the row.
+type NoteRepository interface {
+	Create(ctx context.Context, note *model.Note) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Note, error)
+	GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*model.Note, error)
+	Update(ctx context.Context, note *model.Note) error
+	// Delete, Restore and BulkUpdateStatus drop the publishing schedule of
+	// notes they delete or restore, so a restored note is not published for a
+	// publish_at that passed while it was deleted
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	Restore(ctx context.Context, id, userID uuid.UUID) error
+	HardDelete(ctx context.Context, id, userID uuid.UUID) error
+	// PurgeDeleted hard deletes up to limit notes deleted longer ago than the
+	// trash retention of their owner, or defaultDays, oldest first
+	PurgeDeleted(ctx context.Context, now time.Time, defaultDays, limit int) ([]model.Note, error)
+	// EmptyTrash hard deletes all deleted notes of a user
+	EmptyTrash(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error)
+	Search(ctx context.Context, userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error)
+	// AddViewCounts adds views per note without touching updated_at
+	AddViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error
+	GetPublicNotes(ctx context.Context, params *model.GetNotesParams) ([]model.Note, int64, error)
+	// PublishDue and UnpublishDue apply the publish_at and unpublish_at times
+	// that have passed by now, clear them and return the changed notes
+	PublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	UnpublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	BulkUpdateStatus(ctx context.Context, userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) (int64, error)
+	GetNoteStats(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error)
+}
+
+// OutboxRepository stores side effects to deliver after commit. Add takes
+// part in the unit of work of ctx, so a message exists only if the change
+// that produced it was committed.
+type OutboxRepository interface {
+	Add(ctx context.Context, msg *model.OutboxMessage) error
+	// ClaimDue leases up to limit pending messages that are due by moving
+	// their next attempt to leaseUntil, so concurrent relays skip them
+	ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.OutboxMessage, error)
+	MarkProcessed(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, errMsg string, retryAt time.Time) error
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ShareLinkRepository stores share links of notes. Lookups return nil, nil
+// when the link does not exist.
+type ShareLinkRepository interface {
+	Create(ctx context.Context, link *model.ShareLink) error
+	GetBySlug(ctx context.Context, slug string) (*model.ShareLink, error)
+	// GetByNoteID returns every link of a note, revoked ones included, newest first
+	GetByNoteID(ctx context.Context, noteID uuid.UUID) ([]model.ShareLink, error)
+	Revoke(ctx context.Context, id, noteID uuid.UUID) error
+	// RecordView counts a view of the link and reports whether it was still
+	// active; views past the limit, expiry or revocation are not counted
+	RecordView(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// ViewStatsRepository stores the daily view rollup of notes. Rows for
+// notes that no longer exist are dropped when added.
+type ViewStatsRepository interface {
+	// Add adds the views of each row to the rollup
+	Add(ctx context.Context, stats []model.NoteViewStat) error
+	// GetByNote returns the rows of a note from the day since on
+	GetByNote(ctx context.Context, noteID uuid.UUID, since time.Time) ([]model.NoteViewStat, error)
+	// GetByUser returns the rows of a user's public notes from the day since
+	// on, summed per day and referrer
+	GetByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]model.NoteViewStat, error)
+}
+
+// Compile-time checks that the implementations satisfy the interfaces
+var (
+	_ UserRepository      = (*PostgresUserRepository)(nil)
+	_ SessionRepository   = (*PostgresSessionRepository)(nil)
+	_ NoteRepository      = (*PostgresNoteRepository)(nil)
+	_ UserRepository      = (*SQLiteUserRepository)(nil)
+	_ SessionRepository   = (*SQLiteSessionRepository)(nil)
+	_ NoteRepository      = (*SQLiteNoteRepository)(nil)
+	_ UserRepository      = (*MemoryUserRepository)(nil)
+	_ SessionRepository   = (*MemorySessionRepository)(nil)
+	_ NoteRepository      = (*MemoryNoteRepository)(nil)
+	_ OutboxRepository    = (*PostgresOutboxRepository)(nil)
+	_ OutboxRepository    = (*SQLiteOutboxRepository)(nil)
+	_ OutboxRepository    = (*MemoryOutboxRepository)(nil)
+	_ ShareLinkRepository = (*PostgresShareLinkRepository)(nil)
+	_ ShareLinkRepository = (*SQLiteShareLinkRepository)(nil)
+	_ ShareLinkRepository = (*MemoryShareLinkRepository)(nil)
+	_ ViewStatsRepository = (*PostgresViewStatsRepository)(nil)
+	_ ViewStatsRepository = (*SQLiteViewStatsRepository)(nil)
+	_ ViewStatsRepository = (*MemoryViewStatsRepository)(nil)
+	_ TxManager           = (*SQLTxManager)(nil)
+	_ TxManager           = (*MemoryTxManager)(nil)
+)
+
+// withTimeout bounds a single repository call by the configured query timeout.
+// A shorter deadline or cancellation from the caller still applies.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// sortedIDs returns the keys of counts in a stable order, so concurrent
+// batch updates lock rows in the same order
+func sortedIDs(counts map[uuid.UUID]int64) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
+// maxBatchRows bounds the rows of one multi-row statement, keeping the bind
+// parameters well below the 65535 PostgreSQL accepts per statement
+const maxBatchRows = 1000
+
+// inBatches calls fn for consecutive ranges [start, end) of n items, at most
+// size at a time, and stops at the first error
+func inBatches(n, size int, fn func(start, end int) error) error {
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		if err := fn(start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"gonotes/internal/logger"
+	"gonotes/internal/metrics"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// replicaCheckTimeout bounds the health check of a single replica
+const replicaCheckTimeout = 2 * time.Second
+
+// replicaLagQuery returns how far a PostgreSQL standby is behind, in
+// seconds. A standby that has replayed everything it received is not
+// lagging, however old its last transaction is.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END
+`
+
+// DBRouter routes read-only queries that tolerate replication lag to read
+// replicas, round-robin over the replicas that passed their last health
+// check. Writes, transactions and reads by a user who wrote within the
+// read-your-writes window go to the primary. Without replicas every query
+// goes to the primary.
+type DBRouter struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+
+	// pins marks users who wrote recently; kept in the shared store so the
+	// window holds across instances
+	pins   store.Store
+	window time.Duration
+	maxLag time.Duration
+}
+
+// replica is a read replica and the result of its last health check
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// NewDBRouter creates a router over primary and replicas. Replicas are
+// skipped until the first health check passes and when they lag more than
+// maxLag (0 disables the lag check).
+func NewDBRouter(primary *sql.DB, replicas []*sql.DB, pins store.Store, window, maxLag time.Duration) *DBRouter {
+	router := &DBRouter{
+		primary: primary,
+		pins:    pins,
+		window:  window,
+		maxLag:  maxLag,
+	}
+	for i, db := range replicas {
+		name := fmt.Sprintf("replica%d", i)
+		router.replicas = append(router.replicas, &replica{name: name, db: db})
+		metrics.DBReplicaUp.WithLabelValues(name).Set(0)
+	}
+	return router
+}
+
+// Primary returns the primary database
+func (r *DBRouter) Primary() *sql.DB {
+	return r.primary
+}
+
+// reader returns where a read-only query for userID should run: the
+// transaction in ctx, the primary while userID is pinned, or the next healthy
+// replica. Pass callerID(ctx) for reads not tied to a user.
+func (r *DBRouter) reader(ctx context.Context, userID uuid.UUID) dbtx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && state.tx != nil {
+		return state.tx
+	}
+	if len(r.replicas) == 0 {
+		return r.primary
+	}
+
+	if userID != uuid.Nil && r.pinned(ctx, userID) {
+		metrics.DBReads.WithLabelValues("primary").Inc()
+		return r.primary
+	}
+
+	// Round-robin, skipping replicas that failed their last check
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if replica.healthy.Load() {
+			metrics.DBReads.WithLabelValues("replica").Inc()
+			return replica.db
+		}
+	}
+
+	metrics.DBReads.WithLabelValues("primary").Inc()
+	return r.primary
+}
+
+// recordWrite pins userID to the primary for the read-your-writes window,
+// starting when the unit of work in ctx commits
+func (r *DBRouter) recordWrite(ctx context.Context, userID uuid.UUID) {
+	if len(r.replicas) == 0 || r.window <= 0 {
+		return
+	}
+
+	AfterCommit(ctx, func(ctx context.Context) {
+		if err := r.pins.Set(ctx, pinKey(userID), "1", r.window); err != nil {
+			slog.WarnContext(ctx, "Failed to pin reads to primary", "user_id", userID, "error", err)
+		}
+	})
+}
+
+// pinned reports whether userID wrote within the window. When the store
+// cannot tell, reads stay on the primary.
+func (r *DBRouter) pinned(ctx context.Context, userID uuid.UUID) bool {
+	if r.window <= 0 {
+		return false
+	}
+	_, ok, err := r.pins.Get(ctx, pinKey(userID))
+	return ok || err != nil
+}
+
+// pinKey is the store key marking a recent write by userID
+func pinKey(userID uuid.UUID) string {
+	return fmt.Sprintf("read_primary:%s", userID)
+}
+
+// callerID returns the authenticated user of the request in ctx, or uuid.Nil
+// for anonymous requests, so that shared listings read the caller's own writes
+func callerID(ctx context.Context) uuid.UUID {
+	userID, err := uuid.Parse(logger.UserID(ctx))
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
+
+// Run checks the replicas right away and then every interval until ctx is cancelled
+func (r *DBRouter) Run(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckReplicas(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckReplicas pings each replica and measures its replication lag,
+// taking replicas that fail out of rotation until they recover
+func (r *DBRouter) CheckReplicas(ctx context.Context) {
+	for _, replica := range r.replicas {
+		err := r.checkReplica(ctx, replica.db)
+		healthy := err == nil
+
+		if was := replica.healthy.Swap(healthy); was != healthy {
+			if healthy {
+				slog.InfoContext(ctx, "Read replica in rotation", "replica", replica.name)
+			} else {
+				slog.WarnContext(ctx, "Read replica taken out of rotation", "replica", replica.name, "error", err)
+			}
+		}
+
+		up := 0.0
+		if healthy {
+			up = 1
+		}
+		metrics.DBReplicaUp.WithLabelValues(replica.name).Set(up)
+	}
+}
+
+// checkReplica returns an error when db is unreachable or lags more than maxLag
+func (r *DBRouter) checkReplica(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	var lag float64
+	if err := db.QueryRowContext(ctx, replicaLagQuery).Scan(&lag); err != nil {
+		return fmt.Errorf("failed to check replication lag: %w", err)
+	}
+
+	if r.maxLag > 0 && time.Duration(lag*float64(time.Second)) > r.maxLag {
+		return fmt.Errorf("replication lag %.1fs exceeds %s", lag, r.maxLag)
+	}
+	return nil
+}
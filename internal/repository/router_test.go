@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"gonotes/internal/logger"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func TestDBRouter_Reader(t *testing.T) {
+	ctx := context.Background()
+	open := func() *sql.DB {
+		db, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	primary, replica0, replica1 := open(), open(), open()
+	router := NewDBRouter(primary, []*sql.DB{replica0, replica1}, store.NewMemoryStore(), time.Minute, 0)
+	userID := uuid.New()
+
+	// Replicas are skipped until a health check passes
+	if got := router.reader(ctx, userID); got != primary {
+		t.Error("reader() before health check should use the primary")
+	}
+
+	for _, replica := range router.replicas {
+		replica.healthy.Store(true)
+	}
+	seen := map[dbtx]bool{}
+	for i := 0; i < 4; i++ {
+		seen[router.reader(ctx, userID)] = true
+	}
+	if !seen[replica0] || !seen[replica1] || seen[primary] {
+		t.Error("reader() should round-robin over healthy replicas")
+	}
+
+	router.replicas[0].healthy.Store(false)
+	for i := 0; i < 4; i++ {
+		if got := router.reader(ctx, userID); got != replica1 {
+			t.Fatal("reader() should skip unhealthy replicas")
+		}
+	}
+
+	// A write pins the user, but not others, to the primary
+	router.recordWrite(ctx, userID)
+	if got := router.reader(ctx, userID); got != primary {
+		t.Error("reader() after a write should use the primary")
+	}
+	if got := router.reader(ctx, uuid.New()); got != replica1 {
+		t.Error("reader() for another user should use a replica")
+	}
+	if got := router.reader(ctx, uuid.Nil); got != replica1 {
+		t.Error("reader() for anonymous reads should use a replica")
+	}
+
+	// Reads not tied to a user follow the authenticated caller in ctx
+	callerCtx := logger.NewContext(ctx, "req", "127.0.0.1")
+	logger.SetUserID(callerCtx, userID.String())
+	if got := router.reader(callerCtx, callerID(callerCtx)); got != primary {
+		t.Error("reader() for the caller who wrote should use the primary")
+	}
+	if callerID(ctx) != uuid.Nil {
+		t.Error("callerID() without an authenticated user should be uuid.Nil")
+	}
+}
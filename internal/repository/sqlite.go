@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// utc normalizes timestamps written to SQLite. They are stored as text, which
+// only sorts and compares correctly when every value has the same offset.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// utcPtr is utc for nullable timestamps
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// ftsQuery turns user input into an FTS5 query matching notes whose title or
+// content contains all terms, like plainto_tsquery on either column does in
+// PostgreSQL. Terms are quoted so FTS5 operators in the input are literal.
+// It returns "" when the input has no searchable terms.
+func ftsQuery(input string) string {
+	var terms []string
+	for _, term := range strings.Fields(input) {
+		term = strings.ReplaceAll(term, `"`, "")
+		if strings.IndexFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, `"`+term+`"`)
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+
+	all := "(" + strings.Join(terms, " ") + ")"
+	return "title : " + all + " OR content : " + all
+}
@@ -0,0 +1,874 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteNoteRepository handles SQLite operations for notes
+type SQLiteNoteRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSQLiteNoteRepository creates a new SQLite note repository
+func NewSQLiteNoteRepository(db *sql.DB, queryTimeout time.Duration) *SQLiteNoteRepository {
+	return &SQLiteNoteRepository{
+		db:      db,
+		timeout: queryTimeout,
+	}
+}
+
+// Create creates a new note
+func (r *SQLiteNoteRepository) Create(ctx context.Context, note *model.Note) error {
+	defer metrics.ObserveQuery("notes", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO notes (id, user_id, title, content, status, tags, is_public, view_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	now := time.Now()
+	note.CreatedAt = now
+	note.UpdatedAt = now
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		note.ID,
+		note.UserID,
+		note.Title,
+		note.Content,
+		note.Status,
+		note.Tags,
+		note.IsPublic,
+		note.ViewCount,
+		utc(note.CreatedAt),
+		utc(note.UpdatedAt),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a note by ID
+func (r *SQLiteNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Note, error) {
+	defer metrics.ObserveQuery("notes", "GetByID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
+			   created_at, updated_at, deleted_at, publish_at, unpublish_at
+		FROM notes 
+		WHERE id = $1
+	`
+
+	var note model.Note
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&note.ID,
+		&note.UserID,
+		&note.Title,
+		&note.Content,
+		&note.Status,
+		&note.Tags,
+		&note.IsPublic,
+		&note.ViewCount,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+		&note.DeletedAt,
+		&note.PublishAt,
+		&note.UnpublishAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note by ID: %w", err)
+	}
+
+	return &note, nil
+}
+
+// GetByIDAndUserID retrieves a note by ID and user ID (for security)
+func (r *SQLiteNoteRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*model.Note, error) {
+	defer metrics.ObserveQuery("notes", "GetByIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
+			   created_at, updated_at, deleted_at, publish_at, unpublish_at
+		FROM notes 
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var note model.Note
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id, userID).Scan(
+		&note.ID,
+		&note.UserID,
+		&note.Title,
+		&note.Content,
+		&note.Status,
+		&note.Tags,
+		&note.IsPublic,
+		&note.ViewCount,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+		&note.DeletedAt,
+		&note.PublishAt,
+		&note.UnpublishAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note by ID and user ID: %w", err)
+	}
+
+	return &note, nil
+}
+
+// Update updates an existing note
+func (r *SQLiteNoteRepository) Update(ctx context.Context, note *model.Note) error {
+	defer metrics.ObserveQuery("notes", "Update", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE notes 
+		SET title = $2, content = $3, status = $4, tags = $5, is_public = $6, 
+			updated_at = $7, deleted_at = $8, publish_at = $10, unpublish_at = $11
+		WHERE id = $1 AND user_id = $9
+	`
+
+	note.UpdatedAt = time.Now()
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query,
+		note.ID,
+		note.Title,
+		note.Content,
+		note.Status,
+		note.Tags,
+		note.IsPublic,
+		utc(note.UpdatedAt),
+		utcPtr(note.DeletedAt),
+		note.UserID,
+		utcPtr(note.PublishAt),
+		utcPtr(note.UnpublishAt),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found or no permission to update")
+	}
+
+	return nil
+}
+
+// Delete soft deletes a note (sets status to deleted and deleted_at timestamp)
+func (r *SQLiteNoteRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "Delete", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE notes 
+		SET status = 'deleted', deleted_at = $3, updated_at = $3, publish_at = NULL, unpublish_at = NULL
+		WHERE id = $1 AND user_id = $2 AND status != 'deleted'
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found or already deleted")
+	}
+
+	return nil
+}
+
+// Restore restores a soft-deleted note
+func (r *SQLiteNoteRepository) Restore(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "Restore", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE notes 
+		SET status = 'active', deleted_at = NULL, updated_at = $3, publish_at = NULL, unpublish_at = NULL
+		WHERE id = $1 AND user_id = $2 AND status = 'deleted'
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found or not deleted")
+	}
+
+	return nil
+}
+
+// HardDelete permanently deletes a note from database
+func (r *SQLiteNoteRepository) HardDelete(ctx context.Context, id, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("notes", "HardDelete", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM notes WHERE id = $1 AND user_id = $2`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to hard delete note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found")
+	}
+
+	return nil
+}
+
+// PurgeDeleted hard deletes up to limit notes that have been in the trash
+// longer than their owner's retention, or defaultDays when the owner has
+// none, and returns them with ID, UserID and DeletedAt set
+func (r *SQLiteNoteRepository) PurgeDeleted(ctx context.Context, now time.Time, defaultDays, limit int) ([]model.Note, error) {
+	defer metrics.ObserveQuery("notes", "PurgeDeleted", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM notes
+		WHERE id IN (
+			SELECT n.id FROM notes n JOIN users u ON u.id = n.user_id
+			WHERE n.status = 'deleted' AND julianday(n.deleted_at) < julianday($1) - COALESCE(u.trash_retention_days, $2)
+			ORDER BY n.deleted_at
+			LIMIT $3
+		)
+		RETURNING id, user_id, deleted_at
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, utc(now), defaultDays, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted notes: %w", err)
+	}
+	defer rows.Close()
+
+	var purged []model.Note
+	for rows.Next() {
+		var note model.Note
+		if err := rows.Scan(&note.ID, &note.UserID, &note.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan purged note: %w", err)
+		}
+		purged = append(purged, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate purged notes: %w", err)
+	}
+
+	return purged, nil
+}
+
+// EmptyTrash hard deletes every deleted note of a user and returns their IDs
+func (r *SQLiteNoteRepository) EmptyTrash(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "EmptyTrash", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM notes WHERE user_id = $1 AND status = 'deleted' RETURNING id`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted note: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate deleted notes: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetByUserID retrieves notes by user ID with pagination and filtering
+func (r *SQLiteNoteRepository) GetByUserID(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "GetByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// Set defaults
+	params.SetDefaults()
+
+	// Build WHERE clause
+	whereConditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	argIndex := 2
+
+	// Status filter
+	if params.Status != "all" {
+		whereConditions = append(whereConditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, params.Status)
+		argIndex++
+	}
+
+	// Public filter
+	if params.IsPublic != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("is_public = $%d", argIndex))
+		args = append(args, *params.IsPublic)
+		argIndex++
+	}
+
+	// Search in title and content
+	if params.Search != "" {
+		searchPattern := "%" + params.Search + "%"
+		searchQuery := fmt.Sprintf("(title LIKE $%d OR content LIKE $%d)", argIndex, argIndex)
+		args = append(args, searchPattern)
+		argIndex++
+
+		if match := ftsQuery(params.Search); match != "" {
+			searchQuery = fmt.Sprintf(`(
+				seq IN (SELECT rowid FROM notes_fts WHERE notes_fts MATCH $%d) OR
+				title LIKE $%d OR
+				content LIKE $%d
+			)`, argIndex, argIndex-1, argIndex-1)
+			args = append(args, match)
+			argIndex++
+		}
+
+		whereConditions = append(whereConditions, searchQuery)
+	}
+
+	// Tags filter
+	if params.Tags != "" {
+		tagsArray := params.GetTagsArray()
+		if len(tagsArray) > 0 {
+			tagConditions := make([]string, len(tagsArray))
+			for i, tag := range tagsArray {
+				tagConditions[i] = fmt.Sprintf("tags LIKE $%d", argIndex)
+				args = append(args, "%"+tag+"%")
+				argIndex++
+			}
+			whereConditions = append(whereConditions, "("+strings.Join(tagConditions, " OR ")+")")
+		}
+	}
+
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
+	var total int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notes: %w", err)
+	}
+
+	// Build main query with pagination
+	offset := (params.Page - 1) * params.PageSize
+	query := fmt.Sprintf(`
+		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
+			   created_at, updated_at, deleted_at
+		FROM notes 
+		WHERE %s
+		ORDER BY %s %s
+		LIMIT $%d OFFSET $%d
+	`, whereClause, params.SortBy, params.SortDir, argIndex, argIndex+1)
+
+	args = append(args, params.PageSize, offset)
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []model.Note
+	for rows.Next() {
+		var note model.Note
+		err := rows.Scan(
+			&note.ID,
+			&note.UserID,
+			&note.Title,
+			&note.Content,
+			&note.Status,
+			&note.Tags,
+			&note.IsPublic,
+			&note.ViewCount,
+			&note.CreatedAt,
+			&note.UpdatedAt,
+			&note.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan note row: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating note rows: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+// Search performs advanced search across notes
+func (r *SQLiteNoteRepository) Search(ctx context.Context, userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "Search", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// Set defaults
+	req.SetDefaults()
+
+	// Build WHERE clause
+	whereConditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	argIndex := 2
+
+	// Status filter
+	if req.Status != "all" {
+		whereConditions = append(whereConditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, req.Status)
+		argIndex++
+	}
+
+	// Public filter
+	if req.IsPublic != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("is_public = $%d", argIndex))
+		args = append(args, *req.IsPublic)
+		argIndex++
+	}
+
+	// Full-text search query (only if query is provided). Input without
+	// searchable terms matches nothing, as with plainto_tsquery.
+	match := ftsQuery(req.Query)
+	if req.Query != "" {
+		if match == "" {
+			return nil, 0, nil
+		}
+		whereConditions = append(whereConditions, fmt.Sprintf("seq IN (SELECT rowid FROM notes_fts WHERE notes_fts MATCH $%d)", argIndex))
+		args = append(args, match)
+		argIndex++
+	}
+
+	// Tags filter
+	if len(req.Tags) > 0 {
+		tagConditions := make([]string, len(req.Tags))
+		for i, tag := range req.Tags {
+			tagConditions[i] = fmt.Sprintf("tags LIKE $%d", argIndex)
+			args = append(args, "%"+tag+"%")
+			argIndex++
+		}
+		whereConditions = append(whereConditions, "("+strings.Join(tagConditions, " OR ")+")")
+	}
+
+	// Date range filter
+	if req.DateFrom != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, *req.DateFrom)
+		argIndex++
+	}
+
+	if req.DateTo != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at <= $%d", argIndex))
+		args = append(args, *req.DateTo)
+		argIndex++
+	}
+
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
+	var total int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	// Build main query with ranking
+	offset := (req.Page - 1) * req.PageSize
+
+	selectFields := "id, user_id, title, status, tags, is_public, view_count, created_at, updated_at, deleted_at"
+	if req.IncludeContent {
+		selectFields = "id, user_id, title, content, status, tags, is_public, view_count, created_at, updated_at, deleted_at"
+	} else {
+		selectFields = "id, user_id, title, NULL as content, status, tags, is_public, view_count, created_at, updated_at, deleted_at"
+	}
+
+	var query string
+	if req.Query != "" {
+		// With text search ranking; bm25 is lower for better matches
+		query = fmt.Sprintf(`
+			SELECT %s, fts.rank
+			FROM notes
+			JOIN (SELECT rowid, bm25(notes_fts) AS rank FROM notes_fts WHERE notes_fts MATCH $%d) fts
+				ON fts.rowid = notes.seq
+			WHERE %s
+			ORDER BY fts.rank, updated_at DESC
+			LIMIT $%d OFFSET $%d
+		`, selectFields, argIndex, whereClause, argIndex+1, argIndex+2)
+		args = append(args, match, req.PageSize, offset)
+	} else {
+		// Without text search ranking
+		query = fmt.Sprintf(`
+			SELECT %s, 0 as rank
+			FROM notes 
+			WHERE %s
+			ORDER BY updated_at DESC
+			LIMIT $%d OFFSET $%d
+		`, selectFields, whereClause, argIndex, argIndex+1)
+		args = append(args, req.PageSize, offset)
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []model.Note
+	for rows.Next() {
+		var note model.Note
+		var rank float64
+		err := rows.Scan(
+			&note.ID,
+			&note.UserID,
+			&note.Title,
+			&note.Content,
+			&note.Status,
+			&note.Tags,
+			&note.IsPublic,
+			&note.ViewCount,
+			&note.CreatedAt,
+			&note.UpdatedAt,
+			&note.DeletedAt,
+			&rank,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+// AddViewCounts adds views to several notes. Callers batch the statements
+// in a transaction.
+func (r *SQLiteNoteRepository) AddViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	defer metrics.ObserveQuery("notes", "AddViewCounts", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE notes SET view_count = view_count + $2 WHERE id = $1`
+
+	for _, id := range sortedIDs(counts) {
+		if _, err := executor(ctx, r.db).ExecContext(ctx, query, id, counts[id]); err != nil {
+			return fmt.Errorf("failed to add view counts: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PublishDue makes public the notes whose publish_at has passed, turning
+// drafts active, and returns their IDs
+func (r *SQLiteNoteRepository) PublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "PublishDue", time.Now())
+
+	query := `
+		UPDATE notes
+		SET is_public = TRUE, status = 'active', publish_at = NULL, updated_at = $1
+		WHERE publish_at <= $1 AND status IN ('active', 'draft')
+		RETURNING id
+	`
+	ids, err := r.updateDue(ctx, query, utc(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish scheduled notes: %w", err)
+	}
+	return ids, nil
+}
+
+// UnpublishDue makes private the notes whose unpublish_at has passed and
+// returns their IDs
+func (r *SQLiteNoteRepository) UnpublishDue(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	defer metrics.ObserveQuery("notes", "UnpublishDue", time.Now())
+
+	query := `
+		UPDATE notes
+		SET is_public = FALSE, unpublish_at = NULL, updated_at = $1
+		WHERE unpublish_at <= $1 AND status != 'deleted'
+		RETURNING id
+	`
+	ids, err := r.updateDue(ctx, query, utc(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpublish scheduled notes: %w", err)
+	}
+	return ids, nil
+}
+
+// updateDue runs a scheduled update returning the IDs of the changed notes
+func (r *SQLiteNoteRepository) updateDue(ctx context.Context, query string, now interface{}) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetPublicNotes retrieves public notes with pagination
+func (r *SQLiteNoteRepository) GetPublicNotes(ctx context.Context, params *model.GetNotesParams) ([]model.Note, int64, error) {
+	defer metrics.ObserveQuery("notes", "GetPublicNotes", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// Set defaults
+	params.SetDefaults()
+
+	// Build WHERE clause for public notes
+	whereConditions := []string{"is_public = true", "status = 'active'"}
+	args := []interface{}{}
+	argIndex := 1
+
+	// Search in title and content
+	if params.Search != "" {
+		searchPattern := "%" + params.Search + "%"
+		searchQuery := fmt.Sprintf("(title LIKE $%d OR content LIKE $%d)", argIndex, argIndex)
+		args = append(args, searchPattern)
+		argIndex++
+
+		if match := ftsQuery(params.Search); match != "" {
+			searchQuery = fmt.Sprintf(`(
+				seq IN (SELECT rowid FROM notes_fts WHERE notes_fts MATCH $%d) OR
+				title LIKE $%d OR
+				content LIKE $%d
+			)`, argIndex, argIndex-1, argIndex-1)
+			args = append(args, match)
+			argIndex++
+		}
+
+		whereConditions = append(whereConditions, searchQuery)
+	}
+
+	// Tags filter
+	if params.Tags != "" {
+		tagsArray := params.GetTagsArray()
+		if len(tagsArray) > 0 {
+			tagConditions := make([]string, len(tagsArray))
+			for i, tag := range tagsArray {
+				tagConditions[i] = fmt.Sprintf("tags LIKE $%d", argIndex)
+				args = append(args, "%"+tag+"%")
+				argIndex++
+			}
+			whereConditions = append(whereConditions, "("+strings.Join(tagConditions, " OR ")+")")
+		}
+	}
+
+	// Author filter
+	if params.AuthorID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, *params.AuthorID)
+		argIndex++
+	}
+
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE %s", whereClause)
+	var total int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count public notes: %w", err)
+	}
+
+	// Build main query with pagination
+	offset := (params.Page - 1) * params.PageSize
+	query := fmt.Sprintf(`
+		SELECT id, user_id, title, content, status, tags, is_public, view_count, 
+			   created_at, updated_at, deleted_at
+		FROM notes 
+		WHERE %s
+		ORDER BY %s %s
+		LIMIT $%d OFFSET $%d
+	`, whereClause, params.SortBy, params.SortDir, argIndex, argIndex+1)
+
+	args = append(args, params.PageSize, offset)
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query public notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []model.Note
+	for rows.Next() {
+		var note model.Note
+		err := rows.Scan(
+			&note.ID,
+			&note.UserID,
+			&note.Title,
+			&note.Content,
+			&note.Status,
+			&note.Tags,
+			&note.IsPublic,
+			&note.ViewCount,
+			&note.CreatedAt,
+			&note.UpdatedAt,
+			&note.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan public note row: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating public note rows: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+// BulkUpdateStatus updates status for multiple notes and returns how many
+// were updated; IDs of other users' notes are skipped, so none updated is 0
+func (r *SQLiteNoteRepository) BulkUpdateStatus(ctx context.Context, userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) (int64, error) {
+	defer metrics.ObserveQuery("notes", "BulkUpdateStatus", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if len(noteIDs) == 0 {
+		return 0, fmt.Errorf("no note IDs provided")
+	}
+
+	// Create placeholders for note IDs
+	placeholders := make([]string, len(noteIDs))
+	args := []interface{}{userID, status, time.Now().UTC()}
+
+	for i, noteID := range noteIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+4) // Start from $4 since $1=userID, $2=status, $3=now
+		args = append(args, noteID)
+	}
+
+	// deleted_at follows the status, which a trigger does in PostgreSQL
+	query := fmt.Sprintf(`
+		UPDATE notes
+		SET status = $2, updated_at = $3,
+			deleted_at = CASE WHEN $2 = 'deleted' THEN COALESCE(deleted_at, $3) ELSE NULL END,
+			publish_at = CASE WHEN $2 = 'deleted' OR status = 'deleted' THEN NULL ELSE publish_at END,
+			unpublish_at = CASE WHEN $2 = 'deleted' OR status = 'deleted' THEN NULL ELSE unpublish_at END
+		WHERE user_id = $1 AND id IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk update status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// GetNoteStats returns statistics for user's notes
+func (r *SQLiteNoteRepository) GetNoteStats(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
+	defer metrics.ObserveQuery("notes", "GetNoteStats", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COUNT(*) as total,
+			COUNT(CASE WHEN status = 'active' THEN 1 END) as active,
+			COUNT(CASE WHEN status = 'draft' THEN 1 END) as drafts,
+			COUNT(CASE WHEN status = 'deleted' THEN 1 END) as deleted,
+			COUNT(CASE WHEN is_public = true AND status = 'active' THEN 1 END) as public,
+			COALESCE(SUM(view_count), 0) as total_views
+		FROM notes 
+		WHERE user_id = $1
+	`
+
+	var total, active, drafts, deleted, public, totalViews int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&total, &active, &drafts, &deleted, &public, &totalViews)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note stats: %w", err)
+	}
+
+	stats := map[string]interface{}{
+		"total":       total,
+		"active":      active,
+		"drafts":      drafts,
+		"deleted":     deleted,
+		"public":      public,
+		"total_views": totalViews,
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gonotes/internal/migrate"
+	"gonotes/internal/model"
+	"gonotes/migrations"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// newTestSQLite opens a migrated database in a temporary directory
+func newTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=foreign_keys(1)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrations.SQLiteFS, migrate.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+// newTestSQLiteNotes returns a note repository and the ID of a user owning seeded notes
+func newTestSQLiteNotes(t *testing.T) (*SQLiteNoteRepository, uuid.UUID) {
+	t.Helper()
+
+	db := newTestSQLite(t)
+	users := NewSQLiteUserRepository(db, time.Second)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: "hash", FullName: "Test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	repo := NewSQLiteNoteRepository(db, time.Second)
+	seedNotes(t, repo, user.ID)
+	return repo, user.ID
+}
+
+func TestSQLiteNoteRepository_GetByUserID(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+
+	tests := []struct {
+		name      string
+		params    model.GetNotesParams
+		wantTotal int64
+	}{
+		{"defaults to active", model.GetNotesParams{}, 2},
+		{"all statuses", model.GetNotesParams{Status: "all"}, 4},
+		{"tag filter", model.GetNotesParams{Status: "all", Tags: "GO"}, 2},
+		{"stemmed full-text match", model.GetNotesParams{Status: "all", Search: "channel"}, 1},
+		{"substring match", model.GetNotesParams{Status: "all", Search: "normaliz"}, 1},
+		{"operators are literal", model.GetNotesParams{Status: "all", Search: `go OR "NOT"`}, 0},
+		{"public filter", model.GetNotesParams{IsPublic: boolPtr(true)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			_, total, err := repo.GetByUserID(ctx, userID, &params)
+			if err != nil {
+				t.Fatalf("GetByUserID() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSQLiteNoteRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+
+	notes, total, err := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "go", Status: "all", IncludeContent: true})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 2 || len(notes) != 2 {
+		t.Fatalf("got %d notes (total %d), want 2", len(notes), total)
+	}
+	if notes[0].Content == nil {
+		t.Error("content missing with include_content")
+	}
+
+	// Terms must all match within the title or within the content
+	if _, total, _ := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "advanced channels", Status: "all"}); total != 0 {
+		t.Errorf("terms split across title and content matched %d notes", total)
+	}
+	if _, total, _ := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "learn language", Status: "all"}); total != 1 {
+		t.Errorf("stemmed content terms matched %d notes, want 1", total)
+	}
+}
+
+func TestSQLiteNoteRepository_DeleteDropsSchedule(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+
+	notes, _, _ := repo.GetByUserID(ctx, userID, &model.GetNotesParams{})
+	publishAt := time.Now().Add(time.Minute)
+	for i := range notes[:2] {
+		notes[i].PublishAt = &publishAt
+		if err := repo.Update(ctx, &notes[i]); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	if err := repo.Delete(ctx, notes[0].ID, userID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.BulkUpdateStatus(ctx, userID, []uuid.UUID{notes[1].ID}, model.NoteStatusDeleted); err != nil {
+		t.Fatalf("BulkUpdateStatus() error = %v", err)
+	}
+	if err := repo.Restore(ctx, notes[0].ID, userID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := repo.BulkUpdateStatus(ctx, userID, []uuid.UUID{notes[1].ID}, model.NoteStatusActive); err != nil {
+		t.Fatalf("BulkUpdateStatus() error = %v", err)
+	}
+
+	// Restored notes are not published for a publish_at that passed meanwhile
+	ids, err := repo.PublishDue(ctx, publishAt.Add(time.Hour))
+	if err != nil || len(ids) != 0 {
+		t.Errorf("PublishDue() after restore = %v, %v, want none", ids, err)
+	}
+}
+
+func TestSQLiteNoteRepository_StatusChanges(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+
+	notes, _, _ := repo.GetByUserID(ctx, userID, &model.GetNotesParams{})
+	ids := []uuid.UUID{notes[0].ID, notes[1].ID}
+
+	if count, err := repo.BulkUpdateStatus(ctx, userID, ids, model.NoteStatusDeleted); err != nil || count != 2 {
+		t.Fatalf("BulkUpdateStatus() = %d, %v", count, err)
+	}
+	if count, err := repo.BulkUpdateStatus(ctx, userID, []uuid.UUID{uuid.New()}, model.NoteStatusDeleted); err != nil || count != 0 {
+		t.Errorf("BulkUpdateStatus() of missing notes = %d, %v, want 0, nil", count, err)
+	}
+	note, _ := repo.GetByID(ctx, ids[0])
+	if note.DeletedAt == nil {
+		t.Error("deleted_at not set by bulk delete")
+	}
+
+	if err := repo.Restore(ctx, ids[0], userID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if err := repo.Delete(ctx, ids[0], userID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete(ctx, ids[0], userID); err == nil {
+		t.Error("Delete() of a deleted note succeeded")
+	}
+
+	// The full-text index follows updates and hard deletes
+	note.Title = "Renamed"
+	note.Status = model.NoteStatusActive
+	note.DeletedAt = nil
+	if err := repo.Update(ctx, note); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, total, _ := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "renamed", Status: "all"}); total != 1 {
+		t.Errorf("renamed note matched %d times, want 1", total)
+	}
+	if err := repo.HardDelete(ctx, note.ID, userID); err != nil {
+		t.Fatalf("HardDelete() error = %v", err)
+	}
+	if _, total, _ := repo.Search(ctx, userID, &model.NoteSearchRequest{Query: "renamed", Status: "all"}); total != 0 {
+		t.Errorf("hard deleted note still matched %d times", total)
+	}
+
+	stats, err := repo.GetNoteStats(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetNoteStats() error = %v", err)
+	}
+	if stats["total"] != int64(3) || stats["deleted"] != int64(2) {
+		t.Errorf("stats = %v", stats)
+	}
+}
+
+func TestSQLiteNoteRepository_PublishDue(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	draft := &model.Note{ID: uuid.New(), UserID: userID, Title: "Scheduled draft", Status: model.NoteStatusDraft}
+	later := &model.Note{ID: uuid.New(), UserID: userID, Title: "Later", Status: model.NoteStatusActive}
+	for _, note := range []*model.Note{draft, later} {
+		if err := repo.Create(ctx, note); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	draft.PublishAt, draft.UnpublishAt = &past, &future
+	later.PublishAt = &future
+	for _, note := range []*model.Note{draft, later} {
+		if err := repo.Update(ctx, note); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	ids, err := repo.PublishDue(ctx, now)
+	if err != nil {
+		t.Fatalf("PublishDue() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != draft.ID {
+		t.Fatalf("PublishDue() = %v, want only the draft", ids)
+	}
+
+	got, err := repo.GetByID(ctx, draft.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !got.IsPublic || got.Status != model.NoteStatusActive || got.PublishAt != nil || got.UnpublishAt == nil {
+		t.Errorf("published draft = public %v, status %s, publish_at %v, unpublish_at %v", got.IsPublic, got.Status, got.PublishAt, got.UnpublishAt)
+	}
+
+	ids, err = repo.UnpublishDue(ctx, future.Add(time.Second))
+	if err != nil {
+		t.Fatalf("UnpublishDue() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != draft.ID {
+		t.Fatalf("UnpublishDue() = %v, want only the draft", ids)
+	}
+	if got, _ := repo.GetByID(ctx, draft.ID); got.IsPublic || got.UnpublishAt != nil {
+		t.Errorf("unpublished note = public %v, unpublish_at %v", got.IsPublic, got.UnpublishAt)
+	}
+}
+
+func TestSQLiteNoteRepository_PurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	repo, userID := newTestSQLiteNotes(t)
+	users := NewSQLiteUserRepository(repo.db, time.Second)
+
+	days := 3
+	patient := &model.User{ID: uuid.New(), Email: "patient@example.com", Password: "hash", FullName: "Patient", TrashRetentionDays: &days, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := users.Create(ctx, patient); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	now := time.Now()
+	deletedAt := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+	old := &model.Note{ID: uuid.New(), UserID: userID, Title: "Old", Status: model.NoteStatusActive}
+	recent := &model.Note{ID: uuid.New(), UserID: userID, Title: "Recent", Status: model.NoteStatusActive}
+	kept := &model.Note{ID: uuid.New(), UserID: patient.ID, Title: "Kept longer", Status: model.NoteStatusActive}
+	for note, ago := range map[*model.Note]time.Duration{old: 72 * time.Hour, recent: time.Hour, kept: 48 * time.Hour} {
+		if err := repo.Create(ctx, note); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		note.Status, note.DeletedAt = model.NoteStatusDeleted, deletedAt(ago)
+		if err := repo.Update(ctx, note); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	// The default retention is one day; the second user keeps notes three
+	purged, err := repo.PurgeDeleted(ctx, now, 1, 10)
+	if err != nil {
+		t.Fatalf("PurgeDeleted() error = %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID || purged[0].UserID != userID || purged[0].DeletedAt == nil {
+		t.Fatalf("PurgeDeleted() = %+v, want only the old note", purged)
+	}
+	if got, _ := repo.GetByID(ctx, old.ID); got != nil {
+		t.Error("purged note still exists")
+	}
+
+	// Two days on, the rest is due, including the note deleted by seedNotes
+	purged, err = repo.PurgeDeleted(ctx, now.Add(48*time.Hour), 1, 10)
+	if err != nil || len(purged) != 3 {
+		t.Fatalf("PurgeDeleted(+48h) = %d notes, %v, want 3", len(purged), err)
+	}
+
+	trashed := &model.Note{ID: uuid.New(), UserID: userID, Title: "Trashed", Status: model.NoteStatusActive}
+	if err := repo.Create(ctx, trashed); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.Delete(ctx, trashed.ID, userID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	ids, err := repo.EmptyTrash(ctx, userID)
+	if err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != trashed.ID {
+		t.Errorf("EmptyTrash() = %v, want the trashed note", ids)
+	}
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+)
+
+// SQLiteOutboxRepository handles SQLite operations for the outbox
+type SQLiteOutboxRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSQLiteOutboxRepository creates a new SQLite outbox repository
+func NewSQLiteOutboxRepository(db *sql.DB, queryTimeout time.Duration) *SQLiteOutboxRepository {
+	return &SQLiteOutboxRepository{db: db, timeout: queryTimeout}
+}
+
+// Add records a message in the unit of work of ctx
+func (r *SQLiteOutboxRepository) Add(ctx context.Context, msg *model.OutboxMessage) error {
+	defer metrics.ObserveQuery("outbox", "Add", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO outbox (kind, payload, next_attempt_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, msg.Kind, string(msg.Payload), utc(msg.NextAttemptAt)).
+		Scan(&msg.ID, &msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue leases pending messages that are due. SQLite serializes writers,
+// so the select and update cannot race another relay.
+func (r *SQLiteOutboxRepository) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.OutboxMessage, error) {
+	defer metrics.ObserveQuery("outbox", "ClaimDue", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE processed_at IS NULL AND next_attempt_at <= $3
+			ORDER BY id
+			LIMIT $2
+		)
+		RETURNING id, kind, payload, attempts, last_error, created_at, next_attempt_at
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, utc(leaseUntil), limit, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []model.OutboxMessage
+	for rows.Next() {
+		var msg model.OutboxMessage
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Kind,
+			&msg.Payload,
+			&msg.Attempts,
+			&msg.LastError,
+			&msg.CreatedAt,
+			&msg.NextAttemptAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// MarkProcessed records that a message was delivered
+func (r *SQLiteOutboxRepository) MarkProcessed(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("outbox", "MarkProcessed", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE outbox SET processed_at = $2, last_error = NULL WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to mark outbox message processed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to retry it
+func (r *SQLiteOutboxRepository) MarkFailed(ctx context.Context, id int64, errMsg string, retryAt time.Time) error {
+	defer metrics.ObserveQuery("outbox", "MarkFailed", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id, errMsg, utc(retryAt)); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteProcessedBefore removes messages delivered before the given time
+func (r *SQLiteOutboxRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("outbox", "DeleteProcessedBefore", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM outbox WHERE processed_at < $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, utc(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed outbox messages: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteSessionRepository handles SQLite operations for sessions
+type SQLiteSessionRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSQLiteSessionRepository creates a new SQLite session repository
+func NewSQLiteSessionRepository(db *sql.DB, queryTimeout time.Duration) *SQLiteSessionRepository {
+	return &SQLiteSessionRepository{db: db, timeout: queryTimeout}
+}
+
+// Create creates a new session in the database
+func (r *SQLiteSessionRepository) Create(ctx context.Context, session *model.Session) error {
+	defer metrics.ObserveQuery("sessions", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO sessions (id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.RefreshToken,
+		session.UserAgent,
+		session.IPAddress,
+		session.IsValid,
+		utc(session.CreatedAt),
+		utcPtr(session.ExpiresAt),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetByRefreshToken retrieves a session by refresh token
+func (r *SQLiteSessionRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetByRefreshToken", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at
+		FROM sessions
+		WHERE refresh_token = $1 AND is_valid = true
+	`
+
+	session := &model.Session{}
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, refreshToken).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.IsValid,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Session not found
+		}
+		return nil, fmt.Errorf("failed to get session by refresh token: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetByUserID retrieves all valid sessions for a user
+func (r *SQLiteSessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, refresh_token, user_agent, ip_address, is_valid, created_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND is_valid = true
+		ORDER BY created_at DESC
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions by user ID: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.RefreshToken,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.IsValid,
+			&session.CreatedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// InvalidateByRefreshToken marks a session as invalid by refresh token
+func (r *SQLiteSessionRepository) InvalidateByRefreshToken(ctx context.Context, refreshToken string) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateByRefreshToken", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET is_valid = false
+		WHERE refresh_token = $1
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, refreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// InvalidateBySessionID marks a session as invalid by session ID
+func (r *SQLiteSessionRepository) InvalidateBySessionID(ctx context.Context, sessionID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateBySessionID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET is_valid = false
+		WHERE id = $1
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// InvalidateAllByUserID marks all sessions as invalid for a user
+func (r *SQLiteSessionRepository) InvalidateAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateAllByUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET is_valid = false
+		WHERE user_id = $1 AND is_valid = true
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate all sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CleanupExpiredSessions removes expired sessions from database
+func (r *SQLiteSessionRepository) CleanupExpiredSessions(ctx context.Context) error {
+	defer metrics.ObserveQuery("sessions", "CleanupExpiredSessions", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM sessions
+		WHERE expires_at < $1 OR (expires_at IS NULL AND created_at < $2)
+	`
+
+	// Remove sessions expired or older than 30 days if no expires_at
+	now := time.Now().UTC()
+	thirtyDaysAgo := now.AddDate(0, 0, -30)
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, now, thirtyDaysAgo)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserSessions retrieves all active sessions for a user
+func (r *SQLiteSessionRepository) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetUserSessions", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, refresh_token, user_agent, ip_address, 
+			   is_valid, created_at, expires_at
+		FROM sessions 
+		WHERE user_id = $1 AND is_valid = true 
+		ORDER BY created_at DESC
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.RefreshToken,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.IsValid,
+			&session.CreatedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// GetSessionByIDAndUserID retrieves a specific session by ID and user ID
+func (r *SQLiteSessionRepository) GetSessionByIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) (*model.Session, error) {
+	defer metrics.ObserveQuery("sessions", "GetSessionByIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, refresh_token, user_agent, ip_address, 
+			   is_valid, created_at, expires_at
+		FROM sessions 
+		WHERE id = $1 AND user_id = $2 AND is_valid = true
+	`
+
+	session := &model.Session{}
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, sessionID, userID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.IsValid,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Session not found
+		}
+		return nil, fmt.Errorf("failed to get session by ID and user ID: %w", err)
+	}
+
+	return session, nil
+}
+
+// InvalidateBySessionIDAndUserID invalidates a specific session for a user
+func (r *SQLiteSessionRepository) InvalidateBySessionIDAndUserID(ctx context.Context, sessionID, userID uuid.UUID) error {
+	defer metrics.ObserveQuery("sessions", "InvalidateBySessionIDAndUserID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET is_valid = false
+		WHERE id = $1 AND user_id = $2 AND is_valid = true
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found or not owned by user")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteShareLinkRepository_RecordView(t *testing.T) {
+	ctx := context.Background()
+	notes, userID := newTestSQLiteNotes(t)
+	repo := NewSQLiteShareLinkRepository(notes.db, time.Second)
+
+	note := &model.Note{ID: uuid.New(), UserID: userID, Title: "Shared", Status: model.NoteStatusActive, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := notes.Create(ctx, note); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	maxViews := int64(1)
+	expiresAt := time.Now().Add(time.Hour)
+	limited := &model.ShareLink{ID: uuid.New(), NoteID: note.ID, UserID: userID, Slug: "limited", MaxViews: &maxViews, ExpiresAt: &expiresAt, CreatedAt: time.Now()}
+	expiredAt := time.Now().Add(-time.Hour)
+	expired := &model.ShareLink{ID: uuid.New(), NoteID: note.ID, UserID: userID, Slug: "expired", ExpiresAt: &expiredAt, CreatedAt: time.Now()}
+	for _, link := range []*model.ShareLink{limited, expired} {
+		if err := repo.Create(ctx, link); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		id   uuid.UUID
+		want bool
+	}{
+		{name: "within limit", id: limited.ID, want: true},
+		{name: "limit reached", id: limited.ID, want: false},
+		{name: "expired", id: expired.ID, want: false},
+	}
+	for _, tt := range tests {
+		got, err := repo.RecordView(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("%s: RecordView() error = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: RecordView() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	stored, err := repo.GetBySlug(ctx, "limited")
+	if err != nil || stored == nil {
+		t.Fatalf("GetBySlug() = %v, %v", stored, err)
+	}
+	if stored.ViewCount != 1 || stored.LastViewedAt == nil {
+		t.Errorf("GetBySlug() view count = %d, last viewed %v", stored.ViewCount, stored.LastViewedAt)
+	}
+
+	if err := repo.Revoke(ctx, limited.ID, note.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	links, _ := repo.GetByNoteID(ctx, note.ID)
+	if len(links) != 2 {
+		t.Errorf("GetByNoteID() returned %d links, want 2", len(links))
+	}
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteUserRepository handles SQLite operations for users
+type SQLiteUserRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSQLiteUserRepository creates a new SQLite user repository
+func NewSQLiteUserRepository(db *sql.DB, queryTimeout time.Duration) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db, timeout: queryTimeout}
+}
+
+// Create creates a new user in the database
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *model.User) error {
+	defer metrics.ObserveQuery("users", "Create", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (id, email, password, full_name, feed_opt_out, handle, bio, avatar_url, profile_public,
+			trash_retention_days, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		query,
+		user.ID,
+		user.Email,
+		user.Password,
+		user.FullName,
+		user.FeedOptOut,
+		user.Handle,
+		user.Bio,
+		user.AvatarURL,
+		user.ProfilePublic,
+		user.TrashRetentionDays,
+		utc(user.CreatedAt),
+		utc(user.UpdatedAt),
+	)
+
+	if err != nil {
+		if sqliteHandleConflict(err) {
+			return fmt.Errorf("failed to create user: handle already exists")
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// GetByEmail retrieves a user by email
+func (r *SQLiteUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	defer metrics.ObserveQuery("users", "GetByEmail", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+
+	user, err := scanUser(executor(ctx, r.db).QueryRowContext(ctx, query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	defer metrics.ObserveQuery("users", "GetByID", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	user, err := scanUser(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+// EmailExists checks if an email already exists in the database
+func (r *SQLiteUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	defer metrics.ObserveQuery("users", "EmailExists", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
+
+	var exists bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// EmailExistsExcludingUser checks if an email already exists excluding specific user
+func (r *SQLiteUserRepository) EmailExistsExcludingUser(ctx context.Context, email string, userID uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("users", "EmailExistsExcludingUser", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id != $2)`
+
+	var exists bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, email, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Update updates a user's profile information
+func (r *SQLiteUserRepository) Update(ctx context.Context, user *model.User) error {
+	defer metrics.ObserveQuery("users", "Update", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET email = $2, full_name = $3, feed_opt_out = $4, handle = $5, bio = $6,
+			avatar_url = $7, profile_public = $8, trash_retention_days = $9, updated_at = $10
+		WHERE id = $1
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx,
+		query,
+		user.ID,
+		user.Email,
+		user.FullName,
+		user.FeedOptOut,
+		user.Handle,
+		user.Bio,
+		user.AvatarURL,
+		user.ProfilePublic,
+		user.TrashRetentionDays,
+		utc(user.UpdatedAt),
+	)
+
+	if err != nil {
+		if sqliteHandleConflict(err) {
+			return fmt.Errorf("failed to update user: handle already exists")
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetByIDs retrieves the users with the given IDs; missing users are skipped
+func (r *SQLiteUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	defer metrics.ObserveQuery("users", "GetByIDs", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0, len(ids))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetByHandle retrieves the user whose current or previous handle is handle
+func (r *SQLiteUserRepository) GetByHandle(ctx context.Context, handle string) (*model.User, error) {
+	defer metrics.ObserveQuery("users", "GetByHandle", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE handle = $1 OR id = (SELECT user_id FROM handle_redirects WHERE handle = $1)
+		ORDER BY handle = $1 DESC
+		LIMIT 1
+	`
+
+	user, err := scanUser(executor(ctx, r.db).QueryRowContext(ctx, query, handle))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to get user by handle: %w", err)
+	}
+
+	return user, nil
+}
+
+// HandleTaken checks if a handle is used or was used by another user
+func (r *SQLiteUserRepository) HandleTaken(ctx context.Context, handle string, userID uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("users", "HandleTaken", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS(SELECT 1 FROM users WHERE handle = $1 AND id != $2)
+			OR EXISTS(SELECT 1 FROM handle_redirects WHERE handle = $1 AND user_id != $2)
+	`
+
+	var taken bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, handle, userID).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to check handle: %w", err)
+	}
+
+	return taken, nil
+}
+
+// RecordHandleChange keeps oldHandle redirecting to the user and drops the
+// redirect of newHandle when the user takes back a previous handle. An
+// empty oldHandle records no redirect.
+func (r *SQLiteUserRepository) RecordHandleChange(ctx context.Context, userID uuid.UUID, oldHandle, newHandle string) error {
+	defer metrics.ObserveQuery("handle_redirects", "RecordHandleChange", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	exec := executor(ctx, r.db)
+
+	if _, err := exec.ExecContext(ctx,
+		`DELETE FROM handle_redirects WHERE handle = $1 AND user_id = $2`,
+		newHandle, userID,
+	); err != nil {
+		return fmt.Errorf("failed to delete handle redirect: %w", err)
+	}
+
+	if oldHandle == "" {
+		return nil
+	}
+
+	query := `
+		INSERT INTO handle_redirects (handle, user_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (handle) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at
+	`
+	if _, err := exec.ExecContext(ctx, query, oldHandle, userID, utc(time.Now())); err != nil {
+		return fmt.Errorf("failed to record handle redirect: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteHandleConflict reports whether err violates the unique index on
+// handles, which a concurrent claim of the same handle can hit after HandleTaken
+func sqliteHandleConflict(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed: users.handle")
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteUserRepository_Handles(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSQLiteUserRepository(newTestSQLite(t), time.Second)
+
+	handle := "alice"
+	alice := &model.User{ID: uuid.New(), Email: "alice@example.com", Password: "hash", FullName: "Alice", Handle: &handle, ProfilePublic: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	bob := &model.User{ID: uuid.New(), Email: "bob@example.com", Password: "hash", FullName: "Bob", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	for _, user := range []*model.User{alice, bob} {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// Rename alice and keep the old handle as a redirect
+	renamed := "alice_dev"
+	alice.Handle = &renamed
+	if err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := repo.RecordHandleChange(ctx, alice.ID, "alice", "alice_dev"); err != nil {
+		t.Fatalf("RecordHandleChange() error = %v", err)
+	}
+
+	for _, h := range []string{"alice", "alice_dev"} {
+		user, err := repo.GetByHandle(ctx, h)
+		if err != nil {
+			t.Fatalf("GetByHandle(%q) error = %v", h, err)
+		}
+		if user == nil || user.ID != alice.ID || *user.Handle != "alice_dev" || !user.ProfilePublic {
+			t.Errorf("GetByHandle(%q) = %+v, want alice as alice_dev", h, user)
+		}
+	}
+	if user, err := repo.GetByHandle(ctx, "nobody"); err != nil || user != nil {
+		t.Errorf("GetByHandle(unknown) = %v, %v, want nil, nil", user, err)
+	}
+
+	// A concurrent claim that passed HandleTaken hits the unique index
+	bob.Handle = &renamed
+	if err := repo.Update(ctx, bob); err == nil || !strings.Contains(err.Error(), "handle already exists") {
+		t.Errorf("Update() with a taken handle error = %v, want handle already exists", err)
+	}
+	bob.Handle = nil
+
+	taken, err := repo.HandleTaken(ctx, "alice", bob.ID)
+	if err != nil || !taken {
+		t.Errorf("HandleTaken(old handle, other user) = %v, %v, want true", taken, err)
+	}
+	taken, err = repo.HandleTaken(ctx, "alice", alice.ID)
+	if err != nil || taken {
+		t.Errorf("HandleTaken(old handle, owner) = %v, %v, want false", taken, err)
+	}
+
+	users, err := repo.GetByIDs(ctx, []uuid.UUID{alice.ID, bob.ID, uuid.New()})
+	if err != nil || len(users) != 2 {
+		t.Errorf("GetByIDs() = %d users, %v, want 2", len(users), err)
+	}
+}
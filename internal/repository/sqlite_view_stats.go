@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteViewStatsRepository handles SQLite operations for note view stats.
+// Days are stored as YYYY-MM-DD text.
+type SQLiteViewStatsRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSQLiteViewStatsRepository creates a new SQLite view stats repository
+func NewSQLiteViewStatsRepository(db *sql.DB, queryTimeout time.Duration) *SQLiteViewStatsRepository {
+	return &SQLiteViewStatsRepository{db: db, timeout: queryTimeout}
+}
+
+// Add upserts the rows. Callers batch the statements in a transaction.
+func (r *SQLiteViewStatsRepository) Add(ctx context.Context, stats []model.NoteViewStat) error {
+	defer metrics.ObserveQuery("note_view_stats", "Add", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// The EXISTS check drops views of notes deleted before the flush
+	query := `
+		INSERT INTO note_view_stats (note_id, day, referrer, views, unique_viewers)
+		SELECT $1, $2, $3, $4, $5
+		WHERE EXISTS (SELECT 1 FROM notes WHERE id = $1)
+		ON CONFLICT (note_id, day, referrer) DO UPDATE SET
+			views = views + excluded.views,
+			unique_viewers = unique_viewers + excluded.unique_viewers
+	`
+
+	for _, stat := range stats {
+		_, err := executor(ctx, r.db).ExecContext(ctx, query,
+			stat.NoteID, stat.Day.Format("2006-01-02"), stat.Referrer, stat.Views, stat.UniqueViewers)
+		if err != nil {
+			return fmt.Errorf("failed to add view stats: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByNote returns the rows of a note from the day since on
+func (r *SQLiteViewStatsRepository) GetByNote(ctx context.Context, noteID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	defer metrics.ObserveQuery("note_view_stats", "GetByNote", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT day, referrer, views, unique_viewers
+		FROM note_view_stats
+		WHERE note_id = $1 AND day >= $2
+		ORDER BY day, referrer
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, noteID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note view stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats, err := scanSQLiteViewStats(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan note view stats: %w", err)
+	}
+	for i := range stats {
+		stats[i].NoteID = noteID
+	}
+	return stats, nil
+}
+
+// GetByUser returns the rows of a user's public notes from the day since on, summed per day and referrer
+func (r *SQLiteViewStatsRepository) GetByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]model.NoteViewStat, error) {
+	defer metrics.ObserveQuery("note_view_stats", "GetByUser", time.Now())
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT s.day, s.referrer, SUM(s.views), SUM(s.unique_viewers)
+		FROM note_view_stats s
+		JOIN notes n ON n.id = s.note_id
+		WHERE n.user_id = $1 AND n.is_public = 1 AND s.day >= $2
+		GROUP BY s.day, s.referrer
+		ORDER BY s.day, s.referrer
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user view stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats, err := scanSQLiteViewStats(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user view stats: %w", err)
+	}
+	return stats, nil
+}
+
+// scanSQLiteViewStats reads day, referrer, views and unique viewers rows
+func scanSQLiteViewStats(rows *sql.Rows) ([]model.NoteViewStat, error) {
+	var stats []model.NoteViewStat
+	for rows.Next() {
+		var stat model.NoteViewStat
+		var day string
+		if err := rows.Scan(&day, &stat.Referrer, &stat.Views, &stat.UniqueViewers); err != nil {
+			return nil, err
+		}
+		parsed, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day %q: %w", day, err)
+		}
+		stat.Day = parsed
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
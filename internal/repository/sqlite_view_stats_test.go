@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteViewStatsRepository_Add(t *testing.T) {
+	ctx := context.Background()
+	notes, userID := newTestSQLiteNotes(t)
+	repo := NewSQLiteViewStatsRepository(notes.db, time.Second)
+
+	note := &model.Note{ID: uuid.New(), UserID: userID, Title: "Viewed", Status: model.NoteStatusActive, IsPublic: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := notes.Create(ctx, note); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	rows := []model.NoteViewStat{
+		{NoteID: note.ID, Day: today, Referrer: "example.com", Views: 2, UniqueViewers: 1},
+		{NoteID: note.ID, Day: today, Referrer: "", Views: 1, UniqueViewers: 1},
+		// Unknown notes are dropped rather than failing the batch
+		{NoteID: uuid.New(), Day: today, Views: 5},
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.Add(ctx, rows); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	stats, err := repo.GetByNote(ctx, note.ID, today.AddDate(0, 0, -6))
+	if err != nil {
+		t.Fatalf("GetByNote() error = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("GetByNote() returned %d rows, want 2", len(stats))
+	}
+	if stats[1].Referrer != "example.com" || stats[1].Views != 4 || stats[1].UniqueViewers != 2 {
+		t.Errorf("GetByNote() row = %+v, want 4 views and 2 unique viewers from example.com", stats[1])
+	}
+	if !stats[1].Day.Equal(today) {
+		t.Errorf("GetByNote() day = %v, want %v", stats[1].Day, today)
+	}
+
+	byUser, err := repo.GetByUser(ctx, userID, today)
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	if len(byUser) != 2 {
+		t.Errorf("GetByUser() returned %d rows, want 2", len(byUser))
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// TxManager runs a unit of work. Repository calls made with the context
+// passed to fn take part in the same transaction, which is committed when fn
+// returns nil and rolled back when it returns an error or panics. Calls to
+// WithinTx inside fn join the outer transaction.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// dbtx is implemented by both *sql.DB and *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// txState is the unit of work carried in the context
+type txState struct {
+	tx          *sql.Tx // nil for the in-memory manager
+	afterCommit []func(ctx context.Context)
+}
+
+// executor returns the transaction in ctx, or db outside of a unit of work
+func executor(ctx context.Context, db *sql.DB) dbtx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && state.tx != nil {
+		return state.tx
+	}
+	return db
+}
+
+// AfterCommit runs fn once the unit of work in ctx has committed, or right
+// away outside of one. fn gets a context without the transaction. Hooks are
+// dropped on rollback.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// SQLTxManager runs units of work in a database transaction
+type SQLTxManager struct {
+	db *sql.DB
+}
+
+// NewSQLTxManager creates a transaction manager for db
+func NewSQLTxManager(db *sql.DB) *SQLTxManager {
+	return &SQLTxManager{db: db}
+}
+
+// WithinTx runs fn in a transaction
+func (m *SQLTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	state := &txState{tx: tx}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback(ctx, tx)
+			panic(p)
+		}
+		if err != nil {
+			rollback(ctx, tx)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, hook := range state.afterCommit {
+		hook(ctx)
+	}
+	return nil
+}
+
+// rollback aborts tx, logging failures other than an already finished transaction
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		slog.ErrorContext(ctx, "Failed to roll back transaction", "error", err)
+	}
+}
+
+// MemoryTxManager runs units of work for the in-memory repositories. Writes
+// are applied immediately and not rolled back; after-commit hooks still only
+// run when fn succeeds.
+type MemoryTxManager struct{}
+
+// NewMemoryTxManager creates a transaction manager for in-memory repositories
+func NewMemoryTxManager() *MemoryTxManager {
+	return &MemoryTxManager{}
+}
+
+// WithinTx runs fn and then its after-commit hooks
+func (m *MemoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook(ctx)
+	}
+	return nil
+}
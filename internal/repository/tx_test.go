@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+)
+
+func TestSQLTxManager_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	tx := NewSQLTxManager(db)
+	outbox := NewSQLiteOutboxRepository(db, time.Second)
+
+	add := func(ctx context.Context, kind string) error {
+		return outbox.Add(ctx, &model.OutboxMessage{Kind: kind, Payload: []byte("{}"), NextAttemptAt: time.Now()})
+	}
+
+	hooks := 0
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := add(ctx, "rolled_back"); err != nil {
+			return err
+		}
+		AfterCommit(ctx, func(ctx context.Context) { hooks++ })
+		return errors.New("abort")
+	})
+	if err == nil || err.Error() != "abort" {
+		t.Fatalf("WithinTx() error = %v, want abort", err)
+	}
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) { hooks++ })
+		// Nested units of work join the outer transaction
+		return tx.WithinTx(ctx, func(ctx context.Context) error {
+			return add(ctx, "committed")
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	if hooks != 1 {
+		t.Errorf("after-commit hooks ran %d times, want 1", hooks)
+	}
+
+	messages, err := outbox.ClaimDue(ctx, 10, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Kind != "committed" {
+		t.Errorf("messages = %+v, want only the committed one", messages)
+	}
+}
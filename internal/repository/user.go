@@ -1,160 +0,0 @@
-package repository
-
-import (
-	"database/sql"
-	"fmt"
-
-	"gonotes/internal/model"
-
-	"github.com/google/uuid"
-)
-
-// UserRepository handles database operations for users
-type UserRepository struct {
-	db *sql.DB
-}
-
-// NewUserRepository creates a new user repository
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
-}
-
-// Create creates a new user in the database
-func (r *UserRepository) Create(user *model.User) error {
-	query := `
-		INSERT INTO users (id, email, password, full_name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-
-	_, err := r.db.Exec(
-		query,
-		user.ID,
-		user.Email,
-		user.Password,
-		user.FullName,
-		user.CreatedAt,
-		user.UpdatedAt,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
-	}
-
-	return nil
-}
-
-// GetByEmail retrieves a user by email
-func (r *UserRepository) GetByEmail(email string) (*model.User, error) {
-	query := `
-		SELECT id, email, password, full_name, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
-
-	user := &model.User{}
-
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FullName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // User not found
-		}
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
-	}
-
-	return user, nil
-}
-
-// GetByID retrieves a user by ID
-func (r *UserRepository) GetByID(id uuid.UUID) (*model.User, error) {
-	query := `
-		SELECT id, email, password, full_name, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-
-	user := &model.User{}
-
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FullName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // User not found
-		}
-		return nil, fmt.Errorf("failed to get user by ID: %w", err)
-	}
-
-	return user, nil
-}
-
-// EmailExists checks if an email already exists in the database
-func (r *UserRepository) EmailExists(email string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
-
-	var exists bool
-	err := r.db.QueryRow(query, email).Scan(&exists)
-	if err != nil {
-		return false, fmt.Errorf("failed to check email existence: %w", err)
-	}
-
-	return exists, nil
-}
-
-// EmailExistsExcludingUser checks if an email already exists excluding specific user
-func (r *UserRepository) EmailExistsExcludingUser(email string, userID uuid.UUID) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id != $2)`
-
-	var exists bool
-	err := r.db.QueryRow(query, email, userID).Scan(&exists)
-	if err != nil {
-		return false, fmt.Errorf("failed to check email existence: %w", err)
-	}
-
-	return exists, nil
-}
-
-// Update updates a user's profile information
-func (r *UserRepository) Update(user *model.User) error {
-	query := `
-		UPDATE users 
-		SET email = $2, full_name = $3, updated_at = $4
-		WHERE id = $1
-	`
-
-	result, err := r.db.Exec(
-		query,
-		user.ID,
-		user.Email,
-		user.FullName,
-		user.UpdatedAt,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
-	}
-
-	return nil
-}
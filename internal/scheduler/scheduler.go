@@ -0,0 +1,284 @@
+// Package scheduler runs periodic maintenance jobs on cron schedules. Every
+// replica runs the scheduler; each occurrence of a job is claimed in the
+// key-value store first, so only one replica runs it.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+	"gonotes/internal/store"
+	"gonotes/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// historyTTL drops the run history of jobs that stopped running
+const historyTTL = 30 * 24 * time.Hour
+
+// historyWriteTimeout bounds recording a run, which also happens on shutdown
+const historyWriteTimeout = 5 * time.Second
+
+// Func is the work of a job. It must return promptly once ctx is done.
+type Func func(ctx context.Context) error
+
+// job is a registered job
+type job struct {
+	name     string
+	schedule *Schedule
+	timeout  time.Duration
+	fn       Func
+	running  atomic.Bool
+}
+
+// Scheduler runs registered jobs on their schedules, in UTC. A run is
+// cancelled when it exceeds the job timeout, which should be shorter than
+// the time between two occurrences: the claim of an occurrence lasts as long
+// as the timeout.
+type Scheduler struct {
+	store       store.Store
+	instance    string
+	historySize int
+	jobs        []*job
+}
+
+// New creates a scheduler claiming occurrences in st on behalf of instance
+// (e.g. the hostname) and keeping the last historySize runs of each job
+func New(st store.Store, instance string, historySize int) *Scheduler {
+	if historySize <= 0 {
+		historySize = 20
+	}
+	return &Scheduler{
+		store:       st,
+		instance:    instance,
+		historySize: historySize,
+	}
+}
+
+// Register adds a job running fn on the cron schedule spec for at most
+// timeout per run. An empty spec disables the job. Jobs must be registered
+// before Run.
+func (s *Scheduler) Register(name, spec string, timeout time.Duration, fn Func) error {
+	if spec == "" {
+		slog.Info("Scheduled job disabled", "job", name)
+		return nil
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("job %s: timeout must be positive", name)
+	}
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %s: already registered", name)
+		}
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		schedule: schedule,
+		timeout:  timeout,
+		fn:       fn,
+	})
+	return nil
+}
+
+// Run runs the jobs until ctx is cancelled. Running jobs see their context
+// cancelled too; Run returns once they have stopped.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+}
+
+// Status returns every job with its next occurrence and recent runs
+func (s *Scheduler) Status(ctx context.Context) ([]model.JobStatus, error) {
+	now := time.Now().UTC()
+	statuses := make([]model.JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		runs, err := s.history(ctx, j.name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, model.JobStatus{
+			Name:     j.name,
+			Schedule: j.schedule.String(),
+			Timeout:  j.timeout.String(),
+			NextRun:  j.schedule.Next(now),
+			Running:  j.running.Load(),
+			Runs:     runs,
+		})
+	}
+	return statuses, nil
+}
+
+// loop waits for each occurrence of j and runs it. Occurrences that pass
+// while a run is still going are skipped.
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now().UTC())
+		if next.IsZero() {
+			slog.WarnContext(ctx, "Scheduled job never runs again", "job", j.name, "schedule", j.schedule.String())
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runOccurrence(ctx, j, next)
+	}
+}
+
+// runOccurrence claims the occurrence of j scheduled at and runs it if no
+// other instance claimed it first. It reports whether the job ran.
+func (s *Scheduler) runOccurrence(ctx context.Context, j *job, at time.Time) bool {
+	// A claim in the per-replica fallback store would not stop other replicas
+	if s.degraded() {
+		slog.WarnContext(ctx, "Store degraded, skipping scheduled job", "job", j.name, "scheduled_at", at)
+		return false
+	}
+
+	claimKey := fmt.Sprintf("jobs:claim:%s:%d", j.name, at.Unix())
+	claimed, err := s.store.SetNX(ctx, claimKey, s.instance, j.timeout)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to claim scheduled job, skipping", "job", j.name, "error", err)
+		return false
+	}
+	if !claimed {
+		slog.DebugContext(ctx, "Scheduled job claimed by another instance", "job", j.name)
+		return false
+	}
+	// The store may have failed over while claiming
+	if s.degraded() {
+		slog.WarnContext(ctx, "Store degraded, skipping scheduled job", "job", j.name, "scheduled_at", at)
+		return false
+	}
+
+	j.running.Store(true)
+	defer j.running.Store(false)
+
+	run := model.JobRun{
+		ScheduledAt: at,
+		StartedAt:   time.Now().UTC(),
+		Instance:    s.instance,
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, j.timeout)
+	jobCtx, span := tracing.Start(jobCtx, "job "+j.name, attribute.String("gonotes.job", j.name))
+	err = call(jobCtx, j.fn)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	cancel()
+
+	elapsed := time.Since(run.StartedAt)
+	run.DurationMS = float64(elapsed.Microseconds()) / 1000
+	metrics.JobDuration.WithLabelValues(j.name).Observe(elapsed.Seconds())
+
+	if err != nil {
+		run.Error = err.Error()
+		metrics.JobRuns.WithLabelValues(j.name, "failed").Inc()
+		slog.ErrorContext(ctx, "Scheduled job failed", "job", j.name, "duration_ms", run.DurationMS, "error", err)
+	} else {
+		metrics.JobRuns.WithLabelValues(j.name, "succeeded").Inc()
+		slog.InfoContext(ctx, "Scheduled job finished", "job", j.name, "duration_ms", run.DurationMS)
+	}
+
+	s.record(ctx, j.name, run)
+	return true
+}
+
+// degraded reports whether the store is serving from its in-memory fallback,
+// where claims are not shared between replicas
+func (s *Scheduler) degraded() bool {
+	failover, ok := s.store.(interface{ Degraded() bool })
+	return ok && failover.Degraded()
+}
+
+// call runs fn, turning a panic into an error so one job cannot take the
+// scheduler down
+func call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
+
+// record prepends run to the history of a job. Only the instance holding
+// the claim writes, so the read-modify-write does not race.
+func (s *Scheduler) record(ctx context.Context, name string, run model.JobRun) {
+	// Runs cut short by shutdown are recorded too
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), historyWriteTimeout)
+	defer cancel()
+
+	runs, err := s.history(ctx, name)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read job history", "job", name, "error", err)
+	}
+
+	runs = append([]model.JobRun{run}, runs...)
+	if len(runs) > s.historySize {
+		runs = runs[:s.historySize]
+	}
+
+	data, err := json.Marshal(runs)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to encode job history", "job", name, "error", err)
+		return
+	}
+	if err := s.store.Set(ctx, historyKey(name), string(data), historyTTL); err != nil {
+		slog.WarnContext(ctx, "Failed to write job history", "job", name, "error", err)
+	}
+}
+
+// history returns the recorded runs of a job, newest first. Without an
+// authoritative answer from the store the history is reported empty.
+func (s *Scheduler) history(ctx context.Context, name string) ([]model.JobRun, error) {
+	val, found, err := s.store.Get(ctx, historyKey(name))
+	if errors.Is(err, store.ErrUnavailable) {
+		return []model.JobRun{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job history: %w", err)
+	}
+
+	runs := []model.JobRun{}
+	if !found {
+		return runs, nil
+	}
+	if err := json.Unmarshal([]byte(val), &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode job history: %w", err)
+	}
+	return runs, nil
+}
+
+// historyKey is the store key holding the run history of a job
+func historyKey(name string) string {
+	return fmt.Sprintf("jobs:history:%s", name)
+}
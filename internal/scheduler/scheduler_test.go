@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gonotes/internal/store"
+)
+
+func TestScheduler_Register(t *testing.T) {
+	st := store.NewMemoryStore()
+	defer st.Close()
+	s := New(st, "a", 5)
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.Register("cleanup", "*/5 * * * *", time.Minute, noop); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := s.Register("cleanup", "@hourly", time.Minute, noop); err == nil {
+		t.Error("expected error registering a job twice")
+	}
+	if err := s.Register("broken", "61 * * * *", time.Minute, noop); err == nil {
+		t.Error("expected error for an invalid schedule")
+	}
+	if err := s.Register("disabled", "", time.Minute, noop); err != nil {
+		t.Errorf("Register() with empty schedule error = %v", err)
+	}
+	if len(s.jobs) != 1 {
+		t.Errorf("registered %d jobs, want 1", len(s.jobs))
+	}
+}
+
+func TestScheduler_OneInstancePerOccurrence(t *testing.T) {
+	st := store.NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	fn := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	replicas := []*Scheduler{New(st, "a", 5), New(st, "b", 5)}
+	for _, s := range replicas {
+		if err := s.Register("cleanup", "* * * * *", time.Minute, fn); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for _, s := range replicas {
+		s.runOccurrence(ctx, s.jobs[0], at)
+	}
+	if runs.Load() != 1 {
+		t.Fatalf("occurrence ran %d times, want 1", runs.Load())
+	}
+
+	// The next occurrence is claimed independently
+	if !replicas[1].runOccurrence(ctx, replicas[1].jobs[0], at.Add(time.Minute)) {
+		t.Error("expected the next occurrence to run")
+	}
+
+	status, err := replicas[0].Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(status) != 1 || len(status[0].Runs) != 2 {
+		t.Fatalf("Status() = %+v, want 2 runs of one job", status)
+	}
+	if status[0].Runs[0].Instance != "b" || status[0].Runs[1].Instance != "a" {
+		t.Errorf("runs not newest first: %+v", status[0].Runs)
+	}
+}
+
+// degradedStore is a store serving from its fallback
+type degradedStore struct {
+	*store.MemoryStore
+}
+
+func (degradedStore) Degraded() bool { return true }
+
+func TestScheduler_SkipsWhileDegraded(t *testing.T) {
+	st := degradedStore{store.NewMemoryStore()}
+	defer st.Close()
+
+	var runs atomic.Int32
+	s := New(st, "a", 5)
+	if err := s.Register("cleanup", "* * * * *", time.Minute, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	if s.runOccurrence(context.Background(), s.jobs[0], at) || runs.Load() != 0 {
+		t.Error("occurrence ran while the store was degraded")
+	}
+}
+
+func TestScheduler_HistoryRecordsErrors(t *testing.T) {
+	st := store.NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+	s := New(st, "a", 2)
+
+	calls := 0
+	s.Register("flaky", "* * * * *", time.Minute, func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return errors.New("database unreachable")
+		case 2:
+			panic("nil map")
+		}
+		return nil
+	})
+
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		s.runOccurrence(ctx, s.jobs[0], at.Add(time.Duration(i)*time.Minute))
+	}
+
+	runs, err := s.history(ctx, "flaky")
+	if err != nil {
+		t.Fatalf("history() error = %v", err)
+	}
+	// Capped at the history size, newest first
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[0].Error != "" {
+		t.Errorf("latest run error = %q, want none", runs[0].Error)
+	}
+	if runs[1].Error != "panic: nil map" {
+		t.Errorf("previous run error = %q, want panic: nil map", runs[1].Error)
+	}
+}
+
+func TestScheduler_StopsOnShutdown(t *testing.T) {
+	st := store.NewMemoryStore()
+	defer st.Close()
+	s := New(st, "a", 5)
+
+	started := make(chan struct{})
+	s.Register("slow", "* * * * *", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.runOccurrence(ctx, s.jobs[0], time.Now().Truncate(time.Minute))
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after cancellation")
+	}
+
+	runs, _ := s.history(context.Background(), "slow")
+	if len(runs) != 1 || runs[0].Error != context.Canceled.Error() {
+		t.Errorf("runs = %+v, want one cancelled run", runs)
+	}
+
+	// Run returns promptly once cancelled, without waiting for the next occurrence
+	finished := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
@@ -1,19 +1,30 @@
 package service
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"gonotes/internal/model"
 )
 
+// auditFlushInterval bounds how long audit entries stay buffered in memory
+const auditFlushInterval = time.Second
+
 // AuditService handles audit logging operations
 type AuditService struct {
+	mu      sync.Mutex
 	logFile *os.File
+	writer  *bufio.Writer
+
+	stop    chan struct{}
+	stopped chan struct{}
 }
 
 // NewAuditService creates a new audit service
@@ -21,40 +32,49 @@ func NewAuditService() *AuditService {
 	// Create audit log file (in production, this would be a proper logging system)
 	logFile, err := os.OpenFile("audit.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		log.Printf("Failed to open audit log file: %v", err)
+		slog.Error("Failed to open audit log file", "error", err)
 		logFile = nil
 	}
 
-	return &AuditService{
+	s := &AuditService{
 		logFile: logFile,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if logFile != nil {
+		s.writer = bufio.NewWriter(logFile)
 	}
+
+	go s.flushLoop()
+	return s
 }
 
-// LogEvent logs an audit event
-func (s *AuditService) LogEvent(event *model.AuditEvent) {
+// LogEvent logs an audit event. The log record carries the request fields
+// of ctx (request ID, trace) so it correlates with the access log.
+func (s *AuditService) LogEvent(ctx context.Context, event *model.AuditEvent) {
 	// Convert event to JSON
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Failed to marshal audit event: %v", err)
+		slog.ErrorContext(ctx, "Failed to marshal audit event", "error", err)
 		return
 	}
 
+	slog.LogAttrs(ctx, slog.LevelInfo, "audit event", auditAttrs(event)...)
+
 	// Create log entry
 	timestamp := event.CreatedAt.Format(time.RFC3339)
 	logEntry := fmt.Sprintf("[AUDIT] %s - %s\n", timestamp, string(eventJSON))
 
-	// Write to console
-	fmt.Print(logEntry)
-
-	// Write to file if available
-	if s.logFile != nil {
-		s.logFile.WriteString(logEntry)
-		s.logFile.Sync()
+	// Buffer for the file if available, flushed periodically and on shutdown
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer != nil {
+		s.writer.WriteString(logEntry)
 	}
 }
 
 // LogAuthEvent logs an authentication-related event
-func (s *AuditService) LogAuthEvent(action, resource, ipAddress string, userAgent *string, userID *model.User, success bool, errorMsg *string) {
+func (s *AuditService) LogAuthEvent(ctx context.Context, action, resource, ipAddress string, userAgent *string, userID *model.User, success bool, errorMsg *string) {
 	event := model.CreateAuditEvent(model.EventTypeAuth, action, resource)
 	event.SetClientInfo(ipAddress, userAgent)
 
@@ -66,11 +86,11 @@ func (s *AuditService) LogAuthEvent(action, resource, ipAddress string, userAgen
 		event.SetError(*errorMsg)
 	}
 
-	s.LogEvent(event)
+	s.LogEvent(ctx, event)
 }
 
 // LogSessionEvent logs a session-related event
-func (s *AuditService) LogSessionEvent(action string, userID model.User, sessionID string, ipAddress string, userAgent *string, success bool) {
+func (s *AuditService) LogSessionEvent(ctx context.Context, action string, userID model.User, sessionID string, ipAddress string, userAgent *string, success bool) {
 	event := model.CreateAuditEvent(model.EventTypeSession, action, "session")
 	event.SetUser(userID.ID)
 	event.SetResourceID(sessionID)
@@ -80,11 +100,11 @@ func (s *AuditService) LogSessionEvent(action string, userID model.User, session
 		event.SetError("Session operation failed")
 	}
 
-	s.LogEvent(event)
+	s.LogEvent(ctx, event)
 }
 
 // LogUserEvent logs a user-related event
-func (s *AuditService) LogUserEvent(action string, userID model.User, ipAddress string, userAgent *string, details *string, success bool) {
+func (s *AuditService) LogUserEvent(ctx context.Context, action string, userID model.User, ipAddress string, userAgent *string, details *string, success bool) {
 	event := model.CreateAuditEvent(model.EventTypeUser, action, "user")
 	event.SetUser(userID.ID)
 	event.SetResourceID(userID.ID.String())
@@ -98,11 +118,11 @@ func (s *AuditService) LogUserEvent(action string, userID model.User, ipAddress
 		event.SetError("User operation failed")
 	}
 
-	s.LogEvent(event)
+	s.LogEvent(ctx, event)
 }
 
 // LogNoteEvent logs a note-related event
-func (s *AuditService) LogNoteEvent(action string, userID model.User, noteID string, ipAddress string, userAgent *string, success bool) {
+func (s *AuditService) LogNoteEvent(ctx context.Context, action string, userID model.User, noteID string, ipAddress string, userAgent *string, success bool) {
 	event := model.CreateAuditEvent(model.EventTypeNote, action, "note")
 	event.SetUser(userID.ID)
 	event.SetResourceID(noteID)
@@ -112,11 +132,11 @@ func (s *AuditService) LogNoteEvent(action string, userID model.User, noteID str
 		event.SetError("Note operation failed")
 	}
 
-	s.LogEvent(event)
+	s.LogEvent(ctx, event)
 }
 
 // LogSecurityEvent logs a security-related event
-func (s *AuditService) LogSecurityEvent(action, details, ipAddress string, userAgent *string, userID *model.User) {
+func (s *AuditService) LogSecurityEvent(ctx context.Context, action, details, ipAddress string, userAgent *string, userID *model.User) {
 	event := model.CreateAuditEvent(model.EventTypeSecurity, action, "security")
 	event.SetClientInfo(ipAddress, userAgent)
 	event.SetDetails(details)
@@ -125,13 +145,99 @@ func (s *AuditService) LogSecurityEvent(action, details, ipAddress string, userA
 		event.SetUser(userID.ID)
 	}
 
-	s.LogEvent(event)
+	s.LogEvent(ctx, event)
 }
 
-// Close closes the audit service and its resources
-func (s *AuditService) Close() {
+// auditAttrs returns the log attributes of an audit event
+func auditAttrs(event *model.AuditEvent) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("audit_id", event.ID.String()),
+		slog.String("event_type", event.EventType),
+		slog.String("event_action", event.EventAction),
+		slog.String("resource", event.Resource),
+		slog.Bool("success", event.Success),
+	}
+	if event.UserID != nil {
+		attrs = append(attrs, slog.String("event_user_id", event.UserID.String()))
+	}
+	if event.ResourceID != nil {
+		attrs = append(attrs, slog.String("resource_id", *event.ResourceID))
+	}
+	if event.IPAddress != "" {
+		attrs = append(attrs, slog.String("ip_address", event.IPAddress))
+	}
+	if event.Details != nil {
+		attrs = append(attrs, slog.String("details", *event.Details))
+	}
+	if event.ErrorMsg != nil {
+		attrs = append(attrs, slog.String("error", *event.ErrorMsg))
+	}
+	return attrs
+}
+
+// Flush writes buffered audit entries to the log file and syncs it to disk
+func (s *AuditService) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writer == nil {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush audit log: %w", err)
+	}
+	return s.logFile.Sync()
+}
+
+// Shutdown stops the periodic flush, flushes remaining entries and closes
+// the log file. Events logged afterwards are only written to the log.
+func (s *AuditService) Shutdown(ctx context.Context) error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		close(s.stop)
+	}
+
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	err := s.Flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.logFile != nil {
 		s.logFile.Close()
+		s.logFile = nil
+		s.writer = nil
+	}
+	return err
+}
+
+// Close closes the audit service and its resources
+func (s *AuditService) Close() {
+	s.Shutdown(context.Background())
+}
+
+// flushLoop periodically flushes buffered entries until Shutdown
+func (s *AuditService) flushLoop() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				slog.Error("Failed to flush audit log", "error", err)
+			}
+		}
 	}
 }
 
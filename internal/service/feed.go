@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/tracing"
+
+	"github.com/google/uuid"
+)
+
+// feedSize is the number of most recent notes in a feed
+const feedSize = 50
+
+// FeedService builds feeds of public notes
+type FeedService struct {
+	noteRepo repository.NoteRepository
+	userRepo repository.UserRepository
+}
+
+// NewFeedService creates a new feed service
+func NewFeedService(noteRepo repository.NoteRepository, userRepo repository.UserRepository) *FeedService {
+	return &FeedService{
+		noteRepo: noteRepo,
+		userRepo: userRepo,
+	}
+}
+
+// PublicFeed returns the most recent public notes, limited to tag unless it is empty
+func (s *FeedService) PublicFeed(ctx context.Context, tag string) (*model.Feed, error) {
+	ctx, span := tracing.Start(ctx, "FeedService.PublicFeed")
+	defer span.End()
+
+	title := "Public notes"
+	if tag != "" {
+		title = fmt.Sprintf("Public notes tagged %s", tag)
+	}
+	return s.build(ctx, title, nil, tag)
+}
+
+// UserFeed returns the most recent public notes of userID, limited to tag
+// unless it is empty. Users who opted out have no feed.
+func (s *FeedService) UserFeed(ctx context.Context, userID uuid.UUID, tag string) (*model.Feed, error) {
+	ctx, span := tracing.Start(ctx, "FeedService.UserFeed", tracing.UserID(userID))
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || user.FeedOptOut {
+		return nil, fmt.Errorf("feed not found")
+	}
+
+	title := fmt.Sprintf("Public notes by %s", user.FullName)
+	if tag != "" {
+		title = fmt.Sprintf("Public notes by %s tagged %s", user.FullName, tag)
+	}
+	feed, err := s.build(ctx, title, user, tag)
+	if err != nil {
+		return nil, err
+	}
+	feed.Author = user.FullName
+	return feed, nil
+}
+
+// build reads the newest public notes, of author if set, into a feed
+func (s *FeedService) build(ctx context.Context, title string, author *model.User, tag string) (*model.Feed, error) {
+	params := &model.GetNotesParams{
+		Page:     1,
+		PageSize: feedSize,
+		Tags:     strings.TrimSpace(tag),
+		SortBy:   "created_at",
+		SortDir:  "desc",
+	}
+	if author != nil {
+		params.AuthorID = &author.ID
+	}
+
+	notes, _, err := s.noteRepo.GetPublicNotes(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public notes: %w", err)
+	}
+
+	// Author names, looked up in one query
+	names := make(map[uuid.UUID]string)
+	if author != nil {
+		names[author.ID] = author.FullName
+	} else {
+		ids := make([]uuid.UUID, 0, len(notes))
+		for _, note := range notes {
+			if _, ok := names[note.UserID]; !ok {
+				names[note.UserID] = ""
+				ids = append(ids, note.UserID)
+			}
+		}
+		users, err := s.userRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get authors: %w", err)
+		}
+		for _, user := range users {
+			names[user.ID] = user.FullName
+		}
+	}
+
+	feed := &model.Feed{Title: title, Entries: make([]model.FeedEntry, 0, len(notes))}
+	for _, note := range notes {
+		entry := model.FeedEntry{
+			ID:        note.ID,
+			Title:     note.Title,
+			Tags:      note.GetTagsArray(),
+			Author:    names[note.UserID],
+			Published: note.CreatedAt,
+			Updated:   note.UpdatedAt,
+		}
+		if note.Content != nil {
+			entry.Content = *note.Content
+		}
+		feed.Entries = append(feed.Entries, entry)
+
+		if note.UpdatedAt.After(feed.Updated) {
+			feed.Updated = note.UpdatedAt
+		}
+	}
+
+	return feed, nil
+}
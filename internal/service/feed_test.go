@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestFeedService(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := repository.NewMemoryNoteRepository()
+	userRepo := repository.NewMemoryUserRepository()
+	feeds := NewFeedService(noteRepo, userRepo)
+	users := NewUserService(userRepo)
+
+	alice := &model.User{ID: uuid.New(), Email: "alice@example.com", Password: "hash", FullName: "Alice", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	bob := &model.User{ID: uuid.New(), Email: "bob@example.com", Password: "hash", FullName: "Bob", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	userRepo.Create(ctx, alice)
+	userRepo.Create(ctx, bob)
+
+	notes := []*model.Note{
+		{ID: uuid.New(), UserID: alice.ID, Title: "Alice on Go", Tags: stringPtr("go"), IsPublic: true, Status: model.NoteStatusActive},
+		{ID: uuid.New(), UserID: alice.ID, Title: "Alice private", IsPublic: false, Status: model.NoteStatusActive},
+		{ID: uuid.New(), UserID: bob.ID, Title: "Bob on Rust", Tags: stringPtr("rust"), IsPublic: true, Status: model.NoteStatusActive},
+	}
+	for _, note := range notes {
+		noteRepo.Create(ctx, note)
+	}
+
+	tests := []struct {
+		name   string
+		feed   func() (*model.Feed, error)
+		titles []string
+		author string
+	}{
+		{name: "public", feed: func() (*model.Feed, error) { return feeds.PublicFeed(ctx, "") }, titles: []string{"Alice on Go", "Bob on Rust"}},
+		{name: "tag", feed: func() (*model.Feed, error) { return feeds.PublicFeed(ctx, "rust") }, titles: []string{"Bob on Rust"}},
+		{name: "user", feed: func() (*model.Feed, error) { return feeds.UserFeed(ctx, alice.ID, "") }, titles: []string{"Alice on Go"}, author: "Alice"},
+		{name: "user and tag", feed: func() (*model.Feed, error) { return feeds.UserFeed(ctx, bob.ID, "go") }, author: "Bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := tt.feed()
+			if err != nil {
+				t.Fatalf("feed error = %v", err)
+			}
+			if feed.Author != tt.author {
+				t.Errorf("feed author = %q, want %q", feed.Author, tt.author)
+			}
+			got := make(map[string]bool)
+			for _, entry := range feed.Entries {
+				got[entry.Title] = true
+				if entry.Author == "" {
+					t.Errorf("entry %q has no author", entry.Title)
+				}
+			}
+			if len(got) != len(tt.titles) {
+				t.Fatalf("feed entries = %v, want %v", got, tt.titles)
+			}
+			for _, title := range tt.titles {
+				if !got[title] {
+					t.Errorf("feed is missing %q", title)
+				}
+			}
+		})
+	}
+
+	// Opting out hides the user's feed but not their notes in other feeds
+	disabled := false
+	if _, err := users.UpdateSettings(ctx, alice.ID, &model.UpdateSettingsRequest{FeedEnabled: &disabled}); err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+	if _, err := feeds.UserFeed(ctx, alice.ID, ""); err == nil || err.Error() != "feed not found" {
+		t.Errorf("UserFeed() after opt-out error = %v, want feed not found", err)
+	}
+	if feed, _ := feeds.PublicFeed(ctx, "go"); len(feed.Entries) != 1 {
+		t.Errorf("PublicFeed() after opt-out has %d entries, want 1", len(feed.Entries))
+	}
+	if _, err := feeds.UserFeed(ctx, uuid.New(), ""); err == nil || err.Error() != "feed not found" {
+		t.Errorf("UserFeed() of unknown user error = %v, want feed not found", err)
+	}
+
+	settings, err := users.GetSettings(ctx, alice.ID)
+	if err != nil || settings.FeedEnabled {
+		t.Errorf("GetSettings() = %+v, %v, want feed disabled", settings, err)
+	}
+}
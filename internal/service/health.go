@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"gonotes/internal/lifecycle"
+	"gonotes/internal/migrate"
+	"gonotes/internal/model"
+	"gonotes/internal/store"
+)
+
+// healthCheckTimeout bounds each dependency check
+const healthCheckTimeout = 2 * time.Second
+
+// HealthService checks the service dependencies for probes and diagnostics
+type HealthService struct {
+	db            *sql.DB
+	dbDriver      string
+	kv            store.Store
+	migrator      *migrate.Migrator
+	lifecycle     *lifecycle.Manager
+	redisRequired bool
+	startedAt     time.Time
+}
+
+// NewHealthService creates a new health service. When redisRequired is false
+// a Redis outage is reported as degraded since the store falls back to memory.
+// The database check is named after dbDriver; a nil db (demo mode) skips the
+// database and migration checks.
+func NewHealthService(db *sql.DB, dbDriver string, kv store.Store, migrator *migrate.Migrator, lc *lifecycle.Manager, redisRequired bool) *HealthService {
+	return &HealthService{
+		db:            db,
+		dbDriver:      dbDriver,
+		kv:            kv,
+		migrator:      migrator,
+		lifecycle:     lc,
+		redisRequired: redisRequired,
+		startedAt:     time.Now(),
+	}
+}
+
+// Check runs all dependency checks concurrently
+func (s *HealthService) Check(ctx context.Context) *model.HealthReport {
+	checks := map[string]func(context.Context) *model.HealthCheck{
+		"redis": s.checkStore,
+	}
+	if s.db != nil {
+		checks[s.dbDriver] = s.checkDatabase
+		checks["migrations"] = s.checkMigrations
+	}
+
+	report := &model.HealthReport{
+		ShuttingDown: s.lifecycle.ShuttingDown(),
+		Uptime:       time.Since(s.startedAt).Round(time.Second).String(),
+		Timestamp:    time.Now(),
+		Checks:       make(map[string]*model.HealthCheck, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) *model.HealthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check(checkCtx)
+			result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+			mu.Lock()
+			report.Checks[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	report.Status = model.HealthStatusHealthy
+	for _, check := range report.Checks {
+		if check.Status != model.HealthStatusUp {
+			report.Status = model.HealthStatusDegraded
+		}
+	}
+	if !report.Ready() {
+		report.Status = model.HealthStatusUnhealthy
+	}
+
+	return report
+}
+
+// checkDatabase pings the database and reports connection pool statistics
+func (s *HealthService) checkDatabase(ctx context.Context) *model.HealthCheck {
+	check := &model.HealthCheck{Status: model.HealthStatusUp, Required: true}
+	if err := s.db.PingContext(ctx); err != nil {
+		check.Status = model.HealthStatusDown
+		check.Error = err.Error()
+	}
+
+	stats := s.db.Stats()
+	check.Details = &model.DatabasePoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMS:     float64(stats.WaitDuration.Microseconds()) / 1000,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+	return check
+}
+
+// checkStore pings the key-value store and reports Redis pool statistics
+func (s *HealthService) checkStore(ctx context.Context) *model.HealthCheck {
+	check := &model.HealthCheck{Status: model.HealthStatusUp, Required: s.redisRequired}
+	if provider, ok := s.kv.(store.PoolStatsProvider); ok {
+		if stats := provider.PoolStats(); stats != nil {
+			check.Details = stats
+		}
+	}
+
+	if err := s.kv.Ping(ctx); err != nil {
+		check.Error = err.Error()
+		check.Status = model.HealthStatusDown
+		if !s.redisRequired {
+			check.Status = model.HealthStatusDegraded
+		}
+		return check
+	}
+
+	if failover, ok := s.kv.(interface{ Degraded() bool }); ok && failover.Degraded() {
+		check.Status = model.HealthStatusDegraded
+		check.Error = "serving from in-memory fallback"
+	}
+	return check
+}
+
+// checkMigrations compares the applied schema version with the embedded migrations.
+// A newer schema is accepted so older replicas keep serving during a rollout.
+func (s *HealthService) checkMigrations(ctx context.Context) *model.HealthCheck {
+	check := &model.HealthCheck{Status: model.HealthStatusUp, Required: true}
+
+	version, err := s.migrator.Version(ctx)
+	if err != nil {
+		check.Status = model.HealthStatusDown
+		check.Error = err.Error()
+		return check
+	}
+
+	expected := s.migrator.Latest()
+	check.Details = &model.MigrationStatus{Version: version, Expected: expected}
+	if version < expected {
+		check.Status = model.HealthStatusDown
+		check.Error = fmt.Sprintf("schema at version %d, expected %d", version, expected)
+	}
+	return check
+}
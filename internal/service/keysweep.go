@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"gonotes/internal/store"
+)
+
+// volatileKeyPatterns match the store keys that are always written with an
+// expiration. Such a key found without one was left behind, e.g. by an
+// older release or a write cut short, and would otherwise never go away.
+var volatileKeyPatterns = []string{
+	"refresh_token:*",
+	"profile:*",
+	"note:*",
+	"notes:public:*:*", // listing pages, not the generation counter
+	"views:*",
+	"viewers:*",
+	"read_primary:*",
+	"rate_limit:*",
+	"ddos_protection:*",
+	"jobs:claim:*",
+}
+
+// StaleKeySweeper deletes volatile keys that lost their expiration
+type StaleKeySweeper struct {
+	store store.Store
+}
+
+// NewStaleKeySweeper creates a sweeper for st
+func NewStaleKeySweeper(st store.Store) *StaleKeySweeper {
+	return &StaleKeySweeper{store: st}
+}
+
+// Sweep deletes the stale keys of every volatile pattern. Stores that
+// cannot sweep, such as the in-memory store whose janitor evicts expired
+// keys, are left alone.
+func (s *StaleKeySweeper) Sweep(ctx context.Context) error {
+	sweeper, ok := s.store.(store.KeySweeper)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	total := 0
+	for _, pattern := range volatileKeyPatterns {
+		deleted, err := sweeper.SweepPersistent(ctx, pattern)
+		total += deleted
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pattern, err))
+		}
+	}
+	if total > 0 {
+		slog.InfoContext(ctx, "Swept stale keys", "keys", total)
+	}
+	return errors.Join(errs...)
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"gonotes/internal/metrics"
 	"gonotes/internal/model"
 	"gonotes/internal/repository"
+	"gonotes/internal/tracing"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
@@ -13,29 +17,44 @@ import (
 
 // NoteService handles business logic for notes
 type NoteService struct {
-	noteRepo  *repository.NoteRepository
-	userRepo  *repository.UserRepository
+	noteRepo  repository.NoteRepository
+	userRepo  repository.UserRepository
+	tx        repository.TxManager
+	views     *ViewCounter
 	validator *utils.Validator
+	cache     *NoteCache
 }
 
 // NewNoteService creates a new note service
-func NewNoteService(noteRepo *repository.NoteRepository, userRepo *repository.UserRepository, validator *utils.Validator) *NoteService {
+func NewNoteService(noteRepo repository.NoteRepository, userRepo repository.UserRepository, tx repository.TxManager, views *ViewCounter, validator *utils.Validator) *NoteService {
 	return &NoteService{
 		noteRepo:  noteRepo,
 		userRepo:  userRepo,
+		tx:        tx,
+		views:     views,
 		validator: validator,
 	}
 }
 
+// NewNoteServiceWithCache creates a new note service that caches single notes and public listings
+func NewNoteServiceWithCache(noteRepo repository.NoteRepository, userRepo repository.UserRepository, tx repository.TxManager, views *ViewCounter, validator *utils.Validator, cache *NoteCache) *NoteService {
+	s := NewNoteService(noteRepo, userRepo, tx, views, validator)
+	s.cache = cache
+	return s
+}
+
 // CreateNote creates a new note
-func (s *NoteService) CreateNote(userID uuid.UUID, req *model.CreateNoteRequest) (*model.NoteResponse, error) {
+func (s *NoteService) CreateNote(ctx context.Context, userID uuid.UUID, req *model.CreateNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.CreateNote", tracing.UserID(userID))
+	defer span.End()
+
 	// Validate request
 	if err := s.validator.ValidateStruct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
 	// Verify user exists
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify user: %w", err)
 	}
@@ -52,18 +71,24 @@ func (s *NoteService) CreateNote(userID uuid.UUID, req *model.CreateNoteRequest)
 	}
 
 	// Create note in database
-	if err := s.noteRepo.Create(note); err != nil {
+	if err := s.noteRepo.Create(ctx, note); err != nil {
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
+	metrics.NotesCreated.Inc()
+	s.invalidateCache(ctx, note.IsPublic, note.ID)
 
 	// Return response
 	return note.ToResponse(), nil
 }
 
-// GetNoteByID retrieves a note by ID with security checks
-func (s *NoteService) GetNoteByID(noteID, userID uuid.UUID) (*model.NoteResponse, error) {
-	// Get note from database
-	note, err := s.noteRepo.GetByID(noteID)
+// GetNoteByID retrieves a note by ID with security checks. referrer is the
+// Referer header of the request, used in view analytics.
+func (s *NoteService) GetNoteByID(ctx context.Context, noteID, userID uuid.UUID, referrer string) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNoteByID", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	// Get note from cache or database
+	note, err := s.loadNote(ctx, noteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
@@ -76,20 +101,52 @@ func (s *NoteService) GetNoteByID(noteID, userID uuid.UUID) (*model.NoteResponse
 		return nil, fmt.Errorf("access denied")
 	}
 
-	// Increment view count if it's not the owner viewing
+	// Count the view if it's not the owner viewing
 	if note.UserID != userID {
-		if err := s.noteRepo.IncrementViewCount(noteID); err != nil {
-			// Log error but don't fail the request
-			// In production, use proper logging
-			fmt.Printf("Failed to increment view count: %v\n", err)
-		}
+		s.views.Record(ctx, noteID, "user:"+userID.String(), referrer)
 	}
 
 	return note.ToResponse(), nil
 }
 
+// GetPublicNote retrieves an active public note for anyone, counting the
+// view unless the author is viewing. userID is uuid.Nil for anonymous
+// readers, who are told apart by clientIP.
+func (s *NoteService) GetPublicNote(ctx context.Context, noteID, userID uuid.UUID, clientIP, referrer string) (*model.PublicNoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetPublicNote", tracing.NoteID(noteID))
+	defer span.End()
+
+	note, err := s.loadNote(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	// Private notes are reported as missing so their IDs cannot be probed
+	if note == nil || !note.IsPublic || note.Status != model.NoteStatusActive {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	author, err := s.userRepo.GetByID(ctx, note.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author: %w", err)
+	}
+	if author == nil {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	if userID == uuid.Nil {
+		s.views.Record(ctx, noteID, "ip:"+clientIP, referrer)
+	} else if userID != note.UserID {
+		s.views.Record(ctx, noteID, "user:"+userID.String(), referrer)
+	}
+
+	return note.ToPublicResponse(author), nil
+}
+
 // GetUserNotes retrieves notes for a user with pagination and filtering
-func (s *NoteService) GetUserNotes(userID uuid.UUID, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+func (s *NoteService) GetUserNotes(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetUserNotes", tracing.UserID(userID))
+	defer span.End()
+
 	// Validate parameters
 	if err := s.validator.ValidateStruct(params); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
@@ -99,7 +156,7 @@ func (s *NoteService) GetUserNotes(userID uuid.UUID, params *model.GetNotesParam
 	params.SetDefaults()
 
 	// Get notes from repository
-	notes, total, err := s.noteRepo.GetByUserID(userID, params)
+	notes, total, err := s.noteRepo.GetByUserID(ctx, userID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user notes: %w", err)
 	}
@@ -110,14 +167,17 @@ func (s *NoteService) GetUserNotes(userID uuid.UUID, params *model.GetNotesParam
 }
 
 // UpdateNote updates an existing note
-func (s *NoteService) UpdateNote(noteID, userID uuid.UUID, req *model.UpdateNoteRequest) (*model.NoteResponse, error) {
+func (s *NoteService) UpdateNote(ctx context.Context, noteID, userID uuid.UUID, req *model.UpdateNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.UpdateNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
 	// Validate request
 	if err := s.validator.ValidateStruct(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
 	// Get existing note
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
@@ -131,6 +191,7 @@ func (s *NoteService) UpdateNote(noteID, userID uuid.UUID, req *model.UpdateNote
 	}
 
 	// Apply updates
+	wasPublic := note.IsPublic
 	req.ApplyToNote(note)
 
 	// Validate updated content
@@ -139,17 +200,21 @@ func (s *NoteService) UpdateNote(noteID, userID uuid.UUID, req *model.UpdateNote
 	}
 
 	// Update in database
-	if err := s.noteRepo.Update(note); err != nil {
+	if err := s.noteRepo.Update(ctx, note); err != nil {
 		return nil, fmt.Errorf("failed to update note: %w", err)
 	}
+	s.invalidateCache(ctx, wasPublic || note.IsPublic, noteID)
 
 	return note.ToResponse(), nil
 }
 
 // DeleteNote soft deletes a note
-func (s *NoteService) DeleteNote(noteID, userID uuid.UUID) error {
+func (s *NoteService) DeleteNote(ctx context.Context, noteID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "NoteService.DeleteNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
 	// Check if note exists and user has permission
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
@@ -163,47 +228,62 @@ func (s *NoteService) DeleteNote(noteID, userID uuid.UUID) error {
 	}
 
 	// Soft delete the note
-	if err := s.noteRepo.Delete(noteID, userID); err != nil {
+	if err := s.noteRepo.Delete(ctx, noteID, userID); err != nil {
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
+	s.invalidateCache(ctx, note.IsPublic, noteID)
 
 	return nil
 }
 
 // RestoreNote restores a soft-deleted note
-func (s *NoteService) RestoreNote(noteID, userID uuid.UUID) (*model.NoteResponse, error) {
-	// Check if note exists and user has permission
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get note: %w", err)
-	}
-	if note == nil {
-		return nil, fmt.Errorf("note not found")
-	}
+func (s *NoteService) RestoreNote(ctx context.Context, noteID, userID uuid.UUID) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.RestoreNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	var restoredNote *model.Note
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Check if note exists and user has permission
+		note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get note: %w", err)
+		}
+		if note == nil {
+			return fmt.Errorf("note not found")
+		}
 
-	// Check if note is actually deleted
-	if !note.IsDeleted() {
-		return nil, fmt.Errorf("note is not deleted")
-	}
+		// Check if note is actually deleted
+		if !note.IsDeleted() {
+			return fmt.Errorf("note is not deleted")
+		}
 
-	// Restore the note
-	if err := s.noteRepo.Restore(noteID, userID); err != nil {
-		return nil, fmt.Errorf("failed to restore note: %w", err)
-	}
+		// Restore the note
+		if err := s.noteRepo.Restore(ctx, noteID, userID); err != nil {
+			return fmt.Errorf("failed to restore note: %w", err)
+		}
 
-	// Get updated note
-	restoredNote, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+		// Get updated note
+		restoredNote, err = s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get restored note: %w", err)
+		}
+		s.invalidateCache(ctx, note.IsPublic, noteID)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get restored note: %w", err)
+		return nil, err
 	}
 
 	return restoredNote.ToResponse(), nil
 }
 
 // HardDeleteNote permanently deletes a note
-func (s *NoteService) HardDeleteNote(noteID, userID uuid.UUID) error {
+func (s *NoteService) HardDeleteNote(ctx context.Context, noteID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "NoteService.HardDeleteNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
 	// Check if note exists and user has permission
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
@@ -212,15 +292,19 @@ func (s *NoteService) HardDeleteNote(noteID, userID uuid.UUID) error {
 	}
 
 	// Hard delete the note
-	if err := s.noteRepo.HardDelete(noteID, userID); err != nil {
+	if err := s.noteRepo.HardDelete(ctx, noteID, userID); err != nil {
 		return fmt.Errorf("failed to hard delete note: %w", err)
 	}
+	s.invalidateCache(ctx, note.IsPublic, noteID)
 
 	return nil
 }
 
 // SearchNotes performs advanced search
-func (s *NoteService) SearchNotes(userID uuid.UUID, req *model.NoteSearchRequest) (*model.NotesListResponse, error) {
+func (s *NoteService) SearchNotes(ctx context.Context, userID uuid.UUID, req *model.NoteSearchRequest) (*model.NotesListResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.SearchNotes", tracing.UserID(userID))
+	defer span.End()
+
 	// Custom validation: ensure at least one search criteria is provided
 	if req.Query == "" && req.IsPublic == nil && len(req.Tags) == 0 && req.DateFrom == nil && req.DateTo == nil {
 		return nil, fmt.Errorf("validation error: at least one search criteria must be provided (query, is_public, tags, or date range)")
@@ -235,7 +319,7 @@ func (s *NoteService) SearchNotes(userID uuid.UUID, req *model.NoteSearchRequest
 	req.SetDefaults()
 
 	// Perform search
-	notes, total, err := s.noteRepo.Search(userID, req)
+	notes, total, err := s.noteRepo.Search(ctx, userID, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search notes: %w", err)
 	}
@@ -254,7 +338,10 @@ func (s *NoteService) SearchNotes(userID uuid.UUID, req *model.NoteSearchRequest
 }
 
 // GetPublicNotes retrieves public notes (accessible to all users)
-func (s *NoteService) GetPublicNotes(params *model.GetNotesParams) (*model.NotesListResponse, error) {
+func (s *NoteService) GetPublicNotes(ctx context.Context, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetPublicNotes")
+	defer span.End()
+
 	// Validate parameters
 	if err := s.validator.ValidateStruct(params); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
@@ -263,19 +350,61 @@ func (s *NoteService) GetPublicNotes(params *model.GetNotesParams) (*model.Notes
 	// Set defaults
 	params.SetDefaults()
 
-	// Get public notes
-	notes, total, err := s.noteRepo.GetPublicNotes(params)
+	if s.cache != nil {
+		return s.cache.PublicNotes(ctx, params, func(ctx context.Context) (*model.NotesListResponse, error) {
+			return s.loadPublicNotes(ctx, params)
+		})
+	}
+	return s.loadPublicNotes(ctx, params)
+}
+
+// loadPublicNotes reads a page of public notes from the repository
+func (s *NoteService) loadPublicNotes(ctx context.Context, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+	notes, total, err := s.noteRepo.GetPublicNotes(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public notes: %w", err)
 	}
 
 	// Convert to response
 	response := model.NewNotesListResponse(notes, total, params)
+	if err := s.addAuthors(ctx, notes, response.Notes); err != nil {
+		return nil, err
+	}
 	return response, nil
 }
 
+// addAuthors credits each list item to the author of the note at the same
+// index
+func (s *NoteService) addAuthors(ctx context.Context, notes []model.Note, items []model.NoteListItem) error {
+	seen := make(map[uuid.UUID]struct{}, len(notes))
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		if _, ok := seen[note.UserID]; !ok {
+			seen[note.UserID] = struct{}{}
+			ids = append(ids, note.UserID)
+		}
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get authors: %w", err)
+	}
+	authors := make(map[uuid.UUID]*model.NoteAuthor, len(users))
+	for i := range users {
+		authors[users[i].ID] = users[i].AsAuthor()
+	}
+
+	for i, note := range notes {
+		items[i].Author = authors[note.UserID]
+	}
+	return nil
+}
+
 // BulkUpdateNotesStatus updates status for multiple notes
-func (s *NoteService) BulkUpdateNotesStatus(userID uuid.UUID, req *model.BulkOperationRequest) error {
+func (s *NoteService) BulkUpdateNotesStatus(ctx context.Context, userID uuid.UUID, req *model.BulkOperationRequest) error {
+	ctx, span := tracing.Start(ctx, "NoteService.BulkUpdateNotesStatus", tracing.UserID(userID))
+	defer span.End()
+
 	// Validate request
 	if err := s.validator.ValidateStruct(req); err != nil {
 		return fmt.Errorf("validation error: %w", err)
@@ -302,28 +431,78 @@ func (s *NoteService) BulkUpdateNotesStatus(userID uuid.UUID, req *model.BulkOpe
 		return fmt.Errorf("invalid status: %s", statusStr)
 	}
 
-	// Perform bulk update
-	if err := s.noteRepo.BulkUpdateStatus(userID, req.NoteIDs, status); err != nil {
-		return fmt.Errorf("failed to bulk update status: %w", err)
+	// Perform bulk update; either every note is updated or none is
+	unique := make(map[uuid.UUID]struct{}, len(req.NoteIDs))
+	for _, id := range req.NoteIDs {
+		unique[id] = struct{}{}
 	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		updated, err := s.noteRepo.BulkUpdateStatus(ctx, userID, req.NoteIDs, status)
+		if err != nil {
+			return fmt.Errorf("failed to bulk update status: %w", err)
+		}
+		if updated != int64(len(unique)) {
+			return fmt.Errorf("one or more notes not found")
+		}
 
-	return nil
+		// Whether the notes were public is unknown here, so listings are dropped too
+		noteIDs := make([]uuid.UUID, 0, len(unique))
+		for id := range unique {
+			noteIDs = append(noteIDs, id)
+		}
+		s.invalidateCache(ctx, true, noteIDs...)
+		return nil
+	})
 }
 
 // GetNoteStats returns statistics for user's notes
-func (s *NoteService) GetNoteStats(userID uuid.UUID) (map[string]interface{}, error) {
+func (s *NoteService) GetNoteStats(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNoteStats", tracing.UserID(userID))
+	defer span.End()
+
 	// Get stats from repository
-	stats, err := s.noteRepo.GetNoteStats(userID)
+	stats, err := s.noteRepo.GetNoteStats(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note stats: %w", err)
 	}
 
+	// Add views of the user's public notes over the last 30 days
+	summary, err := s.views.Summary(ctx, userID, defaultAnalyticsDays)
+	if err != nil {
+		return nil, err
+	}
+	stats["public_views"] = summary
+
 	return stats, nil
 }
 
+// GetNoteAnalytics returns the daily views and referrers of a note over the
+// last days days. Only the owner can see them.
+func (s *NoteService) GetNoteAnalytics(ctx context.Context, noteID, userID uuid.UUID, days int) (*model.ViewAnalytics, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNoteAnalytics", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	if days < 1 || days > maxAnalyticsDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", maxAnalyticsDays)
+	}
+
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	if note == nil {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	return s.views.Analytics(ctx, noteID, days)
+}
+
 // ValidateNoteOwnership validates that a user owns a note
-func (s *NoteService) ValidateNoteOwnership(noteID, userID uuid.UUID) error {
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+func (s *NoteService) ValidateNoteOwnership(ctx context.Context, noteID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "NoteService.ValidateNoteOwnership", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
@@ -334,9 +513,12 @@ func (s *NoteService) ValidateNoteOwnership(noteID, userID uuid.UUID) error {
 }
 
 // DuplicateNote creates a copy of an existing note
-func (s *NoteService) DuplicateNote(noteID, userID uuid.UUID) (*model.NoteResponse, error) {
+func (s *NoteService) DuplicateNote(ctx context.Context, noteID, userID uuid.UUID) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.DuplicateNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
 	// Get original note
-	originalNote, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+	originalNote, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
@@ -357,13 +539,31 @@ func (s *NoteService) DuplicateNote(noteID, userID uuid.UUID) (*model.NoteRespon
 	}
 
 	// Create in database
-	if err := s.noteRepo.Create(duplicateNote); err != nil {
+	if err := s.noteRepo.Create(ctx, duplicateNote); err != nil {
 		return nil, fmt.Errorf("failed to create duplicate note: %w", err)
 	}
+	metrics.NotesCreated.Inc()
 
 	return duplicateNote.ToResponse(), nil
 }
 
+// invalidateCache drops cached copies of the notes once the unit of work in
+// ctx commits, and the public listings too when public is set
+func (s *NoteService) invalidateCache(ctx context.Context, public bool, noteIDs ...uuid.UUID) {
+	if s.cache == nil {
+		return
+	}
+
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		for _, id := range noteIDs {
+			s.cache.InvalidateNote(ctx, id)
+		}
+		if public {
+			s.cache.InvalidatePublicNotes(ctx)
+		}
+	})
+}
+
 // validateNoteContent validates and sanitizes note content
 func (s *NoteService) validateNoteContent(note *model.Note) error {
 	// Trim whitespace
@@ -400,6 +600,17 @@ func (s *NoteService) validateNoteContent(note *model.Note) error {
 	return nil
 }
 
+// loadNote returns the note with noteID from the cache or the database
+func (s *NoteService) loadNote(ctx context.Context, noteID uuid.UUID) (*model.Note, error) {
+	load := func(ctx context.Context) (*model.Note, error) {
+		return s.noteRepo.GetByID(ctx, noteID)
+	}
+	if s.cache != nil {
+		return s.cache.Note(ctx, noteID, load)
+	}
+	return load(ctx)
+}
+
 // canUserAccessNote checks if a user can access a note
 func (s *NoteService) canUserAccessNote(note *model.Note, userID uuid.UUID) bool {
 	// Owner can always access
@@ -417,7 +628,10 @@ func (s *NoteService) canUserAccessNote(note *model.Note, userID uuid.UUID) bool
 }
 
 // GetNotesByTag retrieves notes by tag
-func (s *NoteService) GetNotesByTag(userID uuid.UUID, tag string, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+func (s *NoteService) GetNotesByTag(ctx context.Context, userID uuid.UUID, tag string, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNotesByTag", tracing.UserID(userID))
+	defer span.End()
+
 	// Validate tag
 	if tag == "" {
 		return nil, fmt.Errorf("tag cannot be empty")
@@ -427,18 +641,21 @@ func (s *NoteService) GetNotesByTag(userID uuid.UUID, tag string, params *model.
 	params.Tags = tag
 
 	// Get notes
-	return s.GetUserNotes(userID, params)
+	return s.GetUserNotes(ctx, userID, params)
 }
 
 // GetAllUserTags retrieves all unique tags for a user
-func (s *NoteService) GetAllUserTags(userID uuid.UUID) ([]string, error) {
+func (s *NoteService) GetAllUserTags(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetAllUserTags", tracing.UserID(userID))
+	defer span.End()
+
 	// Get all active notes for user
 	params := &model.GetNotesParams{
 		Status:   "active",
 		PageSize: 1000, // Large page size to get all notes
 	}
 
-	notes, _, err := s.noteRepo.GetByUserID(userID, params)
+	notes, _, err := s.noteRepo.GetByUserID(ctx, userID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user notes: %w", err)
 	}
@@ -464,9 +681,12 @@ func (s *NoteService) GetAllUserTags(userID uuid.UUID) ([]string, error) {
 }
 
 // ToggleNotePublicStatus toggles the public status of a note
-func (s *NoteService) ToggleNotePublicStatus(noteID, userID uuid.UUID) (*model.NoteResponse, error) {
+func (s *NoteService) ToggleNotePublicStatus(ctx context.Context, noteID, userID uuid.UUID) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.ToggleNotePublicStatus", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
 	// Get note
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
@@ -483,9 +703,81 @@ func (s *NoteService) ToggleNotePublicStatus(noteID, userID uuid.UUID) (*model.N
 	note.IsPublic = !note.IsPublic
 
 	// Update in database
-	if err := s.noteRepo.Update(note); err != nil {
+	if err := s.noteRepo.Update(ctx, note); err != nil {
 		return nil, fmt.Errorf("failed to update note: %w", err)
 	}
+	s.invalidateCache(ctx, true, noteID)
 
 	return note.ToResponse(), nil
 }
+
+// ScheduleNote sets when a note is published and unpublished, replacing its
+// schedule. Like ToggleNotePublicStatus only active notes can be scheduled,
+// except that drafts may be given a publish_at, which activates them.
+func (s *NoteService) ScheduleNote(ctx context.Context, noteID, userID uuid.UUID, req *model.ScheduleNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.ScheduleNote", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	now := time.Now()
+	if req.PublishAt != nil && !req.PublishAt.After(now) {
+		return nil, fmt.Errorf("validation error: publish_at must be in the future")
+	}
+	if req.UnpublishAt != nil && !req.UnpublishAt.After(now) {
+		return nil, fmt.Errorf("validation error: unpublish_at must be in the future")
+	}
+	if req.PublishAt != nil && req.UnpublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+		return nil, fmt.Errorf("validation error: unpublish_at must be after publish_at")
+	}
+
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	if note == nil {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	scheduled := req.PublishAt != nil || req.UnpublishAt != nil
+	if scheduled && note.Status != model.NoteStatusActive &&
+		!(note.Status == model.NoteStatusDraft && req.PublishAt != nil) {
+		return nil, fmt.Errorf("only active notes can be scheduled")
+	}
+
+	note.PublishAt = req.PublishAt
+	note.UnpublishAt = req.UnpublishAt
+
+	if err := s.noteRepo.Update(ctx, note); err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	s.invalidateCache(ctx, false, noteID)
+
+	return note.ToResponse(), nil
+}
+
+// ApplySchedules publishes and unpublishes the notes whose scheduled time
+// has passed by now and returns how many changed. Publishing runs first, so
+// a note whose whole schedule has passed ends up private.
+func (s *NoteService) ApplySchedules(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.ApplySchedules")
+	defer span.End()
+
+	published, err := s.noteRepo.PublishDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	// Invalidate as soon as possible so a failure below does not hide the
+	// notes already published
+	if len(published) > 0 {
+		s.invalidateCache(ctx, true, published...)
+	}
+
+	unpublished, err := s.noteRepo.UnpublishDue(ctx, now)
+	if err != nil {
+		return len(published), err
+	}
+	if len(unpublished) > 0 {
+		s.invalidateCache(ctx, true, unpublished...)
+	}
+
+	return len(published) + len(unpublished), nil
+}
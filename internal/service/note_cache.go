@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache names used in metrics
+const (
+	noteCacheName        = "note"
+	publicNotesCacheName = "public_notes"
+)
+
+// publicNotesGenerationKey holds the current generation of public listing pages
+const publicNotesGenerationKey = "notes:public:gen"
+
+// NoteCache is a read-through cache for single notes and public listing
+// pages. Listing keys include a generation that is bumped whenever a public
+// note changes, which invalidates every cached page at once; pages of older
+// generations expire with their TTL. Concurrent misses for the same key share
+// a single load. A generation bumped only on the fallback of a degraded store
+// is bumped again once the primary recovers.
+type NoteCache struct {
+	store store.Store
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+// NewNoteCache creates a note cache whose entries live for about ttl
+func NewNoteCache(st store.Store, ttl time.Duration) *NoteCache {
+	c := &NoteCache{
+		store: st,
+		ttl:   ttl,
+	}
+	if failover, ok := st.(*store.FailoverStore); ok {
+		failover.OnRecover(c.InvalidatePublicNotes)
+	}
+	return c
+}
+
+// Note returns the note with id, calling load on a miss. Missing notes are not cached.
+func (c *NoteCache) Note(ctx context.Context, id uuid.UUID, load func(ctx context.Context) (*model.Note, error)) (*model.Note, error) {
+	key := fmt.Sprintf("note:%s", id)
+
+	var cached model.Note
+	if c.get(ctx, noteCacheName, key, &cached) {
+		return &cached, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		// Shared by all waiters, so one caller going away must not fail the others
+		ctx := context.WithoutCancel(ctx)
+		note, err := load(ctx)
+		if err != nil || note == nil {
+			return note, err
+		}
+		c.set(ctx, key, note)
+		return note, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*model.Note), nil
+}
+
+// PublicNotes returns the public listing page for params, calling load on a miss.
+// params must have defaults applied so equivalent requests share a key.
+func (c *NoteCache) PublicNotes(ctx context.Context, params *model.GetNotesParams, load func(ctx context.Context) (*model.NotesListResponse, error)) (*model.NotesListResponse, error) {
+	generation, found, err := c.store.Get(ctx, publicNotesGenerationKey)
+	if err != nil {
+		// Without the generation a cached page may be stale; skip the cache
+		metrics.CacheRequests.WithLabelValues(publicNotesCacheName, "error").Inc()
+		return load(ctx)
+	}
+	if !found {
+		generation = "0"
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode listing params: %w", err)
+	}
+	sum := sha256.Sum256(paramsJSON)
+	key := fmt.Sprintf("notes:public:%s:%s", generation, hex.EncodeToString(sum[:8]))
+
+	var cached model.NotesListResponse
+	if c.get(ctx, publicNotesCacheName, key, &cached) {
+		return &cached, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		page, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.set(ctx, key, page)
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*model.NotesListResponse), nil
+}
+
+// InvalidateNote drops the cached note with id
+func (c *NoteCache) InvalidateNote(ctx context.Context, id uuid.UUID) {
+	if err := c.store.Delete(ctx, fmt.Sprintf("note:%s", id)); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate note cache", "note_id", id, "error", err)
+	}
+}
+
+// InvalidatePublicNotes drops every cached public listing page
+func (c *NoteCache) InvalidatePublicNotes(ctx context.Context) {
+	if _, err := c.store.Incr(ctx, publicNotesGenerationKey, 0); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate public notes cache", "error", err)
+	}
+}
+
+// get decodes the cached value of key into v and reports whether it was found
+func (c *NoteCache) get(ctx context.Context, cache, key string, v interface{}) bool {
+	val, found, err := c.store.Get(ctx, key)
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues(cache, "error").Inc()
+		return false
+	}
+	if !found {
+		metrics.CacheRequests.WithLabelValues(cache, "miss").Inc()
+		return false
+	}
+	if err := json.Unmarshal([]byte(val), v); err != nil {
+		metrics.CacheRequests.WithLabelValues(cache, "error").Inc()
+		return false
+	}
+	metrics.CacheRequests.WithLabelValues(cache, "hit").Inc()
+	return true
+}
+
+// set caches v under key. The TTL is spread by up to 10% so entries cached
+// together do not all expire at once.
+func (c *NoteCache) set(ctx context.Context, key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to encode cache entry", "key", key, "error", err)
+		return
+	}
+
+	ttl := c.ttl
+	if jitter := int64(ttl / 10); jitter > 0 {
+		ttl += time.Duration(rand.Int63n(jitter))
+	}
+	if err := c.store.Set(ctx, key, string(data), ttl); err != nil {
+		slog.WarnContext(ctx, "Failed to cache entry", "key", key, "error", err)
+	}
+}
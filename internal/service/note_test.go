@@ -1,518 +1,32 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/store"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
 )
 
-// Interfaces for dependency injection in tests
-type NoteRepositoryInterface interface {
-	Create(note *model.Note) error
-	GetByID(id uuid.UUID) (*model.Note, error)
-	GetByIDAndUserID(id, userID uuid.UUID) (*model.Note, error)
-	GetByUserID(userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error)
-	GetPublicNotes(params *model.GetNotesParams) ([]model.Note, int64, error)
-	Update(note *model.Note) error
-	Delete(id, userID uuid.UUID) error
-	Search(userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error)
-	IncrementViewCount(id uuid.UUID) error
-	Restore(id, userID uuid.UUID) error
-	HardDelete(id, userID uuid.UUID) error
-	BulkUpdateStatus(userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) error
-	GetNoteStats(userID uuid.UUID) (map[string]interface{}, error)
-}
-
-type UserRepositoryInterface interface {
-	Create(user *model.User) error
-	GetByEmail(email string) (*model.User, error)
-	GetByID(id uuid.UUID) (*model.User, error)
-	EmailExists(email string) (bool, error)
-	EmailExistsExcludingUser(email string, userID uuid.UUID) (bool, error)
-	Update(user *model.User) error
-}
-
-// TestNoteService for testing with interfaces
-type TestNoteService struct {
-	noteRepo  NoteRepositoryInterface
-	userRepo  UserRepositoryInterface
-	validator *utils.Validator
-}
-
-func NewTestNoteService(noteRepo NoteRepositoryInterface, userRepo UserRepositoryInterface, validator *utils.Validator) *TestNoteService {
-	return &TestNoteService{
-		noteRepo:  noteRepo,
-		userRepo:  userRepo,
-		validator: validator,
-	}
-}
-
-// Delegate all methods to the actual service methods but with interface dependencies
-func (s *TestNoteService) CreateNote(userID uuid.UUID, req *model.CreateNoteRequest) (*model.NoteResponse, error) {
-	// Validate request
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
-	}
-
-	// Verify user exists
-	user, err := s.userRepo.GetByID(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
-	}
-
-	// Convert request to note model
-	note := req.ToNote(userID)
-
-	// Validate content length
-	if note.Content != nil && len(*note.Content) > 10000 {
-		return nil, fmt.Errorf("content too long")
-	}
-
-	// Create note in database
-	if err := s.noteRepo.Create(note); err != nil {
-		return nil, fmt.Errorf("failed to create note: %w", err)
-	}
-
-	return note.ToResponse(), nil
-}
-
-func (s *TestNoteService) GetUserNotes(userID uuid.UUID, params *model.GetNotesParams) (*model.NotesListResponse, error) {
-	if err := s.validator.ValidateStruct(params); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
-	}
-
-	params.SetDefaults()
-
-	notes, total, err := s.noteRepo.GetByUserID(userID, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user notes: %w", err)
-	}
-
-	response := model.NewNotesListResponse(notes, total, params)
-	return response, nil
-}
-
-func (s *TestNoteService) UpdateNote(noteID, userID uuid.UUID, req *model.UpdateNoteRequest) (*model.NoteResponse, error) {
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
-	}
-
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get note: %w", err)
-	}
-	if note == nil {
-		return nil, fmt.Errorf("note not found")
-	}
-
-	req.ApplyToNote(note)
-
-	if err := s.noteRepo.Update(note); err != nil {
-		return nil, fmt.Errorf("failed to update note: %w", err)
-	}
-
-	return note.ToResponse(), nil
-}
-
-func (s *TestNoteService) DeleteNote(noteID, userID uuid.UUID) error {
-	note, err := s.noteRepo.GetByIDAndUserID(noteID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to get note: %w", err)
-	}
-	if note == nil {
-		return fmt.Errorf("note not found")
-	}
-
-	if err := s.noteRepo.Delete(noteID, userID); err != nil {
-		return fmt.Errorf("failed to delete note: %w", err)
-	}
-
-	return nil
-}
-
-func (s *TestNoteService) SearchNotes(userID uuid.UUID, req *model.NoteSearchRequest) (*model.NotesListResponse, error) {
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
-	}
-
-	req.SetDefaults()
-
-	notes, total, err := s.noteRepo.Search(userID, req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search notes: %w", err)
-	}
-
-	params := &model.GetNotesParams{
-		Page:     req.Page,
-		PageSize: req.PageSize,
-		Search:   req.Query,
-		Status:   req.Status,
-	}
-
-	response := model.NewNotesListResponse(notes, total, params)
-	return response, nil
-}
-
-func (s *TestNoteService) GetPublicNotes(params *model.GetNotesParams) (*model.NotesListResponse, error) {
-	if err := s.validator.ValidateStruct(params); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
-	}
-
-	params.SetDefaults()
-
-	notes, total, err := s.noteRepo.GetPublicNotes(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get public notes: %w", err)
-	}
-
-	response := model.NewNotesListResponse(notes, total, params)
-	return response, nil
-}
-
-// MockNoteRepository implements NoteRepositoryInterface for testing
-type MockNoteRepository struct {
-	notes       map[uuid.UUID]*model.Note
-	userNotes   map[uuid.UUID][]*model.Note
-	publicNotes []*model.Note
-	nextID      int
-}
-
-func NewMockNoteRepository() *MockNoteRepository {
-	return &MockNoteRepository{
-		notes:       make(map[uuid.UUID]*model.Note),
-		userNotes:   make(map[uuid.UUID][]*model.Note),
-		publicNotes: make([]*model.Note, 0),
-		nextID:      1,
-	}
-}
-
-func (m *MockNoteRepository) Create(note *model.Note) error {
-	note.ID = uuid.New()
-	note.CreatedAt = time.Now()
-	note.UpdatedAt = time.Now()
-
-	m.notes[note.ID] = note
-	userNotes := m.userNotes[note.UserID]
-	userNotes = append(userNotes, note)
-	m.userNotes[note.UserID] = userNotes
-
-	if note.IsPublic {
-		m.publicNotes = append(m.publicNotes, note)
-	}
-
-	return nil
-}
-
-func (m *MockNoteRepository) GetByID(id uuid.UUID) (*model.Note, error) {
-	note, exists := m.notes[id]
-	if !exists {
-		return nil, nil
-	}
-	return note, nil
-}
-
-func (m *MockNoteRepository) GetByIDAndUserID(id, userID uuid.UUID) (*model.Note, error) {
-	note, exists := m.notes[id]
-	if !exists || note.UserID != userID {
-		return nil, nil
-	}
-	return note, nil
-}
-
-func (m *MockNoteRepository) GetByUserID(userID uuid.UUID, params *model.GetNotesParams) ([]model.Note, int64, error) {
-	userNotes := m.userNotes[userID]
-
-	// Apply pagination
-	start := (params.Page - 1) * params.PageSize
-	end := start + params.PageSize
-
-	if start >= len(userNotes) {
-		return []model.Note{}, int64(len(userNotes)), nil
-	}
-
-	if end > len(userNotes) {
-		end = len(userNotes)
-	}
-
-	result := make([]model.Note, 0, end-start)
-	for i := start; i < end; i++ {
-		result = append(result, *userNotes[i])
-	}
-
-	return result, int64(len(userNotes)), nil
-}
-
-func (m *MockNoteRepository) GetPublicNotes(params *model.GetNotesParams) ([]model.Note, int64, error) {
-	// Apply pagination
-	start := (params.Page - 1) * params.PageSize
-	end := start + params.PageSize
-
-	if start >= len(m.publicNotes) {
-		return []model.Note{}, int64(len(m.publicNotes)), nil
-	}
-
-	if end > len(m.publicNotes) {
-		end = len(m.publicNotes)
-	}
-
-	result := make([]model.Note, 0, end-start)
-	for i := start; i < end; i++ {
-		result = append(result, *m.publicNotes[i])
-	}
-
-	return result, int64(len(m.publicNotes)), nil
-}
-
-func (m *MockNoteRepository) Update(note *model.Note) error {
-	existingNote, exists := m.notes[note.ID]
-	if !exists {
-		return errors.New("note not found")
-	}
-
-	// Update the note
-	note.UpdatedAt = time.Now()
-	note.CreatedAt = existingNote.CreatedAt // Preserve original creation time
-	m.notes[note.ID] = note
-
-	// Update in user notes
-	userNotes := m.userNotes[note.UserID]
-	for i, userNote := range userNotes {
-		if userNote.ID == note.ID {
-			userNotes[i] = note
-			break
-		}
-	}
-
-	// Update in public notes
-	wasPublic := false
-	for i, publicNote := range m.publicNotes {
-		if publicNote.ID == note.ID {
-			wasPublic = true
-			if note.IsPublic {
-				m.publicNotes[i] = note
-			} else {
-				// Remove from public notes
-				m.publicNotes = append(m.publicNotes[:i], m.publicNotes[i+1:]...)
-			}
-			break
-		}
-	}
-
-	// Add to public notes if now public
-	if !wasPublic && note.IsPublic {
-		m.publicNotes = append(m.publicNotes, note)
-	}
-
-	return nil
-}
-
-func (m *MockNoteRepository) Delete(id, userID uuid.UUID) error {
-	note, exists := m.notes[id]
-	if !exists || note.UserID != userID {
-		return errors.New("note not found")
-	}
-
-	// Remove from notes
-	delete(m.notes, id)
-
-	// Remove from user notes
-	userNotes := m.userNotes[note.UserID]
-	for i, userNote := range userNotes {
-		if userNote.ID == id {
-			userNotes = append(userNotes[:i], userNotes[i+1:]...)
-			m.userNotes[note.UserID] = userNotes
-			break
-		}
-	}
-
-	// Remove from public notes
-	for i, publicNote := range m.publicNotes {
-		if publicNote.ID == id {
-			m.publicNotes = append(m.publicNotes[:i], m.publicNotes[i+1:]...)
-			break
-		}
-	}
-
-	return nil
-}
-
-func (m *MockNoteRepository) Search(userID uuid.UUID, req *model.NoteSearchRequest) ([]model.Note, int64, error) {
-	userNotes := m.userNotes[userID]
-	var results []model.Note
-
-	// Simple search implementation
-	for _, note := range userNotes {
-		if contains(note.Title, req.Query) ||
-			(note.Content != nil && contains(*note.Content, req.Query)) {
-			results = append(results, *note)
-		}
-	}
-
-	// Apply pagination
-	start := (req.Page - 1) * req.PageSize
-	end := start + req.PageSize
-
-	if start >= len(results) {
-		return []model.Note{}, int64(len(results)), nil
-	}
-
-	if end > len(results) {
-		end = len(results)
-	}
-
-	return results[start:end], int64(len(results)), nil
-}
-
-// Mock methods for other repository operations
-func (m *MockNoteRepository) IncrementViewCount(id uuid.UUID) error {
-	note, exists := m.notes[id]
-	if !exists {
-		return errors.New("note not found")
-	}
-	note.ViewCount++
-	return nil
-}
-
-func (m *MockNoteRepository) Restore(id, userID uuid.UUID) error {
-	note, exists := m.notes[id]
-	if !exists || note.UserID != userID {
-		return errors.New("note not found")
-	}
-	note.Status = model.NoteStatusActive
-	now := time.Now()
-	note.UpdatedAt = now
-	note.DeletedAt = nil
-	return nil
-}
-
-func (m *MockNoteRepository) HardDelete(id, userID uuid.UUID) error {
-	return m.Delete(id, userID)
-}
-
-func (m *MockNoteRepository) BulkUpdateStatus(userID uuid.UUID, noteIDs []uuid.UUID, status model.NoteStatus) error {
-	for _, noteID := range noteIDs {
-		note, exists := m.notes[noteID]
-		if !exists || note.UserID != userID {
-			continue
-		}
-		note.Status = status
-		note.UpdatedAt = time.Now()
-	}
-	return nil
-}
-
-func (m *MockNoteRepository) GetNoteStats(userID uuid.UUID) (map[string]interface{}, error) {
-	userNotes := m.userNotes[userID]
-	stats := map[string]interface{}{
-		"total":   len(userNotes),
-		"active":  0,
-		"draft":   0,
-		"deleted": 0,
-	}
-
-	for _, note := range userNotes {
-		switch note.Status {
-		case model.NoteStatusActive:
-			stats["active"] = stats["active"].(int) + 1
-		case model.NoteStatusDraft:
-			stats["draft"] = stats["draft"].(int) + 1
-		case model.NoteStatusDeleted:
-			stats["deleted"] = stats["deleted"].(int) + 1
-		}
-	}
-
-	return stats, nil
-}
-
-// MockUserRepository implements UserRepositoryInterface for testing
-type MockUserRepository struct {
-	users     map[string]*model.User
-	usersByID map[uuid.UUID]*model.User
-}
-
-func NewMockUserRepository() *MockUserRepository {
-	return &MockUserRepository{
-		users:     make(map[string]*model.User),
-		usersByID: make(map[uuid.UUID]*model.User),
-	}
-}
-
-func (m *MockUserRepository) Create(user *model.User) error {
-	if _, exists := m.users[user.Email]; exists {
-		return errors.New("email already exists")
-	}
-	m.users[user.Email] = user
-	m.usersByID[user.ID] = user
-	return nil
-}
-
-func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
-	user, exists := m.users[email]
-	if !exists {
-		return nil, nil
-	}
-	return user, nil
-}
-
-func (m *MockUserRepository) GetByID(id uuid.UUID) (*model.User, error) {
-	user, exists := m.usersByID[id]
-	if !exists {
-		return nil, nil
-	}
-	return user, nil
-}
-
-func (m *MockUserRepository) EmailExists(email string) (bool, error) {
-	_, exists := m.users[email]
-	return exists, nil
-}
-
-func (m *MockUserRepository) EmailExistsExcludingUser(email string, userID uuid.UUID) (bool, error) {
-	user, exists := m.users[email]
-	if !exists {
-		return false, nil
-	}
-	return user.ID != userID, nil
-}
-
-func (m *MockUserRepository) Update(user *model.User) error {
-	if _, exists := m.usersByID[user.ID]; !exists {
-		return errors.New("user not found")
-	}
-
-	// Check if email is being changed to an existing email
-	if existingUser, exists := m.users[user.Email]; exists && existingUser.ID != user.ID {
-		return errors.New("email already exists")
-	}
-
-	// Remove old email mapping if email changed
-	for email, u := range m.users {
-		if u.ID == user.ID && email != user.Email {
-			delete(m.users, email)
-			break
-		}
-	}
-
-	m.users[user.Email] = user
-	m.usersByID[user.ID] = user
-	return nil
+// newTestNoteService creates a note service backed by in-memory repositories
+func newTestNoteService() (*NoteService, *repository.MemoryNoteRepository, *repository.MemoryUserRepository) {
+	noteRepo := repository.NewMemoryNoteRepository()
+	userRepo := repository.NewMemoryUserRepository()
+	tx := repository.NewMemoryTxManager()
+	views := NewViewCounter(noteRepo, repository.NewMemoryViewStatsRepository(noteRepo), tx, store.NewMemoryStore(), time.Minute, time.Second)
+	return NewNoteService(noteRepo, userRepo, tx, views, utils.NewValidator()), noteRepo, userRepo
 }
 
 // Test Note Creation
 func TestNoteService_CreateNote(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, _, userRepo := newTestNoteService()
 
 	userID := uuid.New()
 
@@ -525,7 +39,7 @@ func TestNoteService_CreateNote(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	testContent := "This is a test note content"
 	testContent2 := "This note has tags"
@@ -586,7 +100,7 @@ func TestNoteService_CreateNote(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			response, err := noteService.CreateNote(userID, tt.request)
+			response, err := noteService.CreateNote(ctx, userID, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -618,10 +132,8 @@ func TestNoteService_CreateNote(t *testing.T) {
 
 // Test Get User Notes
 func TestNoteService_GetUserNotes(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
 
 	userID := uuid.New()
 
@@ -635,7 +147,7 @@ func TestNoteService_GetUserNotes(t *testing.T) {
 			UserID:  userID,
 			Status:  model.NoteStatusActive,
 		}
-		noteRepo.Create(note)
+		noteRepo.Create(ctx, note)
 	}
 
 	tests := []struct {
@@ -689,7 +201,7 @@ func TestNoteService_GetUserNotes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := noteService.GetUserNotes(tt.userID, tt.params)
+			result, err := noteService.GetUserNotes(ctx, tt.userID, tt.params)
 
 			if tt.expectError {
 				if err == nil {
@@ -720,10 +232,8 @@ func TestNoteService_GetUserNotes(t *testing.T) {
 
 // Test Update Note
 func TestNoteService_UpdateNote(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
 
 	userID := uuid.New()
 	anotherUserID := uuid.New()
@@ -737,7 +247,7 @@ func TestNoteService_UpdateNote(t *testing.T) {
 		UserID:  userID,
 		Status:  model.NoteStatusActive,
 	}
-	noteRepo.Create(existingNote)
+	noteRepo.Create(ctx, existingNote)
 
 	updatedTitle := "Updated Title"
 	updatedContent := "Updated Content"
@@ -830,7 +340,7 @@ func TestNoteService_UpdateNote(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			note, err := noteService.UpdateNote(tt.noteID, tt.userID, tt.request)
+			note, err := noteService.UpdateNote(ctx, tt.noteID, tt.userID, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -866,10 +376,8 @@ func TestNoteService_UpdateNote(t *testing.T) {
 
 // Test Delete Note
 func TestNoteService_DeleteNote(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
 
 	userID := uuid.New()
 	anotherUserID := uuid.New()
@@ -883,7 +391,7 @@ func TestNoteService_DeleteNote(t *testing.T) {
 		UserID:  userID,
 		Status:  model.NoteStatusActive,
 	}
-	noteRepo.Create(existingNote)
+	noteRepo.Create(ctx, existingNote)
 
 	tests := []struct {
 		name          string
@@ -916,7 +424,7 @@ func TestNoteService_DeleteNote(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := noteService.DeleteNote(tt.noteID, tt.userID)
+			err := noteService.DeleteNote(ctx, tt.noteID, tt.userID)
 
 			if tt.expectError {
 				if err == nil {
@@ -934,26 +442,52 @@ func TestNoteService_DeleteNote(t *testing.T) {
 				return
 			}
 
-			// Verify note is deleted
-			deletedNote, err := noteRepo.GetByID(tt.noteID)
+			// Verify note is soft deleted
+			deletedNote, err := noteRepo.GetByID(ctx, tt.noteID)
 			if err != nil {
 				t.Errorf("error checking deleted note: %v", err)
 				return
 			}
 
-			if deletedNote != nil {
-				t.Errorf("expected note to be deleted but it still exists")
+			if deletedNote == nil || !deletedNote.IsDeleted() {
+				t.Errorf("expected note to be soft deleted")
 			}
 		})
 	}
 }
 
+// Test Bulk Status Update with missing notes
+func TestNoteService_BulkUpdateNotesStatusMissing(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
+
+	userID := uuid.New()
+	existingNote := &model.Note{ID: uuid.New(), Title: "Kept", UserID: userID, Status: model.NoteStatusActive}
+	noteRepo.Create(ctx, existingNote)
+
+	for _, ids := range [][]uuid.UUID{{uuid.New()}, {existingNote.ID, uuid.New()}} {
+		req := &model.BulkOperationRequest{
+			NoteIDs:   ids,
+			Operation: "update_status",
+			Data:      map[string]interface{}{"status": "draft"},
+		}
+		err := noteService.BulkUpdateNotesStatus(ctx, userID, req)
+		if err == nil || !contains(err.Error(), "one or more notes not found") {
+			t.Errorf("BulkUpdateNotesStatus(%d IDs) error = %v, want one or more notes not found", len(ids), err)
+		}
+	}
+
+	// Nothing is changed when any note is missing
+	note, _ := noteRepo.GetByID(ctx, existingNote.ID)
+	if note == nil || note.Status != model.NoteStatusActive {
+		t.Errorf("note status changed by a failed bulk update")
+	}
+}
+
 // Test Search Notes
 func TestNoteService_SearchNotes(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
 
 	userID := uuid.New()
 
@@ -966,7 +500,7 @@ func TestNoteService_SearchNotes(t *testing.T) {
 	}
 
 	for _, note := range testNotes {
-		noteRepo.Create(note)
+		noteRepo.Create(ctx, note)
 	}
 
 	tests := []struct {
@@ -1024,7 +558,7 @@ func TestNoteService_SearchNotes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := noteService.SearchNotes(tt.userID, tt.request)
+			result, err := noteService.SearchNotes(ctx, tt.userID, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -1047,10 +581,8 @@ func TestNoteService_SearchNotes(t *testing.T) {
 
 // Test Get Public Notes
 func TestNoteService_GetPublicNotes(t *testing.T) {
-	noteRepo := NewMockNoteRepository()
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	noteService := NewTestNoteService(noteRepo, userRepo, validator)
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
 
 	userID := uuid.New()
 
@@ -1067,10 +599,10 @@ func TestNoteService_GetPublicNotes(t *testing.T) {
 	}
 
 	for _, note := range publicNotes {
-		noteRepo.Create(note)
+		noteRepo.Create(ctx, note)
 	}
 	for _, note := range privateNotes {
-		noteRepo.Create(note)
+		noteRepo.Create(ctx, note)
 	}
 
 	tests := []struct {
@@ -1101,7 +633,7 @@ func TestNoteService_GetPublicNotes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := noteService.GetPublicNotes(tt.params)
+			result, err := noteService.GetPublicNotes(ctx, tt.params)
 
 			if tt.expectError {
 				if err == nil {
@@ -1129,7 +661,216 @@ func TestNoteService_GetPublicNotes(t *testing.T) {
 	}
 }
 
+func TestNoteService_GetPublicNote(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, userRepo := newTestNoteService()
+
+	author := &model.User{ID: uuid.New(), Email: "author@example.com", Password: "hash", FullName: "Ada Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	userRepo.Create(ctx, author)
+
+	public := &model.Note{ID: uuid.New(), Title: "Public", Content: stringPtr("Public content"), UserID: author.ID, IsPublic: true, Status: model.NoteStatusActive}
+	private := &model.Note{ID: uuid.New(), Title: "Private", UserID: author.ID, Status: model.NoteStatusActive}
+	draft := &model.Note{ID: uuid.New(), Title: "Draft", UserID: author.ID, IsPublic: true, Status: model.NoteStatusDraft}
+	for _, note := range []*model.Note{public, private, draft} {
+		noteRepo.Create(ctx, note)
+	}
+
+	tests := []struct {
+		name    string
+		noteID  uuid.UUID
+		userID  uuid.UUID
+		wantErr bool
+	}{
+		{name: "anonymous reader", noteID: public.ID},
+		{name: "authenticated reader", noteID: public.ID, userID: uuid.New()},
+		{name: "author", noteID: public.ID, userID: author.ID},
+		{name: "private note", noteID: private.ID, wantErr: true},
+		{name: "private note by author", noteID: private.ID, userID: author.ID, wantErr: true},
+		{name: "public draft", noteID: draft.ID, wantErr: true},
+		{name: "missing note", noteID: uuid.New(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note, err := noteService.GetPublicNote(ctx, tt.noteID, tt.userID, "192.0.2.1", "")
+			if tt.wantErr {
+				if err == nil || err.Error() != "note not found" {
+					t.Errorf("GetPublicNote() error = %v, want note not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPublicNote() error = %v", err)
+			}
+			if note.Author.DisplayName != author.FullName {
+				t.Errorf("GetPublicNote() author = %q, want %q", note.Author.DisplayName, author.FullName)
+			}
+		})
+	}
+
+	// The anonymous and the authenticated reader count, the author does not
+	if err := noteService.views.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	stored, _ := noteRepo.GetByID(ctx, public.ID)
+	if stored.ViewCount != 2 {
+		t.Errorf("ViewCount = %d, want 2", stored.ViewCount)
+	}
+}
+
+func TestNoteService_ScheduleNote(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
+	userID := uuid.New()
+
+	active := &model.Note{ID: uuid.New(), Title: "Active", UserID: userID, Status: model.NoteStatusActive}
+	draft := &model.Note{ID: uuid.New(), Title: "Draft", UserID: userID, Status: model.NoteStatusDraft}
+	deleted := &model.Note{ID: uuid.New(), Title: "Deleted", UserID: userID, Status: model.NoteStatusDeleted}
+	for _, note := range []*model.Note{active, draft, deleted} {
+		noteRepo.Create(ctx, note)
+	}
+
+	now := time.Now()
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		noteID  uuid.UUID
+		req     model.ScheduleNoteRequest
+		wantErr string
+	}{
+		{name: "active note", noteID: active.ID, req: model.ScheduleNoteRequest{PublishAt: &soon, UnpublishAt: &later}},
+		{name: "draft with publish_at", noteID: draft.ID, req: model.ScheduleNoteRequest{PublishAt: &soon}},
+		{name: "draft with only unpublish_at", noteID: draft.ID, req: model.ScheduleNoteRequest{UnpublishAt: &later}, wantErr: "only active notes can be scheduled"},
+		{name: "deleted note", noteID: deleted.ID, req: model.ScheduleNoteRequest{PublishAt: &soon}, wantErr: "only active notes can be scheduled"},
+		{name: "past time", noteID: active.ID, req: model.ScheduleNoteRequest{PublishAt: &past}, wantErr: "validation error: publish_at must be in the future"},
+		{name: "unpublish before publish", noteID: active.ID, req: model.ScheduleNoteRequest{PublishAt: &later, UnpublishAt: &soon}, wantErr: "validation error: unpublish_at must be after publish_at"},
+		{name: "missing note", noteID: uuid.New(), req: model.ScheduleNoteRequest{PublishAt: &soon}, wantErr: "note not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note, err := noteService.ScheduleNote(ctx, tt.noteID, userID, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ScheduleNote() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScheduleNote() error = %v", err)
+			}
+			if note.Schedule == nil || !note.Schedule.PublishAt.Equal(*tt.req.PublishAt) {
+				t.Errorf("ScheduleNote() schedule = %+v", note.Schedule)
+			}
+		})
+	}
+
+	// The draft is activated and published, then both notes go private again
+	if changed, err := noteService.ApplySchedules(ctx, soon); err != nil || changed != 2 {
+		t.Fatalf("ApplySchedules(soon) = %d, %v, want 2", changed, err)
+	}
+	published, _ := noteRepo.GetByID(ctx, draft.ID)
+	if !published.IsPublic || published.Status != model.NoteStatusActive || published.Schedule() != nil {
+		t.Errorf("draft after publish_at = public %v, status %s, schedule %+v", published.IsPublic, published.Status, published.Schedule())
+	}
+
+	if changed, err := noteService.ApplySchedules(ctx, later); err != nil || changed != 1 {
+		t.Fatalf("ApplySchedules(later) = %d, %v, want 1", changed, err)
+	}
+	unpublished, _ := noteRepo.GetByID(ctx, active.ID)
+	if unpublished.IsPublic || unpublished.Schedule() != nil {
+		t.Errorf("note after unpublish_at = public %v, schedule %+v", unpublished.IsPublic, unpublished.Schedule())
+	}
+}
+
+func TestNoteService_RestoredNoteKeepsNoSchedule(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, _ := newTestNoteService()
+	userID := uuid.New()
+
+	note := &model.Note{ID: uuid.New(), Title: "Scheduled", UserID: userID, Status: model.NoteStatusActive}
+	noteRepo.Create(ctx, note)
+	soon := time.Now().Add(time.Minute)
+	if _, err := noteService.ScheduleNote(ctx, note.ID, userID, &model.ScheduleNoteRequest{PublishAt: &soon}); err != nil {
+		t.Fatalf("ScheduleNote() error = %v", err)
+	}
+
+	// Deleted until long after publish_at, then restored
+	if err := noteService.DeleteNote(ctx, note.ID, userID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if _, err := noteService.RestoreNote(ctx, note.ID, userID); err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+	if changed, err := noteService.ApplySchedules(ctx, soon.Add(24*time.Hour)); err != nil || changed != 0 {
+		t.Errorf("ApplySchedules() after restore = %d, %v, want 0", changed, err)
+	}
+	restored, _ := noteRepo.GetByID(ctx, note.ID)
+	if restored.IsPublic || restored.Schedule() != nil {
+		t.Errorf("restored note = public %v, schedule %+v, want private without schedule", restored.IsPublic, restored.Schedule())
+	}
+}
+
 // Helper function to create string pointers
+func TestNoteService_Cache(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := repository.NewMemoryNoteRepository()
+	cache := NewNoteCache(store.NewMemoryStore(), time.Minute)
+	userRepo := repository.NewMemoryUserRepository()
+	tx := repository.NewMemoryTxManager()
+	views := NewViewCounter(noteRepo, repository.NewMemoryViewStatsRepository(noteRepo), tx, store.NewMemoryStore(), time.Minute, time.Second)
+	noteService := NewNoteServiceWithCache(noteRepo, userRepo, tx, views, utils.NewValidator(), cache)
+
+	userID := uuid.New()
+	userRepo.Create(ctx, &model.User{ID: userID, Email: "cache@example.com", Password: "hash", FullName: "Cache User", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	isPublic := true
+	created, err := noteService.CreateNote(ctx, userID, &model.CreateNoteRequest{Title: "Original", IsPublic: &isPublic})
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	if _, err := noteService.GetNoteByID(ctx, created.ID, userID, ""); err != nil {
+		t.Fatalf("GetNoteByID() error = %v", err)
+	}
+
+	// Writes that bypass the service are not seen until the entry is invalidated
+	stored, _ := noteRepo.GetByID(ctx, created.ID)
+	stored.Title = "Changed behind the cache"
+	noteRepo.Update(ctx, stored)
+	note, _ := noteService.GetNoteByID(ctx, created.ID, userID, "")
+	if note.Title != "Original" {
+		t.Errorf("GetNoteByID() title = %q, want cached %q", note.Title, "Original")
+	}
+
+	newTitle := "Updated"
+	if _, err := noteService.UpdateNote(ctx, created.ID, userID, &model.UpdateNoteRequest{Title: &newTitle}); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+	note, _ = noteService.GetNoteByID(ctx, created.ID, userID, "")
+	if note.Title != newTitle {
+		t.Errorf("GetNoteByID() after update title = %q, want %q", note.Title, newTitle)
+	}
+
+	// Public listing pages are dropped when a public note changes
+	page, err := noteService.GetPublicNotes(ctx, &model.GetNotesParams{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("GetPublicNotes() error = %v", err)
+	}
+	if page.Total != 1 {
+		t.Fatalf("GetPublicNotes() total = %d, want 1", page.Total)
+	}
+
+	if _, err := noteService.ToggleNotePublicStatus(ctx, created.ID, userID); err != nil {
+		t.Fatalf("ToggleNotePublicStatus() error = %v", err)
+	}
+	page, _ = noteService.GetPublicNotes(ctx, &model.GetNotesParams{Page: 1, PageSize: 20})
+	if page.Total != 0 {
+		t.Errorf("GetPublicNotes() after toggle total = %d, want 0", page.Total)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+)
+
+const (
+	// outboxGracePeriod is how long the relay leaves a new message to the
+	// request that enqueued it, which delivers it right after commit
+	outboxGracePeriod = 30 * time.Second
+	// outboxLease is how long a claimed message is hidden from other relays
+	outboxLease = time.Minute
+	// outboxBatchSize bounds the messages claimed per poll
+	outboxBatchSize = 100
+	// outboxMaxBackoff caps the delay between retries of a failing message
+	outboxMaxBackoff = 10 * time.Minute
+	// outboxRetention is how long delivered messages are kept
+	outboxRetention = 24 * time.Hour
+)
+
+// OutboxHandler applies the side effect of a message. Messages can be
+// delivered more than once, so handlers must be idempotent.
+type OutboxHandler func(ctx context.Context, payload []byte) error
+
+// OutboxService records side effects in the unit of work that causes them
+// and delivers them once it commits. Delivery is attempted right after
+// commit; messages that fail, or whose process died first, are retried by
+// the relay loop.
+type OutboxService struct {
+	repo     repository.OutboxRepository
+	interval time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]OutboxHandler
+}
+
+// NewOutboxService creates an outbox whose relay polls every interval
+func NewOutboxService(repo repository.OutboxRepository, interval time.Duration) *OutboxService {
+	return &OutboxService{
+		repo:     repo,
+		interval: interval,
+		handlers: make(map[string]OutboxHandler),
+	}
+}
+
+// Handle registers the handler for a message kind
+func (s *OutboxService) Handle(kind string, handler OutboxHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+// Enqueue records a message in the unit of work of ctx. payload is encoded as JSON.
+func (s *OutboxService) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", kind, err)
+	}
+
+	msg := &model.OutboxMessage{
+		Kind:          kind,
+		Payload:       data,
+		NextAttemptAt: time.Now().Add(outboxGracePeriod),
+	}
+	if err := s.repo.Add(ctx, msg); err != nil {
+		return fmt.Errorf("failed to enqueue %s message: %w", kind, err)
+	}
+
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		s.deliver(ctx, msg)
+	})
+	return nil
+}
+
+// Run relays due messages every interval until ctx is cancelled
+func (s *OutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	lastPurge := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.Relay(ctx); err != nil {
+			slog.ErrorContext(ctx, "Outbox relay failed", "error", err)
+		}
+
+		if time.Since(lastPurge) >= time.Hour {
+			lastPurge = time.Now()
+			if _, err := s.repo.DeleteProcessedBefore(ctx, time.Now().Add(-outboxRetention)); err != nil {
+				slog.ErrorContext(ctx, "Failed to purge outbox", "error", err)
+			}
+		}
+	}
+}
+
+// Relay claims and delivers one batch of due messages and returns how many were claimed
+func (s *OutboxService) Relay(ctx context.Context) (int, error) {
+	messages, err := s.repo.ClaimDue(ctx, outboxBatchSize, time.Now().Add(outboxLease))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		s.deliver(ctx, &messages[i])
+	}
+	return len(messages), nil
+}
+
+// deliver runs the handler of msg and records the outcome
+func (s *OutboxService) deliver(ctx context.Context, msg *model.OutboxMessage) {
+	s.mu.RLock()
+	handler, ok := s.handlers[msg.Kind]
+	s.mu.RUnlock()
+
+	err := fmt.Errorf("no handler for outbox message kind %q", msg.Kind)
+	if ok {
+		err = handler(ctx, msg.Payload)
+	}
+
+	if err == nil {
+		metrics.OutboxDeliveries.WithLabelValues(msg.Kind, "delivered").Inc()
+		if err := s.repo.MarkProcessed(ctx, msg.ID); err != nil {
+			// Delivered again by the relay later; handlers are idempotent
+			slog.WarnContext(ctx, "Failed to mark outbox message processed", "id", msg.ID, "error", err)
+		}
+		return
+	}
+
+	metrics.OutboxDeliveries.WithLabelValues(msg.Kind, "failed").Inc()
+	retryAt := time.Now().Add(outboxBackoff(msg.Attempts))
+	slog.WarnContext(ctx, "Outbox delivery failed", "id", msg.ID, "kind", msg.Kind, "attempts", msg.Attempts+1, "retry_at", retryAt, "error", err)
+	if err := s.repo.MarkFailed(ctx, msg.ID, err.Error(), retryAt); err != nil {
+		slog.WarnContext(ctx, "Failed to record outbox delivery failure", "id", msg.ID, "error", err)
+	}
+}
+
+// outboxBackoff doubles the retry delay with each failed attempt, from 1s up to outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	if attempts >= 10 {
+		return outboxMaxBackoff
+	}
+	backoff := time.Second << attempts
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gonotes/internal/repository"
+)
+
+func TestOutboxService_DeliversAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := repository.NewMemoryOutboxRepository()
+	outbox := NewOutboxService(outboxRepo, time.Second)
+	tx := repository.NewMemoryTxManager()
+
+	var delivered []string
+	outbox.Handle("test", func(ctx context.Context, payload []byte) error {
+		delivered = append(delivered, string(payload))
+		return nil
+	})
+
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := outbox.Enqueue(ctx, "test", "committed"); err != nil {
+			return err
+		}
+		if len(delivered) != 0 {
+			t.Error("message delivered before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := outbox.Enqueue(ctx, "test", "rolled back"); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("WithinTx() expected error")
+	}
+
+	if len(delivered) != 1 || delivered[0] != `"committed"` {
+		t.Errorf("delivered = %v, want only the committed message", delivered)
+	}
+}
+
+func TestOutboxService_RetriesFailedDelivery(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := repository.NewMemoryOutboxRepository()
+	outbox := NewOutboxService(outboxRepo, time.Second)
+
+	calls := 0
+	outbox.Handle("test", func(ctx context.Context, payload []byte) error {
+		calls++
+		if calls == 1 {
+			return errors.New("store unavailable")
+		}
+		return nil
+	})
+
+	if err := outbox.Enqueue(ctx, "test", map[string]string{"id": "1"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	pending := outboxRepo.Pending()
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError == nil {
+		t.Fatalf("pending = %+v, want one failed message", pending)
+	}
+
+	// Not due until the backoff elapses
+	if n, err := outbox.Relay(ctx); err != nil || n != 0 {
+		t.Fatalf("Relay() = %d, %v, want 0 before backoff", n, err)
+	}
+
+	time.Sleep(outboxBackoff(0) + 100*time.Millisecond)
+	if n, err := outbox.Relay(ctx); err != nil || n != 1 {
+		t.Fatalf("Relay() = %d, %v, want 1", n, err)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+	if pending := outboxRepo.Pending(); len(pending) != 0 {
+		t.Errorf("pending = %d, want 0", len(pending))
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/tracing"
+	"gonotes/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// handlePattern is the form of a normalized handle
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reservedHandles cannot be taken, so they cannot pass for the service itself
+var reservedHandles = map[string]struct{}{
+	"admin":     {},
+	"api":       {},
+	"gonotes":   {},
+	"me":        {},
+	"root":      {},
+	"settings":  {},
+	"support":   {},
+	"system":    {},
+	"anonymous": {},
+}
+
+// ProfileService handles public user profiles. A profile is found by its
+// handle; previous handles keep resolving to the user, and only they can
+// take them back.
+type ProfileService struct {
+	userRepo    repository.UserRepository
+	noteService *NoteService
+	tx          repository.TxManager
+	validator   *utils.Validator
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(userRepo repository.UserRepository, noteService *NoteService, tx repository.TxManager, validator *utils.Validator) *ProfileService {
+	return &ProfileService{
+		userRepo:    userRepo,
+		noteService: noteService,
+		tx:          tx,
+		validator:   validator,
+	}
+}
+
+// GetOwnProfile returns the public profile settings of userID
+func (s *ProfileService) GetOwnProfile(ctx context.Context, userID uuid.UUID) (*model.ProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user.ToProfileResponse(), nil
+}
+
+// UpdateProfile changes the public profile fields set in req. A profile
+// needs a handle to be public.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *model.UpdatePublicProfileRequest) (*model.ProfileResponse, error) {
+	ctx, span := tracing.Start(ctx, "ProfileService.UpdateProfile", tracing.UserID(userID))
+	defer span.End()
+
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	var handle string
+	if req.Handle != nil {
+		var err error
+		if handle, err = normalizeHandle(*req.Handle); err != nil {
+			return nil, err
+		}
+	}
+	if req.AvatarURL != nil && *req.AvatarURL != "" {
+		if err := validateAvatarURL(*req.AvatarURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var user *model.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return fmt.Errorf("user not found")
+		}
+
+		wasPublic := user.ProfilePublic
+		oldHandle := ""
+		if user.Handle != nil {
+			oldHandle = *user.Handle
+		}
+
+		if req.Handle != nil && handle != oldHandle {
+			taken, err := s.userRepo.HandleTaken(ctx, handle, userID)
+			if err != nil {
+				return fmt.Errorf("failed to check handle: %w", err)
+			}
+			if taken {
+				return fmt.Errorf("handle already taken")
+			}
+			if err := s.userRepo.RecordHandleChange(ctx, userID, oldHandle, handle); err != nil {
+				return fmt.Errorf("failed to record handle change: %w", err)
+			}
+			user.Handle = &handle
+		}
+		if req.Bio != nil {
+			user.Bio = optionalString(*req.Bio)
+		}
+		if req.AvatarURL != nil {
+			user.AvatarURL = optionalString(*req.AvatarURL)
+		}
+		if req.Public != nil {
+			user.ProfilePublic = *req.Public
+		}
+		if user.ProfilePublic && user.Handle == nil {
+			return fmt.Errorf("validation error: handle is required for a public profile")
+		}
+		user.UpdatedAt = time.Now()
+
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			// Another user claimed the handle since HandleTaken checked it
+			if strings.Contains(err.Error(), "handle already exists") {
+				return fmt.Errorf("handle already taken")
+			}
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		// Public listings credit notes to the handle of public profiles
+		if wasPublic || user.ProfilePublic {
+			s.noteService.invalidateCache(ctx, true)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToProfileResponse(), nil
+}
+
+// GetPublicProfile returns the public profile with handle. The returned
+// handle differs from the requested one when a previous handle was used.
+func (s *ProfileService) GetPublicProfile(ctx context.Context, handle string) (*model.PublicProfile, error) {
+	ctx, span := tracing.Start(ctx, "ProfileService.GetPublicProfile")
+	defer span.End()
+
+	user, err := s.resolve(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToPublicProfile(), nil
+}
+
+// GetProfileNotes returns a page of the public notes of the profile with
+// handle, along with the current handle of the profile
+func (s *ProfileService) GetProfileNotes(ctx context.Context, handle string, params *model.GetNotesParams) (*model.NotesListResponse, string, error) {
+	ctx, span := tracing.Start(ctx, "ProfileService.GetProfileNotes")
+	defer span.End()
+
+	user, err := s.resolve(ctx, handle)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params.AuthorID = &user.ID
+	notes, err := s.noteService.GetPublicNotes(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+	return notes, *user.Handle, nil
+}
+
+// resolve finds the user with a public profile under a current or previous
+// handle. Private profiles are reported as missing.
+func (s *ProfileService) resolve(ctx context.Context, handle string) (*model.User, error) {
+	user, err := s.userRepo.GetByHandle(ctx, strings.ToLower(strings.TrimSpace(handle)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || !user.ProfilePublic || user.Handle == nil {
+		return nil, fmt.Errorf("profile not found")
+	}
+	return user, nil
+}
+
+// normalizeHandle lowercases a handle and checks its form
+func normalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimSpace(handle))
+	if !handlePattern.MatchString(handle) {
+		return "", fmt.Errorf("validation error: handle must be 3 to 30 letters, digits or underscores")
+	}
+	if _, reserved := reservedHandles[handle]; reserved {
+		return "", fmt.Errorf("validation error: handle is reserved")
+	}
+	return handle, nil
+}
+
+// validateAvatarURL checks that an avatar is an absolute http(s) URL
+func validateAvatarURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("validation error: avatar_url must be an http or https URL")
+	}
+	return nil
+}
+
+// optionalString returns nil for an empty or blank s
+func optionalString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestProfileService_Handles(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, userRepo := newTestNoteService()
+	profiles := NewProfileService(userRepo, noteService, repository.NewMemoryTxManager(), utils.NewValidator())
+
+	alice := &model.User{ID: uuid.New(), Email: "alice@example.com", Password: "hash", FullName: "Alice", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	bob := &model.User{ID: uuid.New(), Email: "bob@example.com", Password: "hash", FullName: "Bob", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	userRepo.Create(ctx, alice)
+	userRepo.Create(ctx, bob)
+	noteRepo.Create(ctx, &model.Note{ID: uuid.New(), UserID: alice.ID, Title: "Alice on Go", IsPublic: true, Status: model.NoteStatusActive})
+	noteRepo.Create(ctx, &model.Note{ID: uuid.New(), UserID: bob.ID, Title: "Bob on Rust", IsPublic: true, Status: model.NoteStatusActive})
+
+	// A profile cannot be public without a handle
+	if _, err := profiles.UpdateProfile(ctx, alice.ID, &model.UpdatePublicProfileRequest{Public: boolPtr(true)}); err == nil {
+		t.Fatal("expected error for public profile without handle")
+	}
+
+	for _, handle := range []string{"al", "alice!", "admin"} {
+		if _, err := profiles.UpdateProfile(ctx, alice.ID, &model.UpdatePublicProfileRequest{Handle: stringPtr(handle)}); err == nil {
+			t.Errorf("expected error for handle %q", handle)
+		}
+	}
+
+	profile, err := profiles.UpdateProfile(ctx, alice.ID, &model.UpdatePublicProfileRequest{
+		Handle: stringPtr("Alice_Dev"),
+		Bio:    stringPtr("Writes about Go"),
+		Public: boolPtr(true),
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	if profile.Handle == nil || *profile.Handle != "alice_dev" {
+		t.Errorf("handle = %v, want alice_dev", profile.Handle)
+	}
+
+	if _, err := profiles.UpdateProfile(ctx, bob.ID, &model.UpdatePublicProfileRequest{Handle: stringPtr("alice_dev")}); err == nil || err.Error() != "handle already taken" {
+		t.Errorf("taking a used handle error = %v, want handle already taken", err)
+	}
+
+	// Public listings credit notes to the handle of public profiles only
+	list, err := noteService.GetPublicNotes(ctx, &model.GetNotesParams{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("GetPublicNotes() error = %v", err)
+	}
+	for _, item := range list.Notes {
+		if item.Author == nil {
+			t.Fatalf("note %q has no author", item.Title)
+		}
+		wantHandle := item.Title == "Alice on Go"
+		if (item.Author.Handle != nil) != wantHandle {
+			t.Errorf("note %q author handle = %v", item.Title, item.Author.Handle)
+		}
+	}
+
+	// Renaming keeps the old handle resolving to the user and reserved for them
+	if _, err := profiles.UpdateProfile(ctx, alice.ID, &model.UpdatePublicProfileRequest{Handle: stringPtr("alice")}); err != nil {
+		t.Fatalf("rename error = %v", err)
+	}
+	public, err := profiles.GetPublicProfile(ctx, "alice_dev")
+	if err != nil {
+		t.Fatalf("GetPublicProfile(old handle) error = %v", err)
+	}
+	if public.Handle != "alice" {
+		t.Errorf("old handle resolved to %q, want alice", public.Handle)
+	}
+	if _, err := profiles.UpdateProfile(ctx, bob.ID, &model.UpdatePublicProfileRequest{Handle: stringPtr("alice_dev")}); err == nil {
+		t.Error("expected error taking another user's previous handle")
+	}
+
+	notes, current, err := profiles.GetProfileNotes(ctx, "alice_dev", &model.GetNotesParams{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("GetProfileNotes() error = %v", err)
+	}
+	if current != "alice" || notes.Total != 1 || notes.Notes[0].Title != "Alice on Go" {
+		t.Errorf("GetProfileNotes() = %q, %d notes", current, notes.Total)
+	}
+
+	// Taking back the old handle works and private profiles are hidden
+	if _, err := profiles.UpdateProfile(ctx, alice.ID, &model.UpdatePublicProfileRequest{Handle: stringPtr("alice_dev"), Public: boolPtr(false)}); err != nil {
+		t.Fatalf("taking back old handle error = %v", err)
+	}
+	if _, err := profiles.GetPublicProfile(ctx, "alice_dev"); err == nil || err.Error() != "profile not found" {
+		t.Errorf("private profile error = %v, want profile not found", err)
+	}
+}
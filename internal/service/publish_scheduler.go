@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PublishScheduler applies the publish_at and unpublish_at times of notes.
+// Each pass is a conditional update per direction, so several instances can
+// run it at once.
+type PublishScheduler struct {
+	notes    *NoteService
+	interval time.Duration
+}
+
+// NewPublishScheduler creates a scheduler that checks for due notes every interval
+func NewPublishScheduler(notes *NoteService, interval time.Duration) *PublishScheduler {
+	return &PublishScheduler{
+		notes:    notes,
+		interval: interval,
+	}
+}
+
+// Run applies due schedules every interval until ctx is cancelled
+func (s *PublishScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.notes.ApplySchedules(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to apply note schedules", "error", err)
+		}
+		if changed > 0 {
+			slog.InfoContext(ctx, "Applied note schedules", "notes", changed)
+		}
+	}
+}
@@ -1,38 +1,69 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"gonotes/internal/config"
+	"gonotes/internal/metrics"
 	"gonotes/internal/model"
 	"gonotes/internal/repository"
+	"gonotes/internal/store"
+	"gonotes/internal/tracing"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
-// SessionService handles business logic for sessions
+// Outbox message kinds for token registry updates
+const (
+	outboxRefreshTokenSet    = "refresh_token.set"
+	outboxRefreshTokenDelete = "refresh_token.delete"
+)
+
+// refreshTokenMessage is the payload of the refresh token outbox messages
+type refreshTokenMessage struct {
+	TokenID   string    `json:"token_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// SessionService handles business logic for sessions. Sessions are the
+// source of truth; the token registry is updated through the outbox once
+// session changes commit.
 type SessionService struct {
-	sessionRepo *repository.SessionRepository
-	userRepo    *repository.UserRepository
-	rdb         *redis.Client
+	sessionRepo repository.SessionRepository
+	userRepo    repository.UserRepository
+	tokens      store.Store
+	tx          repository.TxManager
+	outbox      *OutboxService
 	cfg         *config.Config
 }
 
-// NewSessionService creates a new session service
-func NewSessionService(sessionRepo *repository.SessionRepository, userRepo *repository.UserRepository, rdb *redis.Client, cfg *config.Config) *SessionService {
-	return &SessionService{
+// NewSessionService creates a new session service and registers its outbox handlers
+func NewSessionService(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, tokens store.Store, tx repository.TxManager, outbox *OutboxService, cfg *config.Config) *SessionService {
+	s := &SessionService{
 		sessionRepo: sessionRepo,
 		userRepo:    userRepo,
-		rdb:         rdb,
+		tokens:      tokens,
+		tx:          tx,
+		outbox:      outbox,
 		cfg:         cfg,
 	}
+	outbox.Handle(outboxRefreshTokenSet, s.applyRefreshTokenSet)
+	outbox.Handle(outboxRefreshTokenDelete, s.applyRefreshTokenDelete)
+	return s
 }
 
 // CreateSession creates a new session after successful login
-func (s *SessionService) CreateSession(user *model.User, userAgent, ipAddress string) (*model.AuthResponse, error) {
+func (s *SessionService) CreateSession(ctx context.Context, user *model.User, userAgent, ipAddress string) (*model.AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.CreateSession", tracing.UserID(user.ID))
+	defer span.End()
+
 	// Generate access token
 	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.FullName, s.cfg)
 	if err != nil {
@@ -45,18 +76,12 @@ func (s *SessionService) CreateSession(user *model.User, userAgent, ipAddress st
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Parse refresh token to get token ID for Redis
+	// Parse refresh token to get token ID for the token registry
 	refreshClaims, err := utils.ValidateToken(refreshToken, s.cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse refresh token: %w", err)
 	}
 
-	// Store refresh token in Redis
-	err = utils.SetRefreshToken(s.rdb, refreshClaims.ID, user.ID.String(), s.cfg.RefreshExpire)
-	if err != nil {
-		return nil, fmt.Errorf("failed to store refresh token in redis: %w", err)
-	}
-
 	// Create session in database
 	session := &model.Session{
 		ID:           uuid.New(),
@@ -69,10 +94,19 @@ func (s *SessionService) CreateSession(user *model.User, userAgent, ipAddress st
 		ExpiresAt:    &refreshClaims.ExpiresAt.Time,
 	}
 
-	if err := s.sessionRepo.Create(session); err != nil {
-		// Cleanup Redis if database insert fails
-		utils.InvalidateRefreshToken(s.rdb, refreshClaims.ID)
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	// Register the refresh token in the token registry once the session is committed
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Create(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return s.outbox.Enqueue(ctx, outboxRefreshTokenSet, refreshTokenMessage{
+			TokenID:   refreshClaims.ID,
+			UserID:    user.ID.String(),
+			ExpiresAt: refreshClaims.ExpiresAt.Time,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Return auth response
@@ -85,7 +119,10 @@ func (s *SessionService) CreateSession(user *model.User, userAgent, ipAddress st
 }
 
 // RefreshSession generates new tokens from valid refresh token
-func (s *SessionService) RefreshSession(refreshToken string) (*model.AuthResponse, error) {
+func (s *SessionService) RefreshSession(ctx context.Context, refreshToken string) (*model.AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.RefreshSession")
+	defer span.End()
+
 	// Validate refresh token
 	claims, err := utils.ValidateToken(refreshToken, s.cfg)
 	if err != nil {
@@ -96,17 +133,21 @@ func (s *SessionService) RefreshSession(refreshToken string) (*model.AuthRespons
 		return nil, fmt.Errorf("invalid token type")
 	}
 
-	// Check if refresh token exists in Redis
-	userIDStr, err := utils.GetUserIDFromRefreshToken(s.rdb, claims.ID)
+	// Check if refresh token exists in the token registry. When the registry
+	// is unavailable or misses the token, the database session below is the
+	// source of truth: the registration of a new session may still be
+	// waiting in the outbox.
+	userIDStr, err := utils.GetUserIDFromRefreshToken(ctx, s.tokens, claims.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate refresh token: %w", err)
-	}
-	if userIDStr == "" {
-		return nil, fmt.Errorf("refresh token not found or expired")
+		if !errors.Is(err, store.ErrUnavailable) {
+			return nil, fmt.Errorf("failed to validate refresh token: %w", err)
+		}
+		slog.WarnContext(ctx, "Token registry unavailable, validating refresh token against database", "error", err)
 	}
+	registryMiss := err == nil && userIDStr == ""
 
 	// Verify refresh token in database
-	session, err := s.sessionRepo.GetByRefreshToken(refreshToken)
+	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
@@ -119,8 +160,16 @@ func (s *SessionService) RefreshSession(refreshToken string) (*model.AuthRespons
 		return nil, fmt.Errorf("session expired")
 	}
 
+	// Register a valid session the registry missed instead of waiting for the outbox
+	if registryMiss {
+		ttl := time.Until(claims.ExpiresAt.Time)
+		if err := utils.SetRefreshToken(ctx, s.tokens, claims.ID, session.UserID.String(), ttl); err != nil {
+			slog.WarnContext(ctx, "Failed to register refresh token", "session_id", session.ID, "error", err)
+		}
+	}
+
 	// Get user details
-	user, err := s.userRepo.GetByID(session.UserID)
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -144,51 +193,70 @@ func (s *SessionService) RefreshSession(refreshToken string) (*model.AuthRespons
 }
 
 // InvalidateSession invalidates a session (logout)
-func (s *SessionService) InvalidateSession(refreshToken string) error {
+func (s *SessionService) InvalidateSession(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "SessionService.InvalidateSession")
+	defer span.End()
+
 	// Validate refresh token to get token ID
 	claims, err := utils.ValidateToken(refreshToken, s.cfg)
 	if err != nil {
 		return fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// Remove from Redis
-	if err := utils.InvalidateRefreshToken(s.rdb, claims.ID); err != nil {
-		return fmt.Errorf("failed to invalidate refresh token in redis: %w", err)
-	}
-
-	// Mark as invalid in database
-	if err := s.sessionRepo.InvalidateByRefreshToken(refreshToken); err != nil {
-		return fmt.Errorf("failed to invalidate session in database: %w", err)
+	// Mark as invalid in database, then remove from the token registry
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.InvalidateByRefreshToken(ctx, refreshToken); err != nil {
+			return fmt.Errorf("failed to invalidate session in database: %w", err)
+		}
+		return s.outbox.Enqueue(ctx, outboxRefreshTokenDelete, refreshTokenMessage{TokenID: claims.ID})
+	})
+	if err != nil {
+		return err
 	}
+	metrics.SessionsRevoked.WithLabelValues("logout").Inc()
 
 	return nil
 }
 
 // InvalidateAllSessions invalidates all sessions for a user
-func (s *SessionService) InvalidateAllSessions(userID uuid.UUID) error {
-	// Get all sessions for user
-	sessions, err := s.sessionRepo.GetByUserID(userID)
-	if err != nil {
-		return fmt.Errorf("failed to get user sessions: %w", err)
-	}
+func (s *SessionService) InvalidateAllSessions(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "SessionService.InvalidateAllSessions", tracing.UserID(userID))
+	defer span.End()
+
+	var revoked int
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Get all sessions for user
+		sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user sessions: %w", err)
+		}
 
-	// Invalidate each refresh token in Redis
-	for _, session := range sessions {
-		if claims, err := utils.ValidateToken(session.RefreshToken, s.cfg); err == nil {
-			utils.InvalidateRefreshToken(s.rdb, claims.ID)
+		// Invalidate all sessions in database
+		if err := s.sessionRepo.InvalidateAllByUserID(ctx, userID); err != nil {
+			return fmt.Errorf("failed to invalidate all sessions: %w", err)
 		}
-	}
 
-	// Invalidate all sessions in database
-	if err := s.sessionRepo.InvalidateAllByUserID(userID); err != nil {
-		return fmt.Errorf("failed to invalidate all sessions: %w", err)
+		// Invalidate each refresh token in the token registry after commit
+		for _, session := range sessions {
+			if claims, err := utils.ValidateToken(session.RefreshToken, s.cfg); err == nil {
+				if err := s.outbox.Enqueue(ctx, outboxRefreshTokenDelete, refreshTokenMessage{TokenID: claims.ID}); err != nil {
+					return err
+				}
+			}
+		}
+		revoked = len(sessions)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	metrics.SessionsRevoked.WithLabelValues("all_devices").Add(float64(revoked))
 
 	return nil
 }
 
 // ValidateAccessToken validates access token and returns user claims
-func (s *SessionService) ValidateAccessToken(accessToken string) (*utils.JWTClaims, error) {
+func (s *SessionService) ValidateAccessToken(ctx context.Context, accessToken string) (*utils.JWTClaims, error) {
 	claims, err := utils.ValidateToken(accessToken, s.cfg)
 	if err != nil {
 		return nil, fmt.Errorf("invalid access token: %w", err)
@@ -202,9 +270,12 @@ func (s *SessionService) ValidateAccessToken(accessToken string) (*utils.JWTClai
 }
 
 // GetUserSessions retrieves all active sessions for a user
-func (s *SessionService) GetUserSessions(userID uuid.UUID, currentRefreshToken *string) ([]*model.SessionResponse, error) {
+func (s *SessionService) GetUserSessions(ctx context.Context, userID uuid.UUID, currentRefreshToken *string) ([]*model.SessionResponse, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetUserSessions", tracing.UserID(userID))
+	defer span.End()
+
 	// Get all sessions from database
-	sessions, err := s.sessionRepo.GetUserSessions(userID)
+	sessions, err := s.sessionRepo.GetUserSessions(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +283,7 @@ func (s *SessionService) GetUserSessions(userID uuid.UUID, currentRefreshToken *
 	// Determine current session ID if refresh token provided
 	var currentSessionID *uuid.UUID
 	if currentRefreshToken != nil {
-		if currentSession, err := s.sessionRepo.GetByRefreshToken(*currentRefreshToken); err == nil && currentSession != nil {
+		if currentSession, err := s.sessionRepo.GetByRefreshToken(ctx, *currentRefreshToken); err == nil && currentSession != nil {
 			currentSessionID = &currentSession.ID
 		}
 	}
@@ -234,35 +305,45 @@ func (s *SessionService) GetUserSessions(userID uuid.UUID, currentRefreshToken *
 }
 
 // InvalidateSpecificSession invalidates a specific session for a user
-func (s *SessionService) InvalidateSpecificSession(userID, sessionID uuid.UUID) error {
-	// Get the session to be invalidated
-	session, err := s.sessionRepo.GetSessionByIDAndUserID(sessionID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
-	}
-	if session == nil {
-		return fmt.Errorf("session not found or not owned by user")
-	}
+func (s *SessionService) InvalidateSpecificSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "SessionService.InvalidateSpecificSession", tracing.UserID(userID))
+	defer span.End()
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Get the session to be invalidated
+		session, err := s.sessionRepo.GetSessionByIDAndUserID(ctx, sessionID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			return fmt.Errorf("session not found or not owned by user")
+		}
 
-	// Remove refresh token from Redis
-	if claims, err := utils.ValidateToken(session.RefreshToken, s.cfg); err == nil {
-		if err := utils.InvalidateRefreshToken(s.rdb, claims.ID); err != nil {
-			// Log error but continue with database invalidation
-			fmt.Printf("Failed to invalidate refresh token in Redis: %v\n", err)
+		// Invalidate session in database
+		if err := s.sessionRepo.InvalidateBySessionIDAndUserID(ctx, sessionID, userID); err != nil {
+			return fmt.Errorf("failed to invalidate session: %w", err)
 		}
-	}
 
-	// Invalidate session in database
-	if err := s.sessionRepo.InvalidateBySessionIDAndUserID(sessionID, userID); err != nil {
-		return fmt.Errorf("failed to invalidate session: %w", err)
+		// Remove refresh token from the token registry after commit
+		if claims, err := utils.ValidateToken(session.RefreshToken, s.cfg); err == nil {
+			return s.outbox.Enqueue(ctx, outboxRefreshTokenDelete, refreshTokenMessage{TokenID: claims.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	metrics.SessionsRevoked.WithLabelValues("device").Inc()
 
 	return nil
 }
 
 // GetCurrentSessionFromToken determines current session from refresh token
-func (s *SessionService) GetCurrentSessionFromToken(refreshToken string) (*uuid.UUID, error) {
-	session, err := s.sessionRepo.GetByRefreshToken(refreshToken)
+func (s *SessionService) GetCurrentSessionFromToken(ctx context.Context, refreshToken string) (*uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetCurrentSessionFromToken")
+	defer span.End()
+
+	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
@@ -274,6 +355,32 @@ func (s *SessionService) GetCurrentSessionFromToken(refreshToken string) (*uuid.
 }
 
 // CleanupExpiredSessions removes expired sessions
-func (s *SessionService) CleanupExpiredSessions() error {
-	return s.sessionRepo.CleanupExpiredSessions()
+func (s *SessionService) CleanupExpiredSessions(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "SessionService.CleanupExpiredSessions")
+	defer span.End()
+
+	return s.sessionRepo.CleanupExpiredSessions(ctx)
+}
+
+// applyRefreshTokenSet registers a refresh token in the token registry
+func (s *SessionService) applyRefreshTokenSet(ctx context.Context, payload []byte) error {
+	var msg refreshTokenMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("invalid refresh token message: %w", err)
+	}
+
+	ttl := time.Until(msg.ExpiresAt)
+	if ttl <= 0 {
+		return nil // Expired while pending, nothing to register
+	}
+	return utils.SetRefreshToken(ctx, s.tokens, msg.TokenID, msg.UserID, ttl)
+}
+
+// applyRefreshTokenDelete removes a refresh token from the token registry
+func (s *SessionService) applyRefreshTokenDelete(ctx context.Context, payload []byte) error {
+	var msg refreshTokenMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("invalid refresh token message: %w", err)
+	}
+	return utils.InvalidateRefreshToken(ctx, s.tokens, msg.TokenID)
 }
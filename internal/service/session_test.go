@@ -1,272 +1,35 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"testing"
 	"time"
 
 	"gonotes/internal/config"
 	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/store"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
-// SessionRepositoryInterface for testing
-type SessionRepositoryInterface interface {
-	Create(session *model.Session) error
-	GetByRefreshToken(refreshToken string) (*model.Session, error)
-	GetByUserID(userID uuid.UUID) ([]model.Session, error)
-	GetUserSessions(userID uuid.UUID) ([]model.Session, error)
-	GetSessionByIDAndUserID(sessionID, userID uuid.UUID) (*model.Session, error)
-	InvalidateByRefreshToken(refreshToken string) error
-	InvalidateBySessionID(sessionID uuid.UUID) error
-	InvalidateBySessionIDAndUserID(sessionID, userID uuid.UUID) error
-	InvalidateAllByUserID(userID uuid.UUID) error
-	CleanupExpiredSessions() error
-}
-
-// TestSessionService for testing with interfaces
-type TestSessionService struct {
-	sessionRepo SessionRepositoryInterface
-	userRepo    UserRepositoryInterface
-	redisClient *redis.Client
-	config      *config.Config
-}
-
-func NewTestSessionService(sessionRepo SessionRepositoryInterface, userRepo UserRepositoryInterface, redisClient *redis.Client, cfg *config.Config) *TestSessionService {
-	return &TestSessionService{
-		sessionRepo: sessionRepo,
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		config:      cfg,
-	}
-}
-
-// Delegate methods to implement session service functionality
-func (s *TestSessionService) CreateSession(user *model.User, userAgent, ipAddress string) (*model.AuthResponse, error) {
-	// Create session
-	expiresAt := time.Now().Add(s.config.RefreshExpire)
-	session := &model.Session{
-		ID:           uuid.New(),
-		UserID:       user.ID,
-		RefreshToken: generateRefreshToken(),
-		IsValid:      true,
-		UserAgent:    &userAgent,
-		IPAddress:    &ipAddress,
-		CreatedAt:    time.Now(),
-		ExpiresAt:    &expiresAt,
-	}
-
-	if err := s.sessionRepo.Create(session); err != nil {
-		return nil, err
-	}
-
-	// Generate access token
-	accessToken := generateAccessToken(user.ID, user.Email, s.config)
-
-	return &model.AuthResponse{
-		User: &model.UserResponse{
-			ID:       user.ID,
-			Email:    user.Email,
-			FullName: user.FullName,
-		},
-		AccessToken:  accessToken,
-		RefreshToken: session.RefreshToken,
-		ExpiresIn:    int64(s.config.JWTExpire.Seconds()),
-	}, nil
-}
-
-func (s *TestSessionService) InvalidateSession(refreshToken string) error {
-	session, err := s.sessionRepo.GetByRefreshToken(refreshToken)
-	if err != nil {
-		return err
-	}
-	if session == nil {
-		return errors.New("invalid refresh token")
-	}
-
-	return s.sessionRepo.InvalidateByRefreshToken(refreshToken)
-}
-
-func (s *TestSessionService) GetUserSessions(userID uuid.UUID, currentRefreshToken *string) ([]model.SessionResponse, error) {
-	sessions, err := s.sessionRepo.GetUserSessions(userID)
-	if err != nil {
-		return nil, err
-	}
-
-	var responses []model.SessionResponse
-	for _, session := range sessions {
-		response := model.SessionResponse{
-			ID:        session.ID,
-			UserAgent: session.UserAgent,
-			IPAddress: session.IPAddress,
-			CreatedAt: session.CreatedAt,
-			ExpiresAt: session.ExpiresAt,
-		}
-
-		// Add device info if available
-		if session.UserAgent != nil {
-			deviceInfo := parseDeviceInfo(*session.UserAgent)
-			response.DeviceInfo = &deviceInfo
-		}
-
-		responses = append(responses, response)
-	}
-
-	return responses, nil
-}
-
-func (s *TestSessionService) InvalidateSpecificSession(userID, sessionID uuid.UUID) error {
-	return s.sessionRepo.InvalidateBySessionIDAndUserID(sessionID, userID)
-}
-
-func (s *TestSessionService) InvalidateAllSessions(userID uuid.UUID) error {
-	return s.sessionRepo.InvalidateAllByUserID(userID)
-}
-
-func (s *TestSessionService) ValidateAccessToken(accessToken string) (*utils.JWTClaims, error) {
-	// Simple validation for testing
-	if accessToken == "" || accessToken == "invalid-token" {
-		return nil, errors.New("invalid access token")
-	}
-
-	// Return mock claims for valid tokens
-	return &utils.JWTClaims{
-		UserID: uuid.New(),
-		Email:  "test@example.com",
-	}, nil
-}
-
-// MockSessionRepository implements SessionRepositoryInterface for testing
-type MockSessionRepository struct {
-	sessions     map[string]*model.Session
-	userSessions map[uuid.UUID][]*model.Session
-}
-
-func NewMockSessionRepository() *MockSessionRepository {
-	return &MockSessionRepository{
-		sessions:     make(map[string]*model.Session),
-		userSessions: make(map[uuid.UUID][]*model.Session),
-	}
-}
-
-func (m *MockSessionRepository) Create(session *model.Session) error {
-	session.ID = uuid.New()
-	session.CreatedAt = time.Now()
-
-	m.sessions[session.RefreshToken] = session
-	userSessions := m.userSessions[session.UserID]
-	userSessions = append(userSessions, session)
-	m.userSessions[session.UserID] = userSessions
-
-	return nil
-}
-
-func (m *MockSessionRepository) GetByRefreshToken(refreshToken string) (*model.Session, error) {
-	session, exists := m.sessions[refreshToken]
-	if !exists || !session.IsValid {
-		return nil, nil
-	}
-	return session, nil
-}
-
-func (m *MockSessionRepository) GetByUserID(userID uuid.UUID) ([]model.Session, error) {
-	sessions := m.userSessions[userID]
-	var validSessions []model.Session
-	for _, session := range sessions {
-		if session.IsValid {
-			validSessions = append(validSessions, *session)
-		}
-	}
-	return validSessions, nil
-}
-
-func (m *MockSessionRepository) GetUserSessions(userID uuid.UUID) ([]model.Session, error) {
-	return m.GetByUserID(userID)
-}
-
-func (m *MockSessionRepository) GetSessionByIDAndUserID(sessionID, userID uuid.UUID) (*model.Session, error) {
-	for _, session := range m.sessions {
-		if session.ID == sessionID && session.UserID == userID && session.IsValid {
-			return session, nil
-		}
-	}
-	return nil, nil
-}
-
-func (m *MockSessionRepository) InvalidateByRefreshToken(refreshToken string) error {
-	session, exists := m.sessions[refreshToken]
-	if !exists {
-		return errors.New("session not found")
-	}
-	session.IsValid = false
-	return nil
-}
-
-func (m *MockSessionRepository) InvalidateBySessionID(sessionID uuid.UUID) error {
-	for _, session := range m.sessions {
-		if session.ID == sessionID {
-			session.IsValid = false
-			return nil
-		}
-	}
-	return errors.New("session not found")
-}
-
-func (m *MockSessionRepository) InvalidateBySessionIDAndUserID(sessionID, userID uuid.UUID) error {
-	for _, session := range m.sessions {
-		if session.ID == sessionID && session.UserID == userID {
-			session.IsValid = false
-			return nil
-		}
-	}
-	return errors.New("session not found or not owned by user")
-}
-
-func (m *MockSessionRepository) InvalidateAllByUserID(userID uuid.UUID) error {
-	for _, session := range m.sessions {
-		if session.UserID == userID {
-			session.IsValid = false
-		}
-	}
-	return nil
-}
-
-func (m *MockSessionRepository) CleanupExpiredSessions() error {
-	return nil
-}
-
-// Helper functions for testing
-func generateRefreshToken() string {
-	return uuid.New().String()
-}
-
-func generateAccessToken(userID uuid.UUID, email string, cfg *config.Config) string {
-	return "mock-access-token-" + userID.String()
-}
-
-func parseDeviceInfo(userAgent string) model.DeviceInfo {
-	return model.DeviceInfo{
-		Browser: "Test Browser",
-		OS:      "Test OS",
-		Device:  "Test Device",
-	}
+// newTestSessionService creates a session service backed by in-memory repositories
+func newTestSessionService(cfg *config.Config) *SessionService {
+	outbox := NewOutboxService(repository.NewMemoryOutboxRepository(), time.Second)
+	return NewSessionService(repository.NewMemorySessionRepository(), repository.NewMemoryUserRepository(), store.NewMemoryStore(), repository.NewMemoryTxManager(), outbox, cfg)
 }
 
 // Test Session Creation
 func TestSessionService_CreateSession(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"}) // For testing, will fail gracefully
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test user
 	testUser := &model.User{
@@ -310,7 +73,7 @@ func TestSessionService_CreateSession(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			authResponse, err := sessionService.CreateSession(tt.user, tt.userAgent, tt.ipAddress)
+			authResponse, err := sessionService.CreateSession(ctx, tt.user, tt.userAgent, tt.ipAddress)
 
 			if tt.expectError {
 				if err == nil {
@@ -357,16 +120,14 @@ func TestSessionService_CreateSession(t *testing.T) {
 
 // Test Session Invalidation
 func TestSessionService_InvalidateSession(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test session
 	testUser := &model.User{
@@ -378,7 +139,7 @@ func TestSessionService_InvalidateSession(t *testing.T) {
 	}
 
 	// Create session first
-	authResponse, err := sessionService.CreateSession(testUser, "test-agent", "127.0.0.1")
+	authResponse, err := sessionService.CreateSession(ctx, testUser, "test-agent", "127.0.0.1")
 	if err != nil {
 		t.Fatalf("failed to create session for test: %v", err)
 	}
@@ -404,7 +165,7 @@ func TestSessionService_InvalidateSession(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := sessionService.InvalidateSession(tt.refreshToken)
+			err := sessionService.InvalidateSession(ctx, tt.refreshToken)
 
 			if tt.expectError {
 				if err == nil {
@@ -426,16 +187,14 @@ func TestSessionService_InvalidateSession(t *testing.T) {
 
 // Test Get User Sessions
 func TestSessionService_GetUserSessions(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test user
 	testUser := &model.User{
@@ -447,8 +206,8 @@ func TestSessionService_GetUserSessions(t *testing.T) {
 	}
 
 	// Create multiple sessions
-	session1, _ := sessionService.CreateSession(testUser, "Mozilla/5.0 (Macintosh)", "192.168.1.100")
-	_, _ = sessionService.CreateSession(testUser, "Mozilla/5.0 (iPhone)", "10.0.0.50")
+	session1, _ := sessionService.CreateSession(ctx, testUser, "Mozilla/5.0 (Macintosh)", "192.168.1.100")
+	_, _ = sessionService.CreateSession(ctx, testUser, "Mozilla/5.0 (iPhone)", "10.0.0.50")
 
 	tests := []struct {
 		name                 string
@@ -482,7 +241,7 @@ func TestSessionService_GetUserSessions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sessions, err := sessionService.GetUserSessions(tt.userID, tt.currentRefreshToken)
+			sessions, err := sessionService.GetUserSessions(ctx, tt.userID, tt.currentRefreshToken)
 
 			if tt.expectError {
 				if err == nil {
@@ -512,16 +271,14 @@ func TestSessionService_GetUserSessions(t *testing.T) {
 
 // Test Invalidate Specific Session
 func TestSessionService_InvalidateSpecificSession(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test user and session
 	testUser := &model.User{
@@ -532,10 +289,10 @@ func TestSessionService_InvalidateSpecificSession(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	_, _ = sessionService.CreateSession(testUser, "test-agent", "127.0.0.1")
+	_, _ = sessionService.CreateSession(ctx, testUser, "test-agent", "127.0.0.1")
 
 	// Extract session ID from the created session (in real implementation, we'd get this from the session)
-	sessions, _ := sessionService.GetUserSessions(testUser.ID, nil)
+	sessions, _ := sessionService.GetUserSessions(ctx, testUser.ID, nil)
 	var sessionID uuid.UUID
 	if len(sessions) > 0 {
 		sessionID = sessions[0].ID
@@ -572,7 +329,7 @@ func TestSessionService_InvalidateSpecificSession(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := sessionService.InvalidateSpecificSession(tt.userID, tt.sessionID)
+			err := sessionService.InvalidateSpecificSession(ctx, tt.userID, tt.sessionID)
 
 			if tt.expectError {
 				if err == nil {
@@ -594,16 +351,14 @@ func TestSessionService_InvalidateSpecificSession(t *testing.T) {
 
 // Test Invalidate All Sessions
 func TestSessionService_InvalidateAllSessions(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test user and multiple sessions
 	testUser := &model.User{
@@ -615,23 +370,23 @@ func TestSessionService_InvalidateAllSessions(t *testing.T) {
 	}
 
 	// Create multiple sessions
-	sessionService.CreateSession(testUser, "Mozilla/5.0 (Macintosh)", "192.168.1.100")
-	sessionService.CreateSession(testUser, "Mozilla/5.0 (iPhone)", "10.0.0.50")
+	sessionService.CreateSession(ctx, testUser, "Mozilla/5.0 (Macintosh)", "192.168.1.100")
+	sessionService.CreateSession(ctx, testUser, "Mozilla/5.0 (iPhone)", "10.0.0.50")
 
 	// Verify sessions exist
-	sessionsBefore, _ := sessionService.GetUserSessions(testUser.ID, nil)
+	sessionsBefore, _ := sessionService.GetUserSessions(ctx, testUser.ID, nil)
 	if len(sessionsBefore) != 2 {
 		t.Fatalf("expected 2 sessions before invalidation, got %d", len(sessionsBefore))
 	}
 
 	// Test invalidate all sessions
-	err := sessionService.InvalidateAllSessions(testUser.ID)
+	err := sessionService.InvalidateAllSessions(ctx, testUser.ID)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 
 	// Verify all sessions are invalidated
-	sessionsAfter, _ := sessionService.GetUserSessions(testUser.ID, nil)
+	sessionsAfter, _ := sessionService.GetUserSessions(ctx, testUser.ID, nil)
 	if len(sessionsAfter) != 0 {
 		t.Errorf("expected 0 sessions after invalidation, got %d", len(sessionsAfter))
 	}
@@ -639,16 +394,14 @@ func TestSessionService_InvalidateAllSessions(t *testing.T) {
 
 // Test Access Token Validation
 func TestSessionService_ValidateAccessToken(t *testing.T) {
-	sessionRepo := NewMockSessionRepository()
-	userRepo := NewMockUserRepository()
-	mockRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
 	cfg := &config.Config{
 		JWTSecret:     "test-secret",
 		JWTExpire:     15 * time.Minute,
 		RefreshExpire: 7 * 24 * time.Hour,
 	}
 
-	sessionService := NewTestSessionService(sessionRepo, userRepo, mockRedis, cfg)
+	sessionService := newTestSessionService(cfg)
 
 	// Create a test user and session
 	testUser := &model.User{
@@ -659,7 +412,7 @@ func TestSessionService_ValidateAccessToken(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	authResponse, _ := sessionService.CreateSession(testUser, "test-agent", "127.0.0.1")
+	authResponse, _ := sessionService.CreateSession(ctx, testUser, "test-agent", "127.0.0.1")
 
 	tests := []struct {
 		name          string
@@ -688,7 +441,7 @@ func TestSessionService_ValidateAccessToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			claims, err := sessionService.ValidateAccessToken(tt.accessToken)
+			claims, err := sessionService.ValidateAccessToken(ctx, tt.accessToken)
 
 			if tt.expectError {
 				if err == nil {
@@ -717,3 +470,58 @@ func TestSessionService_ValidateAccessToken(t *testing.T) {
 		})
 	}
 }
+
+// Test refreshing a session whose registration is still in the outbox
+func TestSessionService_RefreshSessionBeforeRegistration(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		JWTSecret:     "test-secret",
+		JWTExpire:     15 * time.Minute,
+		RefreshExpire: 7 * 24 * time.Hour,
+	}
+
+	userRepo := repository.NewMemoryUserRepository()
+	tokens := store.NewMemoryStore()
+	defer tokens.Close()
+	outbox := NewOutboxService(repository.NewMemoryOutboxRepository(), time.Second)
+	sessionService := NewSessionService(repository.NewMemorySessionRepository(), userRepo, tokens, repository.NewMemoryTxManager(), outbox, cfg)
+
+	testUser := &model.User{
+		ID:        uuid.New(),
+		Email:     "test@example.com",
+		FullName:  "Test User",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := userRepo.Create(ctx, testUser); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	// The outbox has not registered the token yet
+	authResponse, err := sessionService.CreateSession(ctx, testUser, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	refreshed, err := sessionService.RefreshSession(ctx, authResponse.RefreshToken)
+	if err != nil {
+		t.Fatalf("expected refresh of a new session to succeed, got %v", err)
+	}
+	if refreshed.AccessToken == "" {
+		t.Error("expected a new access token")
+	}
+
+	// The refresh registered the token itself
+	claims, _ := utils.ValidateToken(authResponse.RefreshToken, cfg)
+	if userID, err := utils.GetUserIDFromRefreshToken(ctx, tokens, claims.ID); err != nil || userID != testUser.ID.String() {
+		t.Errorf("expected token to be registered for %s, got %q (err=%v)", testUser.ID, userID, err)
+	}
+
+	// A revoked session stays revoked
+	if err := sessionService.InvalidateSession(ctx, authResponse.RefreshToken); err != nil {
+		t.Fatalf("failed to invalidate session: %v", err)
+	}
+	if _, err := sessionService.RefreshSession(ctx, authResponse.RefreshToken); err == nil {
+		t.Error("expected refresh of an invalidated session to fail")
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/tracing"
+	"gonotes/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// shareSlugBytes is the entropy of a share link slug, 128 bits
+const shareSlugBytes = 16
+
+// ShareService handles share links, which serve a note without
+// authentication at /s/{slug}
+type ShareService struct {
+	shareRepo repository.ShareLinkRepository
+	noteRepo  repository.NoteRepository
+	validator *utils.Validator
+}
+
+// NewShareService creates a new share service
+func NewShareService(shareRepo repository.ShareLinkRepository, noteRepo repository.NoteRepository, validator *utils.Validator) *ShareService {
+	return &ShareService{
+		shareRepo: shareRepo,
+		noteRepo:  noteRepo,
+		validator: validator,
+	}
+}
+
+// CreateShareLink creates a share link for a note owned by userID
+func (s *ShareService) CreateShareLink(ctx context.Context, noteID, userID uuid.UUID, req *model.CreateShareLinkRequest) (*model.ShareLinkResponse, error) {
+	ctx, span := tracing.Start(ctx, "ShareService.CreateShareLink", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	// Validate request
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("validation error: expires_at must be in the future")
+	}
+
+	if _, err := s.getOwnedNote(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	slug, err := newShareSlug()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &model.ShareLink{
+		ID:        uuid.New(),
+		NoteID:    noteID,
+		UserID:    userID,
+		Slug:      slug,
+		ExpiresAt: req.ExpiresAt,
+		MaxViews:  req.MaxViews,
+		CreatedAt: time.Now(),
+	}
+	if req.Password != nil && *req.Password != "" {
+		hash, err := utils.HashPassword(*req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		link.PasswordHash = &hash
+	}
+
+	if err := s.shareRepo.Create(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return link.ToResponse(), nil
+}
+
+// GetShareLinks returns every share link of a note owned by userID, with its stats
+func (s *ShareService) GetShareLinks(ctx context.Context, noteID, userID uuid.UUID) ([]*model.ShareLinkResponse, error) {
+	ctx, span := tracing.Start(ctx, "ShareService.GetShareLinks", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	if _, err := s.getOwnedNote(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shareRepo.GetByNoteID(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+
+	responses := make([]*model.ShareLinkResponse, len(links))
+	for i := range links {
+		responses[i] = links[i].ToResponse()
+	}
+	return responses, nil
+}
+
+// RevokeShareLink revokes a share link of a note owned by userID
+func (s *ShareService) RevokeShareLink(ctx context.Context, noteID, linkID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ShareService.RevokeShareLink", tracing.UserID(userID), tracing.NoteID(noteID))
+	defer span.End()
+
+	if _, err := s.getOwnedNote(ctx, noteID, userID); err != nil {
+		return err
+	}
+
+	if err := s.shareRepo.Revoke(ctx, linkID, noteID); err != nil {
+		return fmt.Errorf("share link not found")
+	}
+	return nil
+}
+
+// OpenShareLink returns the note behind slug and counts the view. password
+// is checked when the link has one.
+func (s *ShareService) OpenShareLink(ctx context.Context, slug, password string) (*model.SharedNoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "ShareService.OpenShareLink")
+	defer span.End()
+
+	link, err := s.shareRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if link == nil {
+		return nil, fmt.Errorf("share link not found")
+	}
+	if !link.Active(time.Now()) {
+		return nil, fmt.Errorf("share link expired")
+	}
+
+	if link.PasswordHash != nil {
+		if password == "" {
+			return nil, fmt.Errorf("password required")
+		}
+		if err := utils.VerifyPassword(*link.PasswordHash, password); err != nil {
+			return nil, fmt.Errorf("incorrect password")
+		}
+	}
+
+	note, err := s.noteRepo.GetByID(ctx, link.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	if note == nil || note.Status == model.NoteStatusDeleted {
+		return nil, fmt.Errorf("share link not found")
+	}
+
+	// Checked again in the update, so concurrent views cannot exceed the limit
+	counted, err := s.shareRepo.RecordView(ctx, link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record share link view: %w", err)
+	}
+	if !counted {
+		return nil, fmt.Errorf("share link expired")
+	}
+
+	return note.ToSharedResponse(), nil
+}
+
+// getOwnedNote returns a note of userID that is not deleted
+func (s *ShareService) getOwnedNote(ctx context.Context, noteID, userID uuid.UUID) (*model.Note, error) {
+	note, err := s.noteRepo.GetByIDAndUserID(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	if note == nil || note.Status == model.NoteStatusDeleted {
+		return nil, fmt.Errorf("note not found")
+	}
+	return note, nil
+}
+
+// newShareSlug returns a random URL-safe slug
+func newShareSlug() (string, error) {
+	b := make([]byte, shareSlugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share slug: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
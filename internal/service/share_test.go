@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// newTestShareService creates a share service and a note owned by the returned user
+func newTestShareService(t *testing.T) (*ShareService, *model.Note) {
+	t.Helper()
+
+	noteRepo := repository.NewMemoryNoteRepository()
+	content := "Shared content"
+	note := &model.Note{ID: uuid.New(), UserID: uuid.New(), Title: "Shared", Content: &content, Status: model.NoteStatusActive}
+	if err := noteRepo.Create(context.Background(), note); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return NewShareService(repository.NewMemoryShareLinkRepository(), noteRepo, utils.NewValidator()), note
+}
+
+func TestShareService_OpenShareLink(t *testing.T) {
+	ctx := context.Background()
+	shares, note := newTestShareService(t)
+
+	password := "secret-pass"
+	maxViews := int64(2)
+	link, err := shares.CreateShareLink(ctx, note.ID, note.UserID, &model.CreateShareLinkRequest{Password: &password, MaxViews: &maxViews})
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+	if len(link.Slug) < 20 || !link.HasPassword || link.Path != "/s/"+link.Slug {
+		t.Errorf("CreateShareLink() = %+v", link)
+	}
+
+	tests := []struct {
+		name     string
+		slug     string
+		password string
+		wantErr  string
+	}{
+		{name: "unknown slug", slug: "missing", wantErr: "share link not found"},
+		{name: "no password", slug: link.Slug, wantErr: "password required"},
+		{name: "wrong password", slug: link.Slug, password: "wrong", wantErr: "incorrect password"},
+		{name: "first view", slug: link.Slug, password: password},
+		{name: "second view", slug: link.Slug, password: password},
+		{name: "view limit reached", slug: link.Slug, password: password, wantErr: "share link expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared, err := shares.OpenShareLink(ctx, tt.slug, tt.password)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("OpenShareLink() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenShareLink() error = %v", err)
+			}
+			if shared.Title != note.Title {
+				t.Errorf("OpenShareLink() title = %q, want %q", shared.Title, note.Title)
+			}
+		})
+	}
+
+	links, err := shares.GetShareLinks(ctx, note.ID, note.UserID)
+	if err != nil {
+		t.Fatalf("GetShareLinks() error = %v", err)
+	}
+	if len(links) != 1 || links[0].ViewCount != 2 || links[0].Active || links[0].LastViewedAt == nil {
+		t.Errorf("GetShareLinks() = %+v, want one exhausted link with 2 views", links)
+	}
+}
+
+func TestShareService_RevokeAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	shares, note := newTestShareService(t)
+
+	// Only the owner manages links
+	if _, err := shares.CreateShareLink(ctx, note.ID, uuid.New(), &model.CreateShareLinkRequest{}); err == nil || err.Error() != "note not found" {
+		t.Errorf("CreateShareLink() by other user error = %v, want note not found", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, err := shares.CreateShareLink(ctx, note.ID, note.UserID, &model.CreateShareLinkRequest{ExpiresAt: &past}); err == nil {
+		t.Error("CreateShareLink() with past expiry succeeded")
+	}
+
+	first, _ := shares.CreateShareLink(ctx, note.ID, note.UserID, &model.CreateShareLinkRequest{})
+	second, _ := shares.CreateShareLink(ctx, note.ID, note.UserID, &model.CreateShareLinkRequest{})
+	if first.Slug == second.Slug {
+		t.Fatal("share links got the same slug")
+	}
+
+	if err := shares.RevokeShareLink(ctx, note.ID, first.ID, note.UserID); err != nil {
+		t.Fatalf("RevokeShareLink() error = %v", err)
+	}
+	if err := shares.RevokeShareLink(ctx, note.ID, first.ID, note.UserID); err == nil || err.Error() != "share link not found" {
+		t.Errorf("RevokeShareLink() twice error = %v, want share link not found", err)
+	}
+
+	if _, err := shares.OpenShareLink(ctx, first.Slug, ""); err == nil || err.Error() != "share link expired" {
+		t.Errorf("OpenShareLink() revoked error = %v, want share link expired", err)
+	}
+	if _, err := shares.OpenShareLink(ctx, second.Slug, ""); err != nil {
+		t.Errorf("OpenShareLink() other link error = %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/tracing"
+
+	"github.com/google/uuid"
+)
+
+// trashPurgeBatchSize bounds the notes deleted per purge statement
+const trashPurgeBatchSize = 500
+
+// TrashService removes deleted notes for good: after the trash retention of
+// their owner, or when the owner empties the trash. Deleted notes take
+// their share links and view statistics with them; notes have no
+// attachments.
+type TrashService struct {
+	noteRepo      repository.NoteRepository
+	userRepo      repository.UserRepository
+	notes         *NoteService
+	audit         *AuditService
+	retentionDays int
+}
+
+// NewTrashService creates a trash service keeping deleted notes for
+// retentionDays unless their owner set otherwise. Purges are not audited
+// when audit is nil.
+func NewTrashService(noteRepo repository.NoteRepository, userRepo repository.UserRepository, notes *NoteService, audit *AuditService, retentionDays int) *TrashService {
+	return &TrashService{
+		noteRepo:      noteRepo,
+		userRepo:      userRepo,
+		notes:         notes,
+		audit:         audit,
+		retentionDays: retentionDays,
+	}
+}
+
+// PurgeExpired purges the notes whose trash retention has passed. It is run
+// by the job scheduler.
+func (s *TrashService) PurgeExpired(ctx context.Context) error {
+	purged, err := s.Purge(ctx, time.Now())
+	if purged > 0 {
+		slog.InfoContext(ctx, "Purged trash", "notes", purged)
+	}
+	return err
+}
+
+// Purge hard deletes the notes whose trash retention has passed by now and
+// returns how many were deleted
+func (s *TrashService) Purge(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.Purge")
+	defer span.End()
+
+	total := 0
+	for {
+		purged, err := s.noteRepo.PurgeDeleted(ctx, now, s.retentionDays, trashPurgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += len(purged)
+
+		ids := make([]uuid.UUID, len(purged))
+		for i, note := range purged {
+			ids[i] = note.ID
+			if s.audit != nil {
+				event := model.CreateAuditEvent(model.EventTypeNote, model.ActionNotePurge, "note")
+				event.SetUser(note.UserID)
+				event.SetResourceID(note.ID.String())
+				event.SetDetails(fmt.Sprintf("trash retention passed; deleted at %s", note.DeletedAt.UTC().Format(time.RFC3339)))
+				s.audit.LogEvent(ctx, event)
+			}
+		}
+		if len(ids) > 0 {
+			s.notes.invalidateCache(ctx, false, ids...)
+		}
+
+		if len(purged) < trashPurgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// EmptyTrash hard deletes every deleted note of userID and returns how many
+// were deleted. ipAddress and userAgent identify the client in the audit log.
+func (s *TrashService) EmptyTrash(ctx context.Context, userID uuid.UUID, ipAddress string, userAgent *string) (int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.EmptyTrash", tracing.UserID(userID))
+	defer span.End()
+
+	ids, err := s.noteRepo.EmptyTrash(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 {
+		s.notes.invalidateCache(ctx, false, ids...)
+	}
+
+	if s.audit != nil {
+		event := model.CreateAuditEvent(model.EventTypeNote, model.ActionTrashEmpty, "note")
+		event.SetUser(userID)
+		event.SetClientInfo(ipAddress, userAgent)
+		event.SetDetails(fmt.Sprintf("%d notes deleted", len(ids)))
+		s.audit.LogEvent(ctx, event)
+	}
+
+	return len(ids), nil
+}
+
+// GetTrash lists the deleted notes of userID with the days left before each
+// is purged
+func (s *TrashService) GetTrash(ctx context.Context, userID uuid.UUID, params *model.GetNotesParams) (*model.NotesListResponse, error) {
+	params.Status = string(model.NoteStatusDeleted)
+	list, err := s.notes.GetUserNotes(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	days := s.retentionDays
+	if user != nil && user.TrashRetentionDays != nil {
+		days = *user.TrashRetentionDays
+	}
+
+	now := time.Now()
+	for i := range list.Notes {
+		if deletedAt := list.Notes[i].DeletedAt; deletedAt != nil {
+			left := daysUntilPurge(*deletedAt, days, now)
+			list.Notes[i].DaysUntilPurge = &left
+		}
+	}
+	return list, nil
+}
+
+// daysUntilPurge returns the whole days, rounded up, until a note deleted
+// at deletedAt is purged after retentionDays
+func daysUntilPurge(deletedAt time.Time, retentionDays int, now time.Time) int {
+	left := deletedAt.AddDate(0, 0, retentionDays).Sub(now)
+	if left <= 0 {
+		return 0
+	}
+	return int(math.Ceil(left.Hours() / 24))
+}
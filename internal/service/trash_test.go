@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestTrashService(t *testing.T) {
+	ctx := context.Background()
+	noteService, noteRepo, userRepo := newTestNoteService()
+	noteRepo.SetUsers(userRepo)
+	trash := NewTrashService(noteRepo, userRepo, noteService, nil, 30)
+
+	days := 7
+	user := &model.User{ID: uuid.New(), Email: "user@example.com", Password: "hash", FullName: "User", TrashRetentionDays: &days, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	other := &model.User{ID: uuid.New(), Email: "other@example.com", Password: "hash", FullName: "Other", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	userRepo.Create(ctx, user)
+	userRepo.Create(ctx, other)
+
+	now := time.Now()
+	trashNote := func(userID uuid.UUID, title string, ago time.Duration) *model.Note {
+		deletedAt := now.Add(-ago)
+		note := &model.Note{ID: uuid.New(), UserID: userID, Title: title, Status: model.NoteStatusDeleted, DeletedAt: &deletedAt}
+		noteRepo.Create(ctx, note)
+		return note
+	}
+	expired := trashNote(user.ID, "Expired", 8*24*time.Hour)
+	fresh := trashNote(user.ID, "Fresh", 36*time.Hour)
+	defaulted := trashNote(other.ID, "Default retention", 8*24*time.Hour)
+
+	list, err := trash.GetTrash(ctx, user.ID, &model.GetNotesParams{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("GetTrash() error = %v", err)
+	}
+	want := map[uuid.UUID]int{expired.ID: 0, fresh.ID: 6}
+	for _, item := range list.Notes {
+		if item.DaysUntilPurge == nil || *item.DaysUntilPurge != want[item.ID] {
+			t.Errorf("%q days_until_purge = %v, want %d", item.Title, item.DaysUntilPurge, want[item.ID])
+		}
+	}
+
+	// Only the note past its owner's seven days goes; the other user keeps thirty
+	purged, err := trash.Purge(ctx, now)
+	if err != nil || purged != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", purged, err)
+	}
+	if note, _ := noteRepo.GetByID(ctx, expired.ID); note != nil {
+		t.Error("expired note was not purged")
+	}
+	if note, _ := noteRepo.GetByID(ctx, defaulted.ID); note == nil {
+		t.Error("note within the default retention was purged")
+	}
+
+	deleted, err := trash.EmptyTrash(ctx, user.ID, "192.0.2.1", nil)
+	if err != nil || deleted != 1 {
+		t.Fatalf("EmptyTrash() = %d, %v, want 1", deleted, err)
+	}
+	if note, _ := noteRepo.GetByID(ctx, fresh.ID); note != nil {
+		t.Error("EmptyTrash() left a deleted note")
+	}
+}
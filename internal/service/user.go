@@ -1,49 +1,56 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
+	"gonotes/internal/metrics"
 	"gonotes/internal/model"
 	"gonotes/internal/repository"
+	"gonotes/internal/store"
+	"gonotes/internal/tracing"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
 // UserService handles business logic for users
 type UserService struct {
-	userRepo    *repository.UserRepository
-	redisClient *redis.Client
+	userRepo repository.UserRepository
+	cache    store.Store
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(userRepo repository.UserRepository) *UserService {
 	return &UserService{
 		userRepo: userRepo,
 	}
 }
 
-// NewUserServiceWithRedis creates a new user service with Redis caching
-func NewUserServiceWithRedis(userRepo *repository.UserRepository, redisClient *redis.Client) *UserService {
+// NewUserServiceWithCache creates a new user service with profile caching
+func NewUserServiceWithCache(userRepo repository.UserRepository, cache store.Store) *UserService {
 	return &UserService{
-		userRepo:    userRepo,
-		redisClient: redisClient,
+		userRepo: userRepo,
+		cache:    cache,
 	}
 }
 
 // Register creates a new user account
-func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error) {
+func (s *UserService) Register(ctx context.Context, req *model.RegisterRequest) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
 	// Validate request
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %s", utils.FormatValidationError(err))
 	}
 
 	// Check if email already exists
-	exists, err := s.userRepo.EmailExists(req.Email)
+	exists, err := s.userRepo.EmailExists(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check email existence: %w", err)
 	}
@@ -68,7 +75,7 @@ func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error)
 	}
 
 	// Save to database
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -76,32 +83,39 @@ func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error)
 }
 
 // Login authenticates a user
-func (s *UserService) Login(req *model.LoginRequest) (*model.User, error) {
+func (s *UserService) Login(ctx context.Context, req *model.LoginRequest) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
 	// Validate request
 	if err := utils.ValidateStruct(req); err != nil {
+		metrics.FailedLogins.WithLabelValues("invalid_request").Inc()
 		return nil, fmt.Errorf("validation failed: %s", utils.FormatValidationError(err))
 	}
 
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 	if user == nil {
+		metrics.FailedLogins.WithLabelValues("unknown_user").Inc()
 		return nil, fmt.Errorf("invalid email or password")
 	}
 
 	// Verify password
 	if err := utils.VerifyPassword(user.Password, req.Password); err != nil {
+		metrics.FailedLogins.WithLabelValues("wrong_password").Inc()
 		return nil, fmt.Errorf("invalid email or password")
 	}
 
+	metrics.Logins.Inc()
 	return user, nil
 }
 
 // GetByID retrieves a user by ID
-func (s *UserService) GetByID(id uuid.UUID) (*model.User, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -112,7 +126,10 @@ func (s *UserService) GetByID(id uuid.UUID) (*model.User, error) {
 }
 
 // UpdateProfile updates a user's profile information
-func (s *UserService) UpdateProfile(userID uuid.UUID, req *model.UpdateProfileRequest) (*model.User, error) {
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *model.UpdateProfileRequest) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile", tracing.UserID(userID))
+	defer span.End()
+
 	// Validate request
 	if err := utils.ValidateStruct(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %s", utils.FormatValidationError(err))
@@ -123,7 +140,7 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, req *model.UpdateProfileRe
 	req.FullName = strings.TrimSpace(req.FullName)
 
 	// Get current user
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -133,7 +150,7 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, req *model.UpdateProfileRe
 
 	// Check if email is being changed and if the new email already exists
 	if req.Email != user.Email {
-		exists, err := s.userRepo.EmailExistsExcludingUser(req.Email, userID)
+		exists, err := s.userRepo.EmailExistsExcludingUser(ctx, req.Email, userID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check email existence: %w", err)
 		}
@@ -148,26 +165,70 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, req *model.UpdateProfileRe
 	user.UpdatedAt = time.Now()
 
 	// Save to database
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
 	// Invalidate profile cache after update
-	if s.redisClient != nil {
-		if err := utils.InvalidateProfileCache(s.redisClient, userID.String()); err != nil {
+	if s.cache != nil {
+		if err := utils.InvalidateProfileCache(ctx, s.cache, userID.String()); err != nil {
 			// Log error but don't fail the request
-			fmt.Printf("Failed to invalidate profile cache: %v\n", err)
+			slog.WarnContext(ctx, "Failed to invalidate profile cache", "user_id", userID, "error", err)
 		}
 	}
 
 	return user, nil
 }
 
-// GetProfileWithCache retrieves user profile with Redis caching
-func (s *UserService) GetProfileWithCache(userID uuid.UUID) (*model.UserResponse, error) {
+// GetSettings returns a user's preferences
+func (s *UserService) GetSettings(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Settings(), nil
+}
+
+// UpdateSettings changes the preferences set in req
+func (s *UserService) UpdateSettings(ctx context.Context, userID uuid.UUID, req *model.UpdateSettingsRequest) (*model.UserSettings, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateSettings", tracing.UserID(userID))
+	defer span.End()
+
+	if err := utils.ValidateStruct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %s", utils.FormatValidationError(err))
+	}
+
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FeedEnabled != nil {
+		user.FeedOptOut = !*req.FeedEnabled
+	}
+	if req.TrashRetentionDays != nil {
+		user.TrashRetentionDays = req.TrashRetentionDays
+		if *req.TrashRetentionDays == 0 {
+			user.TrashRetentionDays = nil
+		}
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user.Settings(), nil
+}
+
+// GetProfileWithCache retrieves user profile with caching
+func (s *UserService) GetProfileWithCache(ctx context.Context, userID uuid.UUID) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfileWithCache", tracing.UserID(userID))
+	defer span.End()
+
 	// Try to get from cache first
-	if s.redisClient != nil {
-		cachedProfile, err := utils.GetProfileCache(s.redisClient, userID.String())
+	if s.cache != nil {
+		cachedProfile, err := utils.GetProfileCache(ctx, s.cache, userID.String())
 		if err == nil && cachedProfile != "" {
 			var profile model.UserResponse
 			if err := json.Unmarshal([]byte(cachedProfile), &profile); err == nil {
@@ -177,7 +238,7 @@ func (s *UserService) GetProfileWithCache(userID uuid.UUID) (*model.UserResponse
 	}
 
 	// Get from database
-	user, err := s.GetByID(userID)
+	user, err := s.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -185,13 +246,13 @@ func (s *UserService) GetProfileWithCache(userID uuid.UUID) (*model.UserResponse
 	profile := user.ToResponse()
 
 	// Cache the profile
-	if s.redisClient != nil {
+	if s.cache != nil {
 		profileJSON, err := json.Marshal(profile)
 		if err == nil {
 			// Cache for 3 minutes (180 seconds)
-			if err := utils.SetProfileCache(s.redisClient, userID.String(), profileJSON, 180*time.Second); err != nil {
+			if err := utils.SetProfileCache(ctx, s.cache, userID.String(), profileJSON, 180*time.Second); err != nil {
 				// Log error but don't fail the request
-				fmt.Printf("Failed to cache profile: %v\n", err)
+				slog.WarnContext(ctx, "Failed to cache profile", "user_id", userID, "error", err)
 			}
 		}
 	}
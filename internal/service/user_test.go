@@ -1,146 +1,21 @@
 package service
 
 import (
-	"fmt"
+	"context"
 	"testing"
 	"time"
 
 	"gonotes/internal/model"
+	"gonotes/internal/repository"
 	"gonotes/internal/utils"
 
 	"github.com/google/uuid"
 )
 
-// TestUserService for testing with interfaces
-type TestUserService struct {
-	userRepo  UserRepositoryInterface
-	validator *utils.Validator
-}
-
-func NewTestUserService(userRepo UserRepositoryInterface, validator *utils.Validator) *TestUserService {
-	return &TestUserService{
-		userRepo:  userRepo,
-		validator: validator,
-	}
-}
-
-// Delegate all methods to the actual service methods but with interface dependencies
-func (s *TestUserService) Register(req *model.RegisterRequest) (*model.User, error) {
-	// Validate request
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
-	}
-
-	// Check if email already exists
-	exists, err := s.userRepo.EmailExists(req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check email existence: %w", err)
-	}
-	if exists {
-		return nil, fmt.Errorf("email already exists")
-	}
-
-	// Hash password
-	hashedPassword, err := utils.HashPassword(req.Password)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
-	}
-
-	// Create user
-	user := &model.User{
-		ID:        uuid.New(),
-		Email:     req.Email,
-		Password:  hashedPassword,
-		FullName:  req.FullName,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
-
-	return user, nil
-}
-
-func (s *TestUserService) Login(req *model.LoginRequest) (*model.User, error) {
-	// Validate request
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
-	}
-
-	// Get user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("invalid email or password")
-	}
-
-	// Verify password
-	if err := utils.VerifyPassword(user.Password, req.Password); err != nil {
-		return nil, fmt.Errorf("invalid email or password")
-	}
-
-	return user, nil
-}
-
-func (s *TestUserService) GetByID(userID uuid.UUID) (*model.User, error) {
-	user, err := s.userRepo.GetByID(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
-	}
-	return user, nil
-}
-
-func (s *TestUserService) UpdateProfile(userID uuid.UUID, req *model.UpdateProfileRequest) (*model.User, error) {
-	// Validate request
-	if err := s.validator.ValidateStruct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
-	}
-
-	// Get existing user
-	user, err := s.userRepo.GetByID(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
-	}
-
-	// Check if email is being changed to an existing email
-	if req.Email != user.Email {
-		exists, err := s.userRepo.EmailExistsExcludingUser(req.Email, userID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check email existence: %w", err)
-		}
-		if exists {
-			return nil, fmt.Errorf("email already exists")
-		}
-	}
-
-	// Update user fields
-	user.Email = req.Email
-	user.FullName = req.FullName
-	user.UpdatedAt = time.Now()
-
-	// Update in database
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
-	}
-
-	return user, nil
-}
-
 // Test User Registration
 func TestUserService_Register(t *testing.T) {
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	userService := NewTestUserService(userRepo, validator)
+	ctx := context.Background()
+	userService := NewUserService(repository.NewMemoryUserRepository())
 
 	tests := []struct {
 		name          string
@@ -201,7 +76,7 @@ func TestUserService_Register(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := userService.Register(tt.request)
+			user, err := userService.Register(ctx, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -247,9 +122,9 @@ func TestUserService_Register(t *testing.T) {
 
 // Test User Login
 func TestUserService_Login(t *testing.T) {
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	userService := NewTestUserService(userRepo, validator)
+	ctx := context.Background()
+	userRepo := repository.NewMemoryUserRepository()
+	userService := NewUserService(userRepo)
 
 	// First register a user
 	hashedPassword, _ := utils.HashPassword("password123")
@@ -261,7 +136,7 @@ func TestUserService_Login(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	tests := []struct {
 		name          string
@@ -308,7 +183,7 @@ func TestUserService_Login(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := userService.Login(tt.request)
+			user, err := userService.Login(ctx, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -340,9 +215,9 @@ func TestUserService_Login(t *testing.T) {
 
 // Test Update Profile
 func TestUserService_UpdateProfile(t *testing.T) {
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	userService := NewTestUserService(userRepo, validator)
+	ctx := context.Background()
+	userRepo := repository.NewMemoryUserRepository()
+	userService := NewUserService(userRepo)
 
 	// First register a user
 	testUser := &model.User{
@@ -353,7 +228,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	// Register another user for email conflict testing
 	anotherUser := &model.User{
@@ -364,7 +239,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	userRepo.Create(anotherUser)
+	userRepo.Create(ctx, anotherUser)
 
 	tests := []struct {
 		name          string
@@ -426,7 +301,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := userService.UpdateProfile(tt.userID, tt.request)
+			user, err := userService.UpdateProfile(ctx, tt.userID, tt.request)
 
 			if tt.expectError {
 				if err == nil {
@@ -462,9 +337,9 @@ func TestUserService_UpdateProfile(t *testing.T) {
 
 // Test Get By ID
 func TestUserService_GetByID(t *testing.T) {
-	userRepo := NewMockUserRepository()
-	validator := utils.NewValidator()
-	userService := NewTestUserService(userRepo, validator)
+	ctx := context.Background()
+	userRepo := repository.NewMemoryUserRepository()
+	userService := NewUserService(userRepo)
 
 	// Register a test user
 	testUser := &model.User{
@@ -475,7 +350,7 @@ func TestUserService_GetByID(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	userRepo.Create(testUser)
+	userRepo.Create(ctx, testUser)
 
 	tests := []struct {
 		name          string
@@ -498,7 +373,7 @@ func TestUserService_GetByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := userService.GetByID(tt.userID)
+			user, err := userService.GetByID(ctx, tt.userID)
 
 			if tt.expectError {
 				if err == nil {
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gonotes/internal/metrics"
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// dailyViewersTTL keeps the set of a day's viewers a little past the day
+const dailyViewersTTL = 48 * time.Hour
+
+// Analytics periods in days
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 90
+)
+
+// directReferrer names views without a referrer in analytics
+const directReferrer = "direct"
+
+// ViewCounter counts note views. A viewer is counted once per note per
+// dedup window, tracked as a set in the key-value store; counted views are
+// buffered in memory and added to the notes and the daily rollup in batches.
+type ViewCounter struct {
+	noteRepo  repository.NoteRepository
+	statsRepo repository.ViewStatsRepository
+	tx        repository.TxManager
+	store     store.Store
+	window    time.Duration
+	interval  time.Duration
+
+	mu      sync.Mutex
+	pending map[viewKey]*viewTally
+}
+
+// viewKey is the rollup row a buffered view belongs to
+type viewKey struct {
+	noteID   uuid.UUID
+	day      time.Time
+	referrer string
+}
+
+// viewTally holds buffered views of a rollup row
+type viewTally struct {
+	views         int64
+	uniqueViewers int64
+}
+
+// NewViewCounter creates a view counter that deduplicates viewers within
+// window and flushes counted views every interval
+func NewViewCounter(noteRepo repository.NoteRepository, statsRepo repository.ViewStatsRepository, tx repository.TxManager, st store.Store, window, interval time.Duration) *ViewCounter {
+	return &ViewCounter{
+		noteRepo:  noteRepo,
+		statsRepo: statsRepo,
+		tx:        tx,
+		store:     st,
+		window:    window,
+		interval:  interval,
+		pending:   make(map[viewKey]*viewTally),
+	}
+}
+
+// Record counts a view of noteID by viewer unless the viewer was already
+// counted in the current window. referrer is the Referer header of the
+// request, if any. When the store is unavailable the view is counted.
+func (c *ViewCounter) Record(ctx context.Context, noteID uuid.UUID, viewer, referrer string) {
+	now := time.Now()
+
+	if c.window > 0 {
+		// Fixed windows, so every instance agrees on the set to check
+		bucket := now.UnixNano() / int64(c.window)
+		key := fmt.Sprintf("views:%s:%d", noteID, bucket)
+		added, err := c.store.SetAdd(ctx, key, viewer, c.window)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to deduplicate view", "note_id", noteID, "error", err)
+		} else if !added {
+			metrics.NoteViews.WithLabelValues("duplicate").Inc()
+			return
+		}
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	// A viewer is unique once per day, attributed to the referrer of their first view
+	unique, err := c.store.SetAdd(ctx, fmt.Sprintf("viewers:%s:%s", noteID, day.Format("2006-01-02")), viewer, dailyViewersTTL)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to track daily viewer", "note_id", noteID, "error", err)
+	}
+
+	metrics.NoteViews.WithLabelValues("counted").Inc()
+	key := viewKey{noteID: noteID, day: day, referrer: referrerDomain(referrer)}
+
+	c.mu.Lock()
+	tally, ok := c.pending[key]
+	if !ok {
+		tally = &viewTally{}
+		c.pending[key] = tally
+	}
+	tally.views++
+	if unique {
+		tally.uniqueViewers++
+	}
+	c.mu.Unlock()
+}
+
+// Run flushes buffered views every interval until ctx is cancelled
+func (c *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Flush(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to flush view counts", "error", err)
+		}
+	}
+}
+
+// Flush adds the buffered views to the notes and the daily rollup in one
+// transaction. On failure they are kept for the next flush.
+func (c *ViewCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[viewKey]*viewTally)
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	counts := make(map[uuid.UUID]int64)
+	stats := make([]model.NoteViewStat, 0, len(pending))
+	for key, tally := range pending {
+		counts[key.noteID] += tally.views
+		stats = append(stats, model.NoteViewStat{
+			NoteID:        key.noteID,
+			Day:           key.day,
+			Referrer:      key.referrer,
+			Views:         tally.views,
+			UniqueViewers: tally.uniqueViewers,
+		})
+	}
+
+	err := c.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := c.noteRepo.AddViewCounts(ctx, counts); err != nil {
+			return err
+		}
+		return c.statsRepo.Add(ctx, stats)
+	})
+	if err != nil {
+		c.mu.Lock()
+		for key, tally := range pending {
+			if current, ok := c.pending[key]; ok {
+				current.views += tally.views
+				current.uniqueViewers += tally.uniqueViewers
+			} else {
+				c.pending[key] = tally
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Analytics returns the daily views and referrers of noteID over the last days days
+func (c *ViewCounter) Analytics(ctx context.Context, noteID uuid.UUID, days int) (*model.ViewAnalytics, error) {
+	since := analyticsSince(days)
+	stats, err := c.statsRepo.GetByNote(ctx, noteID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note analytics: %w", err)
+	}
+
+	analytics := summarizeViews(stats, since, days)
+	analytics.NoteID = &noteID
+	return analytics, nil
+}
+
+// Summary returns the views and referrers of all public notes of userID
+// over the last days days, without the daily series
+func (c *ViewCounter) Summary(ctx context.Context, userID uuid.UUID, days int) (*model.ViewAnalytics, error) {
+	since := analyticsSince(days)
+	stats, err := c.statsRepo.GetByUser(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get view summary: %w", err)
+	}
+
+	analytics := summarizeViews(stats, since, days)
+	analytics.Daily = nil
+	return analytics, nil
+}
+
+// analyticsSince returns the first day of a period of days days ending today
+func analyticsSince(days int) time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+}
+
+// summarizeViews totals rollup rows into a daily series starting at since,
+// with days without views included, and referrers ordered by views
+func summarizeViews(stats []model.NoteViewStat, since time.Time, days int) *model.ViewAnalytics {
+	analytics := &model.ViewAnalytics{
+		Days:      days,
+		Daily:     make([]model.DailyViews, days),
+		Referrers: []model.ReferrerViews{},
+	}
+	for i := range analytics.Daily {
+		analytics.Daily[i].Date = since.AddDate(0, 0, i).Format("2006-01-02")
+	}
+
+	referrers := make(map[string]int64)
+	for _, stat := range stats {
+		analytics.TotalViews += stat.Views
+		analytics.DailyUniqueViewersSum += stat.UniqueViewers
+
+		if i := int(stat.Day.UTC().Sub(since) / (24 * time.Hour)); i >= 0 && i < days {
+			analytics.Daily[i].Views += stat.Views
+			analytics.Daily[i].UniqueViewers += stat.UniqueViewers
+		}
+
+		referrer := stat.Referrer
+		if referrer == "" {
+			referrer = directReferrer
+		}
+		referrers[referrer] += stat.Views
+	}
+
+	for referrer, views := range referrers {
+		analytics.Referrers = append(analytics.Referrers, model.ReferrerViews{Referrer: referrer, Views: views})
+	}
+	sort.Slice(analytics.Referrers, func(i, j int) bool {
+		a, b := analytics.Referrers[i], analytics.Referrers[j]
+		if a.Views != b.Views {
+			return a.Views > b.Views
+		}
+		return a.Referrer < b.Referrer
+	})
+
+	return analytics
+}
+
+// referrerDomain returns the host of a Referer header, lowercased and
+// without "www.", or "" when there is none
+func referrerDomain(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gonotes/internal/model"
+	"gonotes/internal/repository"
+	"gonotes/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func TestViewCounter_RecordAndFlush(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := repository.NewMemoryNoteRepository()
+	views := NewViewCounter(noteRepo, repository.NewMemoryViewStatsRepository(noteRepo), repository.NewMemoryTxManager(), store.NewMemoryStore(), time.Minute, time.Second)
+
+	note := &model.Note{ID: uuid.New(), UserID: uuid.New(), Title: "Viewed", Status: model.NoteStatusActive, IsPublic: true}
+	if err := noteRepo.Create(ctx, note); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	before, _ := noteRepo.GetByID(ctx, note.ID)
+
+	// Repeated views by the same viewer count once per window
+	for i := 0; i < 3; i++ {
+		views.Record(ctx, note.ID, "user:a", "")
+	}
+	views.Record(ctx, note.ID, "user:b", "")
+
+	stored, _ := noteRepo.GetByID(ctx, note.ID)
+	if stored.ViewCount != 0 {
+		t.Errorf("ViewCount before flush = %d, want 0", stored.ViewCount)
+	}
+
+	if err := views.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	stored, _ = noteRepo.GetByID(ctx, note.ID)
+	if stored.ViewCount != 2 {
+		t.Errorf("ViewCount after flush = %d, want 2", stored.ViewCount)
+	}
+	if !stored.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("counting views changed updated_at")
+	}
+
+	// Nothing left to flush
+	if err := views.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	stored, _ = noteRepo.GetByID(ctx, note.ID)
+	if stored.ViewCount != 2 {
+		t.Errorf("ViewCount after second flush = %d, want 2", stored.ViewCount)
+	}
+}
+
+func TestViewCounter_Analytics(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := repository.NewMemoryNoteRepository()
+	views := NewViewCounter(noteRepo, repository.NewMemoryViewStatsRepository(noteRepo), repository.NewMemoryTxManager(), store.NewMemoryStore(), time.Minute, time.Second)
+
+	userID := uuid.New()
+	public := &model.Note{ID: uuid.New(), UserID: userID, Title: "Public", Status: model.NoteStatusActive, IsPublic: true}
+	private := &model.Note{ID: uuid.New(), UserID: userID, Title: "Private", Status: model.NoteStatusActive}
+	for _, note := range []*model.Note{public, private} {
+		if err := noteRepo.Create(ctx, note); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	views.Record(ctx, public.ID, "user:a", "https://www.Example.com/post")
+	views.Record(ctx, public.ID, "user:b", "https://example.com/other")
+	views.Record(ctx, public.ID, "user:c", "")
+	views.Record(ctx, private.ID, "user:a", "")
+	// Views of deleted notes are dropped on flush
+	views.Record(ctx, uuid.New(), "user:a", "")
+	if err := views.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	analytics, err := views.Analytics(ctx, public.ID, 7)
+	if err != nil {
+		t.Fatalf("Analytics() error = %v", err)
+	}
+	if analytics.TotalViews != 3 || analytics.DailyUniqueViewersSum != 3 {
+		t.Errorf("Analytics() totals = %d views, %d unique, want 3 and 3", analytics.TotalViews, analytics.DailyUniqueViewersSum)
+	}
+	if len(analytics.Daily) != 7 {
+		t.Fatalf("Analytics() daily series has %d days, want 7", len(analytics.Daily))
+	}
+	today := analytics.Daily[6]
+	if today.Date != time.Now().UTC().Format("2006-01-02") || today.Views != 3 {
+		t.Errorf("Analytics() today = %+v, want 3 views", today)
+	}
+	if analytics.Daily[0].Views != 0 {
+		t.Errorf("Analytics() first day views = %d, want 0", analytics.Daily[0].Views)
+	}
+	want := []model.ReferrerViews{{Referrer: "example.com", Views: 2}, {Referrer: "direct", Views: 1}}
+	if len(analytics.Referrers) != len(want) {
+		t.Fatalf("Analytics() referrers = %+v, want %+v", analytics.Referrers, want)
+	}
+	for i := range want {
+		if analytics.Referrers[i] != want[i] {
+			t.Errorf("Analytics() referrer %d = %+v, want %+v", i, analytics.Referrers[i], want[i])
+		}
+	}
+
+	// The summary only covers public notes
+	summary, err := views.Summary(ctx, userID, 30)
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	if summary.TotalViews != 3 {
+		t.Errorf("Summary() views = %d, want 3", summary.TotalViews)
+	}
+	if summary.Daily != nil {
+		t.Error("Summary() included a daily series")
+	}
+}
@@ -0,0 +1,486 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replayTimeout bounds replaying the writes made while degraded
+const replayTimeout = 30 * time.Second
+
+// BreakerOptions configures the circuit breaker of a FailoverStore
+type BreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before the primary is retried
+	Cooldown time.Duration
+}
+
+// DefaultBreakerOptions returns the default circuit breaker settings
+func DefaultBreakerOptions() BreakerOptions {
+	return BreakerOptions{
+		FailureThreshold: 3,
+		Cooldown:         10 * time.Second,
+	}
+}
+
+// pendingWrite is the last Set or Delete of a key made while degraded
+type pendingWrite struct {
+	value     string
+	expiresAt time.Time // zero means no expiration
+	deleted   bool
+}
+
+// FailoverStore routes operations to a primary store and degrades to a
+// fallback store while the primary is unhealthy. Rate limits and counters
+// keep working on the fallback with local (per-instance) state. Values set
+// and keys deleted while degraded are replayed to the primary once it
+// recovers, so tokens issued and caches invalidated during an outage are not
+// lost; counters and limits are not replayed. Callers relying on a counter,
+// such as a cache generation, register OnRecover hooks instead.
+type FailoverStore struct {
+	primary  Store
+	fallback Store
+	opts     BreakerOptions
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	onRecover []func(ctx context.Context)
+
+	pendingMu sync.Mutex
+	pending   map[string]pendingWrite
+	inflight  map[string]chan struct{} // keys being replayed, closed when done
+	dirty     atomic.Bool              // pending may hold writes
+	replaying atomic.Bool
+}
+
+// NewFailoverStore creates a store with a circuit breaker around primary
+func NewFailoverStore(primary, fallback Store, opts BreakerOptions) *FailoverStore {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultBreakerOptions().FailureThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultBreakerOptions().Cooldown
+	}
+
+	return &FailoverStore{
+		primary:  primary,
+		fallback: fallback,
+		opts:     opts,
+		pending:  make(map[string]pendingWrite),
+		inflight: make(map[string]chan struct{}),
+	}
+}
+
+// Trip opens the circuit immediately, e.g. when the primary is unreachable at startup
+func (s *FailoverStore) Trip() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = s.opts.FailureThreshold
+	s.openUntil = time.Now().Add(s.opts.Cooldown)
+}
+
+// OnRecover registers fn to run in the background each time the primary
+// recovers, e.g. to bump counters that were only incremented on the fallback
+func (s *FailoverStore) OnRecover(fn func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onRecover = append(s.onRecover, fn)
+}
+
+// Degraded reports whether operations are currently served by the fallback
+func (s *FailoverStore) Degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failures >= s.opts.FailureThreshold
+}
+
+// Get returns the value for key. While degraded, a miss on the fallback is
+// reported as ErrUnavailable because the key may exist in the primary. Keys
+// written while degraded are answered from those writes until they have been
+// replayed.
+func (s *FailoverStore) Get(ctx context.Context, key string) (string, bool, error) {
+	if s.usePrimary() {
+		val, found, err := s.primary.Get(ctx, key)
+		if !s.record(err) {
+			if err == nil {
+				if w, ok := s.pendingWrite(key); ok {
+					return w.value, !w.deleted, nil
+				}
+			}
+			return val, found, err
+		}
+	}
+
+	val, found, err := s.fallback.Get(ctx, key)
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		if w, ok := s.pendingWrite(key); ok && w.deleted {
+			return "", false, nil
+		}
+		return "", false, ErrUnavailable
+	}
+	return val, true, nil
+}
+
+// Set stores a value
+func (s *FailoverStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if s.usePrimary() {
+		s.forget(ctx, key)
+		err := s.primary.Set(ctx, key, value, ttl)
+		if !s.record(err) {
+			return err
+		}
+	}
+	if err := s.fallback.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	s.track(key, pendingWrite{value: value, expiresAt: expiresAt(ttl)})
+	return nil
+}
+
+// Delete removes keys from both stores so stale fallback entries do not linger
+func (s *FailoverStore) Delete(ctx context.Context, keys ...string) error {
+	fallbackErr := s.fallback.Delete(ctx, keys...)
+	if s.usePrimary() {
+		s.forget(ctx, keys...)
+		err := s.primary.Delete(ctx, keys...)
+		if !s.record(err) {
+			return err
+		}
+	}
+	for _, key := range keys {
+		s.track(key, pendingWrite{deleted: true})
+	}
+	return fallbackErr
+}
+
+// Incr increments a counter
+func (s *FailoverStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if s.usePrimary() {
+		val, err := s.primary.Incr(ctx, key, ttl)
+		if !s.record(err) {
+			return val, err
+		}
+	}
+	return s.fallback.Incr(ctx, key, ttl)
+}
+
+// SetAdd adds member to the set at key
+func (s *FailoverStore) SetAdd(ctx context.Context, key, member string, ttl time.Duration) (bool, error) {
+	if s.usePrimary() {
+		added, err := s.primary.SetAdd(ctx, key, member, ttl)
+		if !s.record(err) {
+			return added, err
+		}
+	}
+	return s.fallback.SetAdd(ctx, key, member, ttl)
+}
+
+// SetNX stores a value only if key does not exist. Keys claimed on the
+// fallback only exclude callers of this instance.
+func (s *FailoverStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if s.usePrimary() {
+		stored, err := s.primary.SetNX(ctx, key, value, ttl)
+		if !s.record(err) {
+			return stored, err
+		}
+	}
+	stored, err := s.fallback.SetNX(ctx, key, value, ttl)
+	if stored {
+		s.track(key, pendingWrite{value: value, expiresAt: expiresAt(ttl)})
+	}
+	return stored, err
+}
+
+// SweepPersistent sweeps the primary store if it supports sweeping. Nothing
+// is swept while degraded; the next sweep catches up.
+func (s *FailoverStore) SweepPersistent(ctx context.Context, pattern string) (int, error) {
+	sweeper, ok := s.primary.(KeySweeper)
+	if !ok || !s.usePrimary() {
+		return 0, nil
+	}
+	deleted, err := sweeper.SweepPersistent(ctx, pattern)
+	s.record(err)
+	return deleted, err
+}
+
+// Expire updates the expiration of a key
+func (s *FailoverStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if s.usePrimary() {
+		err := s.primary.Expire(ctx, key, ttl)
+		if !s.record(err) {
+			return err
+		}
+	}
+	return s.fallback.Expire(ctx, key, ttl)
+}
+
+// TokenBucket takes one token from the bucket stored at key
+func (s *FailoverStore) TokenBucket(ctx context.Context, key string, capacity, limit int, window time.Duration) (*LimitResult, error) {
+	if s.usePrimary() {
+		result, err := s.primary.TokenBucket(ctx, key, capacity, limit, window)
+		if !s.record(err) {
+			return result, err
+		}
+	}
+	return s.fallback.TokenBucket(ctx, key, capacity, limit, window)
+}
+
+// SlidingWindow records a request in the window stored at key
+func (s *FailoverStore) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*LimitResult, error) {
+	if s.usePrimary() {
+		result, err := s.primary.SlidingWindow(ctx, key, limit, window)
+		if !s.record(err) {
+			return result, err
+		}
+	}
+	return s.fallback.SlidingWindow(ctx, key, limit, window)
+}
+
+// Ping checks the primary store, returning an error while degraded
+func (s *FailoverStore) Ping(ctx context.Context) error {
+	err := s.primary.Ping(ctx)
+	if err == nil && s.Degraded() {
+		// Primary is reachable again, let the next operation close the circuit
+		s.mu.Lock()
+		s.openUntil = time.Time{}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// PoolStats returns the pool statistics of the primary store, if it has a pool
+func (s *FailoverStore) PoolStats() *PoolStats {
+	if provider, ok := s.primary.(PoolStatsProvider); ok {
+		return provider.PoolStats()
+	}
+	return nil
+}
+
+// Close closes both stores
+func (s *FailoverStore) Close() error {
+	return errors.Join(s.primary.Close(), s.fallback.Close())
+}
+
+// usePrimary decides whether an operation should try the primary store.
+// While open, a single probe is let through once the cooldown has elapsed.
+func (s *FailoverStore) usePrimary() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures < s.opts.FailureThreshold {
+		return true
+	}
+	if time.Now().Before(s.openUntil) || s.probing {
+		return false
+	}
+
+	s.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of a primary operation and
+// reports whether the operation should be retried on the fallback
+func (s *FailoverStore) record(err error) bool {
+	// Caller cancellations say nothing about the health of the store
+	failed := err != nil && !errors.Is(err, context.Canceled)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wasOpen := s.failures >= s.opts.FailureThreshold
+	s.probing = false
+
+	if !failed {
+		if wasOpen {
+			slog.Info("Store primary recovered, closing circuit")
+			go runRecoverHooks(append([]func(ctx context.Context){}, s.onRecover...))
+		}
+		s.failures = 0
+		if s.dirty.Load() && !s.replaying.Load() {
+			go s.replay()
+		}
+		return false
+	}
+
+	s.failures++
+	if s.failures >= s.opts.FailureThreshold {
+		if !wasOpen {
+			slog.Warn("Store primary unhealthy, degrading to fallback", "error", err)
+		}
+		s.openUntil = time.Now().Add(s.opts.Cooldown)
+	}
+
+	return true
+}
+
+// runRecoverHooks runs the hooks registered with OnRecover
+func runRecoverHooks(hooks []func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	defer cancel()
+
+	for _, fn := range hooks {
+		fn(ctx)
+	}
+}
+
+// track remembers a write made on the fallback for replay to the primary
+func (s *FailoverStore) track(key string, w pendingWrite) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	s.pending[key] = w
+	s.dirty.Store(true)
+}
+
+// forget drops pending writes of keys about to be written to the primary, so
+// a replay cannot overwrite the newer value. A key being replayed right now
+// is waited for, so the newer write lands afterwards.
+func (s *FailoverStore) forget(ctx context.Context, keys ...string) {
+	if !s.dirty.Load() {
+		return
+	}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	for _, key := range keys {
+		for {
+			done, ok := s.inflight[key]
+			if !ok {
+				break
+			}
+			s.pendingMu.Unlock()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+			s.pendingMu.Lock()
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		delete(s.pending, key)
+	}
+}
+
+// pendingWrite returns the unreplayed write of key, if any
+func (s *FailoverStore) pendingWrite(key string) (pendingWrite, bool) {
+	if !s.dirty.Load() {
+		return pendingWrite{}, false
+	}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	w, ok := s.pending[key]
+	if ok && !w.deleted && !w.expiresAt.IsZero() && !time.Now().Before(w.expiresAt) {
+		return pendingWrite{deleted: true}, true
+	}
+	return w, ok
+}
+
+// replay writes the pending writes to the primary. It stops at the first
+// failure; the rest is replayed after the next successful primary operation.
+func (s *FailoverStore) replay() {
+	if !s.replaying.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.replaying.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	defer cancel()
+
+	s.pendingMu.Lock()
+	keys := make([]string, 0, len(s.pending))
+	for key := range s.pending {
+		keys = append(keys, key)
+	}
+	s.pendingMu.Unlock()
+
+	for i, key := range keys {
+		if err := s.replayKey(ctx, key); err != nil {
+			slog.Warn("Failed to replay writes to store primary", "replayed", i, "remaining", len(keys)-i, "error", err)
+			s.record(err)
+			return
+		}
+	}
+
+	s.pendingMu.Lock()
+	if len(s.pending) == 0 {
+		s.dirty.Store(false)
+	}
+	s.pendingMu.Unlock()
+
+	if len(keys) > 0 {
+		slog.Info("Replayed writes made while degraded to store primary", "keys", len(keys))
+	}
+}
+
+// replayKey writes the pending write of key to the primary. The lock is not
+// held during the write, so lookups are not stalled by a slow primary; a
+// concurrent write of the same key waits in forget and lands afterwards. The
+// entry is dropped only if it was not replaced meanwhile.
+func (s *FailoverStore) replayKey(ctx context.Context, key string) error {
+	s.pendingMu.Lock()
+	w, ok := s.pending[key]
+	if !ok {
+		s.pendingMu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	s.inflight[key] = done
+	s.pendingMu.Unlock()
+
+	err := s.writePrimary(ctx, key, w)
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	delete(s.inflight, key)
+	close(done)
+	if err != nil {
+		return err
+	}
+	if current, ok := s.pending[key]; ok && current == w {
+		delete(s.pending, key)
+	}
+	return nil
+}
+
+// writePrimary applies a pending write to the primary; expired values are skipped
+func (s *FailoverStore) writePrimary(ctx context.Context, key string, w pendingWrite) error {
+	var err error
+	switch {
+	case w.deleted:
+		err = s.primary.Delete(ctx, key)
+	case w.expiresAt.IsZero():
+		err = s.primary.Set(ctx, key, w.value, 0)
+	default:
+		ttl := time.Until(w.expiresAt)
+		if ttl <= 0 {
+			break
+		}
+		err = s.primary.Set(ctx, key, w.value, ttl)
+	}
+	return err
+}
+
+// expiresAt returns when a key stored with ttl expires, or zero for none
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
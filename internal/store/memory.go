@@ -0,0 +1,353 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a value with an optional expiration
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // zero means no expiration
+}
+
+// expired checks if the entry is past its expiration
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// bucketState holds a token bucket
+type bucketState struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// windowState holds request timestamps of a sliding window
+type windowState struct {
+	hits      []time.Time
+	expiresAt time.Time
+}
+
+// setState holds the members of a set
+type setState struct {
+	members   map[string]struct{}
+	expiresAt time.Time // zero means no expiration
+}
+
+// MemoryStore implements Store in process memory. It is intended for
+// single-instance deployments, tests and as a fallback when Redis is down.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	buckets map[string]*bucketState
+	windows map[string]*windowState
+	sets    map[string]*setState
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryStore creates a new in-memory store with a background janitor
+// that evicts expired keys
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		buckets: make(map[string]*bucketState),
+		windows: make(map[string]*windowState),
+		sets:    make(map[string]*setState),
+		stop:    make(chan struct{}),
+	}
+	go s.janitor(time.Minute)
+	return s
+}
+
+// Get returns the value for key and whether it exists
+func (s *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookup(key, time.Now())
+	if entry == nil {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set stores a value with an optional expiration
+func (s *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+// Delete removes keys
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+		delete(s.buckets, key)
+		delete(s.windows, key)
+		delete(s.sets, key)
+	}
+	return nil
+}
+
+// Incr increments a counter, applying ttl when the counter is created
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry := s.lookup(key, now)
+	if entry == nil {
+		entry = &memoryEntry{value: "0"}
+		if ttl > 0 {
+			entry.expiresAt = now.Add(ttl)
+		}
+		s.entries[key] = entry
+	}
+
+	current, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value at %s is not an integer", key)
+	}
+	current++
+	entry.value = strconv.FormatInt(current, 10)
+
+	return current, nil
+}
+
+// Expire updates the expiration of an existing key
+func (s *MemoryStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.lookup(key, time.Now()); entry != nil {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+// SetAdd adds member to the set at key, applying ttl when the set is created
+func (s *MemoryStore) SetAdd(ctx context.Context, key, member string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	set, ok := s.sets[key]
+	if !ok || (!set.expiresAt.IsZero() && !now.Before(set.expiresAt)) {
+		set = &setState{members: make(map[string]struct{})}
+		if ttl > 0 {
+			set.expiresAt = now.Add(ttl)
+		}
+		s.sets[key] = set
+	}
+
+	if _, exists := set.members[member]; exists {
+		return false, nil
+	}
+	set.members[member] = struct{}{}
+	return true, nil
+}
+
+// SetNX stores a value only if key does not exist
+func (s *MemoryStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.lookup(key, now) != nil {
+		return false, nil
+	}
+
+	entry := &memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	s.entries[key] = entry
+	return true, nil
+}
+
+// SweepPersistent deletes values and sets matching pattern that have no expiration
+func (s *MemoryStore) SweepPersistent(ctx context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for key, entry := range s.entries {
+		if matched, _ := path.Match(pattern, key); matched && entry.expiresAt.IsZero() {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	for key, set := range s.sets {
+		if matched, _ := path.Match(pattern, key); matched && set.expiresAt.IsZero() {
+			delete(s.sets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// TokenBucket takes one token from the bucket stored at key
+func (s *MemoryStore) TokenBucket(ctx context.Context, key string, capacity, limit int, window time.Duration) (*LimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(limit) / float64(window) // tokens per nanosecond
+
+	bucket, ok := s.buckets[key]
+	if !ok || !now.Before(bucket.expiresAt) {
+		bucket = &bucketState{tokens: float64(capacity), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	// Refill for the time elapsed since the last request
+	elapsed := now.Sub(bucket.updatedAt)
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(capacity), bucket.tokens+float64(elapsed)*rate)
+	}
+	bucket.updatedAt = now
+
+	result := &LimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - bucket.tokens) / rate))
+	}
+
+	result.Remaining = int(math.Floor(bucket.tokens))
+	result.ResetIn = time.Duration(math.Ceil((float64(capacity) - bucket.tokens) / rate))
+	bucket.expiresAt = now.Add(time.Duration(math.Ceil(float64(capacity) / rate)))
+
+	return result, nil
+}
+
+// SlidingWindow records a request in the window stored at key
+func (s *MemoryStore) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*LimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	state, ok := s.windows[key]
+	if !ok {
+		state = &windowState{}
+		s.windows[key] = state
+	}
+
+	// Drop requests that fell out of the window
+	cutoff := now.Add(-window)
+	kept := state.hits[:0]
+	for _, hit := range state.hits {
+		if hit.After(cutoff) {
+			kept = append(kept, hit)
+		}
+	}
+	state.hits = kept
+
+	result := &LimitResult{}
+	if len(state.hits) < limit {
+		state.hits = append(state.hits, now)
+		result.Allowed = true
+	}
+	state.expiresAt = now.Add(window)
+
+	result.Remaining = limit - len(state.hits)
+	result.ResetIn = window
+	if len(state.hits) > 0 {
+		result.ResetIn = state.hits[0].Add(window).Sub(now)
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.ResetIn
+	}
+
+	return result, nil
+}
+
+// Ping always succeeds for the in-memory store
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the background janitor
+func (s *MemoryStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+// lookup returns a live entry, evicting it if expired. Caller must hold the lock.
+func (s *MemoryStore) lookup(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(now) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// janitor periodically evicts expired keys
+func (s *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.evictExpired(now)
+		}
+	}
+}
+
+// evictExpired removes all expired keys
+func (s *MemoryStore) evictExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+		}
+	}
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, set := range s.sets {
+		if !set.expiresAt.IsZero() && !now.Before(set.expiresAt) {
+			delete(s.sets, key)
+		}
+	}
+	for key, state := range s.windows {
+		if !now.Before(state.expiresAt) {
+			delete(s.windows, key)
+		}
+	}
+}
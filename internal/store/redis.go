@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills the bucket based on elapsed time and takes one token.
+// Returns {allowed, remaining tokens, ms until next token, ms until full}.
+var tokenBucketScript = redis.NewScript(`
+	local key = KEYS[1]
+	local capacity = tonumber(ARGV[1])
+	local rate = tonumber(ARGV[2]) -- tokens per millisecond
+	local now = tonumber(ARGV[3])
+
+	local state = redis.call('HMGET', key, 'tokens', 'ts')
+	local tokens = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if tokens == nil or ts == nil then
+		tokens = capacity
+		ts = now
+	end
+
+	-- Refill for the time elapsed since the last request
+	local elapsed = math.max(0, now - ts)
+	tokens = math.min(capacity, tokens + elapsed * rate)
+
+	local allowed = 0
+	if tokens >= 1 then
+		tokens = tokens - 1
+		allowed = 1
+	end
+
+	redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+	redis.call('PEXPIRE', key, math.ceil(capacity / rate))
+
+	local retry = 0
+	if allowed == 0 then
+		retry = math.ceil((1 - tokens) / rate)
+	end
+	local full = math.ceil((capacity - tokens) / rate)
+
+	return {allowed, math.floor(tokens), retry, full}
+`)
+
+// slidingWindowScript records a request with a unique member in a sorted set
+// and counts members inside the window.
+// Returns {allowed, remaining, ms until the oldest request leaves the window}.
+var slidingWindowScript = redis.NewScript(`
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local limit = tonumber(ARGV[3])
+	local member = ARGV[4]
+
+	-- Remove requests that fell out of the window
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+	local count = redis.call('ZCARD', key)
+	local allowed = 0
+	if count < limit then
+		redis.call('ZADD', key, now, member)
+		count = count + 1
+		allowed = 1
+	end
+	redis.call('PEXPIRE', key, window)
+
+	local reset = window
+	local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+	if oldest[2] then
+		reset = tonumber(oldest[2]) + window - now
+	end
+
+	return {allowed, limit - count, reset}
+`)
+
+// RedisStore implements Store on top of a Redis client
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore creates a new Redis-backed store
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// Client returns the underlying Redis client
+func (s *RedisStore) Client() *redis.Client {
+	return s.rdb
+}
+
+// Get returns the value for key and whether it exists
+func (s *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	val, err := s.rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return val, true, nil
+}
+
+// Set stores a value with an optional expiration
+func (s *RedisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete removes keys
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.rdb.Del(ctx, keys...).Err()
+}
+
+// Incr increments a counter, applying ttl when the counter is created
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	if ttl > 0 {
+		pipe.ExpireNX(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// SetAdd adds member to the set at key, applying ttl when the set is created
+func (s *RedisStore) SetAdd(ctx context.Context, key, member string, ttl time.Duration) (bool, error) {
+	pipe := s.rdb.TxPipeline()
+	add := pipe.SAdd(ctx, key, member)
+	if ttl > 0 {
+		pipe.ExpireNX(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return add.Val() == 1, nil
+}
+
+// Expire updates the expiration of an existing key
+func (s *RedisStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.rdb.Expire(ctx, key, ttl).Err()
+}
+
+// SetNX stores a value only if key does not exist
+func (s *RedisStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
+// sweepBatchSize is the SCAN count hint and the keys checked per round trip
+const sweepBatchSize = 500
+
+// SweepPersistent scans for keys matching pattern and unlinks those without
+// an expiration. The scan is incremental and does not block Redis.
+func (s *RedisStore) SweepPersistent(ctx context.Context, pattern string) (int, error) {
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, pattern, sweepBatchSize).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to scan keys: %w", err)
+		}
+
+		if len(keys) > 0 {
+			pipe := s.rdb.Pipeline()
+			ttls := make([]*redis.DurationCmd, len(keys))
+			for i, key := range keys {
+				ttls[i] = pipe.TTL(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return deleted, fmt.Errorf("failed to check key expirations: %w", err)
+			}
+
+			var persistent []string
+			for i, ttl := range ttls {
+				// -1 means the key exists without an expiration
+				if ttl.Val() == -1 {
+					persistent = append(persistent, keys[i])
+				}
+			}
+			if len(persistent) > 0 {
+				n, err := s.rdb.Unlink(ctx, persistent...).Result()
+				if err != nil {
+					return deleted, fmt.Errorf("failed to delete keys: %w", err)
+				}
+				deleted += int(n)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// TokenBucket takes one token from the bucket stored at key
+func (s *RedisStore) TokenBucket(ctx context.Context, key string, capacity, limit int, window time.Duration) (*LimitResult, error) {
+	rate := float64(limit) / float64(window.Milliseconds())
+
+	values, err := tokenBucketScript.Run(ctx, s.rdb, []string{key},
+		capacity, rate, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("token bucket check failed: %w", err)
+	}
+
+	return &LimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetIn:    time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// SlidingWindow records a request in the window stored at key
+func (s *RedisStore) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*LimitResult, error) {
+	now := time.Now()
+
+	// Every request gets a unique member so concurrent requests are all counted
+	member := fmt.Sprintf("%d-%s", now.UnixMicro(), uuid.NewString())
+
+	values, err := slidingWindowScript.Run(ctx, s.rdb, []string{key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("sliding window check failed: %w", err)
+	}
+
+	reset := time.Duration(values[2]) * time.Millisecond
+	result := &LimitResult{
+		Allowed:   values[0] == 1,
+		Remaining: int(values[1]),
+		ResetIn:   reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+
+	return result, nil
+}
+
+// Ping checks that Redis is reachable
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.rdb.Ping(ctx).Err()
+}
+
+// PoolStats returns statistics of the Redis connection pool
+func (s *RedisStore) PoolStats() *PoolStats {
+	stats := s.rdb.PoolStats()
+	return &PoolStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
+
+// Close closes the Redis client
+func (s *RedisStore) Close() error {
+	return s.rdb.Close()
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnavailable is returned when the store cannot give an authoritative
+// answer, e.g. a key lookup while the primary store is down. Callers should
+// fall back to their source of truth instead of treating it as a miss.
+var ErrUnavailable = errors.New("store unavailable")
+
+// LimitResult is the outcome of an atomic rate limit operation
+type LimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // time until the next request would be allowed
+	ResetIn    time.Duration // time until the full allowance is restored
+}
+
+// Store is the key-value abstraction used for caching, token registries and
+// rate limiting. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value for key and whether it exists
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set stores a value with an optional expiration (0 means no expiration)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Delete removes keys, ignoring keys that do not exist
+	Delete(ctx context.Context, keys ...string) error
+	// Incr increments a counter, applying ttl when the counter is created
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Expire updates the expiration of an existing key
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// SetAdd adds member to the set at key, applying ttl when the set is
+	// created, and reports whether member was not in the set yet
+	SetAdd(ctx context.Context, key, member string, ttl time.Duration) (bool, error)
+	// SetNX stores a value only if key does not exist and reports whether it
+	// was stored. It is the building block of locks and one-off claims.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+
+	// TokenBucket takes one token from a bucket of the given capacity that
+	// refills limit tokens every window
+	TokenBucket(ctx context.Context, key string, capacity, limit int, window time.Duration) (*LimitResult, error)
+	// SlidingWindow records a request if fewer than limit requests were
+	// recorded within the window
+	SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*LimitResult, error)
+
+	// Ping checks that the store is reachable
+	Ping(ctx context.Context) error
+	// Close releases resources held by the store
+	Close() error
+}
+
+// KeySweeper is implemented by stores that can find keys left without an
+// expiration, e.g. by a crash between writing a key and setting its TTL
+type KeySweeper interface {
+	// SweepPersistent deletes the keys matching the glob pattern that have
+	// no expiration and returns how many were deleted
+	SweepPersistent(ctx context.Context, pattern string) (int, error)
+}
+
+// PoolStats describes the connection pool of a network-backed store
+type PoolStats struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	StaleConns uint32 `json:"stale_conns"`
+}
+
+// PoolStatsProvider is implemented by stores backed by a connection pool
+type PoolStatsProvider interface {
+	PoolStats() *PoolStats
+}
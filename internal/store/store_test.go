@@ -0,0 +1,383 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_GetSetExpire(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	if _, found, _ := st.Get(ctx, "missing"); found {
+		t.Error("expected missing key to not be found")
+	}
+
+	st.Set(ctx, "key", "value", 20*time.Millisecond)
+	if val, found, _ := st.Get(ctx, "key"); !found || val != "value" {
+		t.Errorf("expected value, got %q (found=%v)", val, found)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, found, _ := st.Get(ctx, "key"); found {
+		t.Error("expected key to expire")
+	}
+}
+
+func TestMemoryStore_Incr(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		val, err := st.Incr(ctx, "counter", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if val != i {
+			t.Errorf("expected %d, got %d", i, val)
+		}
+	}
+
+	st.Set(ctx, "text", "abc", 0)
+	if _, err := st.Incr(ctx, "text", 0); err == nil {
+		t.Error("expected error incrementing non-integer value")
+	}
+}
+
+func TestMemoryStore_SetAdd(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	if added, _ := st.SetAdd(ctx, "set", "a", 50*time.Millisecond); !added {
+		t.Error("expected new member to be added")
+	}
+	if added, _ := st.SetAdd(ctx, "set", "a", 50*time.Millisecond); added {
+		t.Error("expected existing member not to be added")
+	}
+	if added, _ := st.SetAdd(ctx, "set", "b", 50*time.Millisecond); !added {
+		t.Error("expected second member to be added")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if added, _ := st.SetAdd(ctx, "set", "a", 50*time.Millisecond); !added {
+		t.Error("expected member to be added after the set expired")
+	}
+}
+
+func TestMemoryStore_SetNX(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	if stored, _ := st.SetNX(ctx, "lock", "a", 20*time.Millisecond); !stored {
+		t.Error("expected first claim to succeed")
+	}
+	if stored, _ := st.SetNX(ctx, "lock", "b", 20*time.Millisecond); stored {
+		t.Error("expected second claim to fail while the key exists")
+	}
+	if val, _, _ := st.Get(ctx, "lock"); val != "a" {
+		t.Errorf("expected value a, got %q", val)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if stored, _ := st.SetNX(ctx, "lock", "b", 20*time.Millisecond); !stored {
+		t.Error("expected claim to succeed after the key expired")
+	}
+}
+
+func TestMemoryStore_SweepPersistent(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	st.Set(ctx, "profile:1", "stale", 0)
+	st.Set(ctx, "profile:2", "fresh", time.Minute)
+	st.SetAdd(ctx, "profile:3", "member", 0)
+	st.Set(ctx, "other:1", "kept", 0)
+
+	deleted, err := st.SweepPersistent(ctx, "profile:*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("expected 2 keys swept, got %d", deleted)
+	}
+	if _, found, _ := st.Get(ctx, "profile:1"); found {
+		t.Error("expected persistent key to be swept")
+	}
+	if _, found, _ := st.Get(ctx, "profile:2"); !found {
+		t.Error("expected key with an expiration to be kept")
+	}
+	if _, found, _ := st.Get(ctx, "other:1"); !found {
+		t.Error("expected key outside the pattern to be kept")
+	}
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	// Burst of 3, refilling 60 tokens per minute
+	for i := 0; i < 3; i++ {
+		result, _ := st.TokenBucket(ctx, "bucket", 3, 60, time.Minute)
+		if !result.Allowed {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+
+	result, _ := st.TokenBucket(ctx, "bucket", 3, 60, time.Minute)
+	if result.Allowed {
+		t.Fatal("expected request beyond burst to be rejected")
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > time.Second {
+		t.Errorf("expected retry after about one second, got %v", result.RetryAfter)
+	}
+}
+
+func TestMemoryStore_SlidingWindow(t *testing.T) {
+	st := NewMemoryStore()
+	defer st.Close()
+	ctx := context.Background()
+
+	// Concurrent requests within the same instant are all counted
+	for i := 0; i < 5; i++ {
+		result, _ := st.SlidingWindow(ctx, "window", 5, 50*time.Millisecond)
+		if !result.Allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+		if result.Remaining != 5-(i+1) {
+			t.Errorf("expected remaining %d, got %d", 5-(i+1), result.Remaining)
+		}
+	}
+
+	if result, _ := st.SlidingWindow(ctx, "window", 5, 50*time.Millisecond); result.Allowed {
+		t.Fatal("expected request over limit to be rejected")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if result, _ := st.SlidingWindow(ctx, "window", 5, 50*time.Millisecond); !result.Allowed {
+		t.Error("expected request after window to be allowed")
+	}
+}
+
+// failingStore is a Store whose operations fail while down is set
+type failingStore struct {
+	*MemoryStore
+	down bool
+}
+
+func (s *failingStore) Get(ctx context.Context, key string) (string, bool, error) {
+	if s.down {
+		return "", false, errors.New("connection refused")
+	}
+	return s.MemoryStore.Get(ctx, key)
+}
+
+func (s *failingStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if s.down {
+		return 0, errors.New("connection refused")
+	}
+	return s.MemoryStore.Incr(ctx, key, ttl)
+}
+
+func (s *failingStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if s.down {
+		return errors.New("connection refused")
+	}
+	return s.MemoryStore.Set(ctx, key, value, ttl)
+}
+
+func (s *failingStore) Delete(ctx context.Context, keys ...string) error {
+	if s.down {
+		return errors.New("connection refused")
+	}
+	return s.MemoryStore.Delete(ctx, keys...)
+}
+
+func TestFailoverStore(t *testing.T) {
+	primary := &failingStore{MemoryStore: NewMemoryStore()}
+	fallback := NewMemoryStore()
+	st := NewFailoverStore(primary, fallback, BreakerOptions{FailureThreshold: 2, Cooldown: 20 * time.Millisecond})
+	defer st.Close()
+	ctx := context.Background()
+
+	primary.MemoryStore.Set(ctx, "token", "user-1", 0)
+	if val, found, err := st.Get(ctx, "token"); err != nil || !found || val != "user-1" {
+		t.Fatalf("expected primary value, got %q found=%v err=%v", val, found, err)
+	}
+
+	// Primary goes down: counters keep working on the fallback
+	primary.down = true
+	for i := int64(1); i <= 3; i++ {
+		val, err := st.Incr(ctx, "counter", time.Minute)
+		if err != nil || val != i {
+			t.Fatalf("expected fallback counter %d, got %d (err=%v)", i, val, err)
+		}
+	}
+	if !st.Degraded() {
+		t.Fatal("expected store to be degraded")
+	}
+
+	// Lookups cannot confirm absence while degraded
+	if _, _, err := st.Get(ctx, "token"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+
+	// After the cooldown a probe closes the circuit again
+	primary.down = false
+	time.Sleep(30 * time.Millisecond)
+	if val, found, err := st.Get(ctx, "token"); err != nil || !found || val != "user-1" {
+		t.Fatalf("expected recovered primary value, got %q found=%v err=%v", val, found, err)
+	}
+	if st.Degraded() {
+		t.Error("expected store to recover")
+	}
+}
+
+func TestFailoverStore_ReplaysWritesAfterRecovery(t *testing.T) {
+	primary := &failingStore{MemoryStore: NewMemoryStore()}
+	fallback := NewMemoryStore()
+	st := NewFailoverStore(primary, fallback, BreakerOptions{FailureThreshold: 1, Cooldown: 20 * time.Millisecond})
+	defer st.Close()
+	ctx := context.Background()
+
+	primary.MemoryStore.Set(ctx, "cache:old", "stale", 0)
+
+	// Writes during the outage land on the fallback
+	primary.down = true
+	if err := st.Set(ctx, "refresh_token:new", "user-1", time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := st.Delete(ctx, "cache:old"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found, err := st.Get(ctx, "cache:old"); err != nil || found {
+		t.Errorf("expected key deleted while degraded to be a miss, got found=%v err=%v", found, err)
+	}
+
+	// Once recovered, reads see the outage writes before they are replayed
+	primary.down = false
+	time.Sleep(30 * time.Millisecond)
+	if val, found, err := st.Get(ctx, "refresh_token:new"); err != nil || !found || val != "user-1" {
+		t.Fatalf("expected value written while degraded, got %q found=%v err=%v", val, found, err)
+	}
+	if _, found, _ := st.Get(ctx, "cache:old"); found {
+		t.Error("expected key deleted while degraded to stay deleted")
+	}
+
+	// and the primary catches up
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, tokenFound, _ := primary.MemoryStore.Get(ctx, "refresh_token:new")
+		_, oldFound, _ := primary.MemoryStore.Get(ctx, "cache:old")
+		if tokenFound && !oldFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected writes to be replayed to the primary, token=%v old=%v", tokenFound, oldFound)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A write after recovery is not overwritten by a later replay
+	if err := st.Set(ctx, "refresh_token:new", "user-2", time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, _, _ := primary.MemoryStore.Get(ctx, "refresh_token:new"); val != "user-2" {
+		t.Errorf("expected newest value in primary, got %q", val)
+	}
+}
+
+// slowSetStore is a Store whose Set waits for release once block is set
+type slowSetStore struct {
+	*MemoryStore
+	block   atomic.Bool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *slowSetStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if s.block.Load() {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	return s.MemoryStore.Set(ctx, key, value, ttl)
+}
+
+func TestFailoverStore_SlowReplayDoesNotBlockLookups(t *testing.T) {
+	primary := &slowSetStore{MemoryStore: NewMemoryStore(), started: make(chan struct{}), release: make(chan struct{})}
+	fallback := NewMemoryStore()
+	st := NewFailoverStore(primary, fallback, BreakerOptions{FailureThreshold: 1, Cooldown: time.Hour})
+	defer st.Close()
+	ctx := context.Background()
+
+	st.Trip()
+	if err := st.Set(ctx, "token", "user-1", time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Recovery starts a replay that hangs on the primary
+	primary.block.Store(true)
+	st.Ping(ctx)
+	st.Get(ctx, "other")
+	<-primary.started
+
+	got := make(chan string, 1)
+	go func() {
+		val, _, _ := st.Get(ctx, "token")
+		got <- val
+	}()
+	select {
+	case val := <-got:
+		if val != "user-1" {
+			t.Errorf("expected pending value during replay, got %q", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lookup blocked behind the replay")
+	}
+
+	primary.block.Store(false)
+	close(primary.release)
+}
+
+func TestFailoverStore_OnRecover(t *testing.T) {
+	primary := &failingStore{MemoryStore: NewMemoryStore()}
+	st := NewFailoverStore(primary, NewMemoryStore(), BreakerOptions{FailureThreshold: 1, Cooldown: 20 * time.Millisecond})
+	defer st.Close()
+	ctx := context.Background()
+
+	recovered := make(chan struct{}, 1)
+	st.OnRecover(func(ctx context.Context) {
+		// Counters bumped on the fallback are bumped on the primary again
+		st.Incr(ctx, "generation", 0)
+		recovered <- struct{}{}
+	})
+
+	primary.down = true
+	st.Incr(ctx, "generation", 0)
+	st.Incr(ctx, "generation", 0)
+	select {
+	case <-recovered:
+		t.Fatal("hook ran before the primary recovered")
+	default:
+	}
+
+	primary.down = false
+	time.Sleep(30 * time.Millisecond)
+	st.Get(ctx, "other")
+	select {
+	case <-recovered:
+	case <-time.After(time.Second):
+		t.Fatal("hook did not run after recovery")
+	}
+	if val, _, _ := primary.MemoryStore.Get(ctx, "generation"); val != "1" {
+		t.Errorf("expected generation bumped on the primary, got %q", val)
+	}
+}
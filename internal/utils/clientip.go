@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPResolver determines the originating client IP of a request. Forwarding
+// headers are only honoured when the immediate peer is a trusted proxy, so
+// clients cannot spoof their address by sending X-Forwarded-For themselves.
+type IPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewIPResolver creates a resolver that trusts the given proxy CIDRs or bare IPs
+func NewIPResolver(trustedProxies []string) (*IPResolver, error) {
+	resolver := &IPResolver{}
+
+	for _, entry := range trustedProxies {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Accept bare addresses as single-host networks
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			resolver.trusted = append(resolver.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %s: %w", entry, err)
+		}
+		resolver.trusted = append(resolver.trusted, network)
+	}
+
+	return resolver, nil
+}
+
+// ClientIP returns the client IP for a request
+func (r *IPResolver) ClientIP(req *http.Request) string {
+	peer := PeerIP(req)
+	if peer == nil {
+		return req.RemoteAddr
+	}
+
+	// Headers from untrusted peers are ignored entirely
+	if !r.isTrusted(peer) {
+		return peer.String()
+	}
+
+	// Prefer the standardised Forwarded header, then X-Forwarded-For
+	chain := forwardedChain(req.Header)
+	if len(chain) == 0 {
+		chain = xForwardedForChain(req.Header)
+	}
+
+	if len(chain) == 0 {
+		// X-Real-IP is a single value set by the proxy itself
+		if ip := parseIP(req.Header.Get("X-Real-IP")); ip != nil {
+			return ip.String()
+		}
+		return peer.String()
+	}
+
+	// Walk the chain from the closest hop outwards; the first address that
+	// is not one of our proxies is the client
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseIP(chain[i])
+		if ip == nil {
+			// Unparseable or obfuscated hop, stop at the last known address
+			break
+		}
+		client = ip
+		if !r.isTrusted(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// isTrusted checks if an address belongs to a trusted proxy network
+func (r *IPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerIP returns the address of the immediate peer of a request
+func PeerIP(req *http.Request) net.IP {
+	return parseIP(req.RemoteAddr)
+}
+
+// forwardedChain extracts the "for" parameters of an RFC 7239 Forwarded header
+func forwardedChain(header http.Header) []string {
+	var chain []string
+
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(key, "for") {
+					continue
+				}
+				chain = append(chain, strings.Trim(val, `"`))
+			}
+		}
+	}
+
+	return chain
+}
+
+// xForwardedForChain extracts the hops of the X-Forwarded-For header
+func xForwardedForChain(header http.Header) []string {
+	var chain []string
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				chain = append(chain, hop)
+			}
+		}
+	}
+
+	return chain
+}
+
+// parseIP parses an address that may carry a port, brackets or a zone
+func parseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil
+	}
+
+	// "host:port" and "[v6]:port"
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	// Bare "[v6]" without a port
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+
+	// Drop IPv6 zone identifiers such as "fe80::1%eth0"
+	if idx := strings.Index(addr, "%"); idx != -1 {
+		addr = addr[:idx]
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPResolver_ClientIP(t *testing.T) {
+	resolver, err := NewIPResolver([]string{"10.0.0.0/8", "192.168.1.10"})
+	if err != nil {
+		t.Fatalf("failed to create resolver: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "direct client without headers",
+			remoteAddr: "203.0.113.5:51234",
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "untrusted peer cannot spoof X-Forwarded-For",
+			remoteAddr: "203.0.113.5:51234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "untrusted peer cannot spoof X-Real-IP",
+			remoteAddr: "203.0.113.5:51234",
+			headers:    map[string]string{"X-Real-IP": "1.2.3.4"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "trusted proxy forwards client",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "forged leftmost entry is skipped",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "multiple trusted hops",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.7, 192.168.1.10, 10.1.1.1"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "Forwarded header takes precedence",
+			remoteAddr: "10.0.0.2:8080",
+			headers: map[string]string{
+				"Forwarded":       `for=198.51.100.9;proto=https, for="10.2.2.2"`,
+				"X-Forwarded-For": "198.51.100.7",
+			},
+			expected: "198.51.100.9",
+		},
+		{
+			name:       "Forwarded header with IPv6 and port",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711"`},
+			expected:   "2001:db8:cafe::17",
+		},
+		{
+			name:       "obfuscated Forwarded hop stops the walk",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"Forwarded": "for=_hidden"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			remoteAddr: "10.0.0.2:8080",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "bare IPv6 remote address is not truncated",
+			remoteAddr: "2001:db8::1",
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "bracketed IPv6 remote address with port",
+			remoteAddr: "[2001:db8::1]:443",
+			expected:   "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			if got := resolver.ClientIP(req); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewIPResolver_InvalidEntry(t *testing.T) {
+	if _, err := NewIPResolver([]string{"not-an-ip"}); err == nil {
+		t.Error("expected error for invalid proxy entry")
+	}
+	if _, err := NewIPResolver([]string{"10.0.0.0/99"}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
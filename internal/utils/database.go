@@ -6,11 +6,26 @@ import (
 
 	"gonotes/internal/config"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	_ "modernc.org/sqlite"
 )
 
-// ConnectDB establishes a connection to PostgreSQL database
+// ConnectDB opens the database selected by DB_DRIVER
 func ConnectDB(cfg *config.Config) (*sql.DB, error) {
+	switch cfg.DBDriver {
+	case "postgres":
+		return connectPostgres(cfg)
+	case "sqlite":
+		return connectSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.DBDriver)
+	}
+}
+
+// connectPostgres establishes a connection to PostgreSQL database
+func connectPostgres(cfg *config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost,
@@ -20,9 +35,9 @@ func ConnectDB(cfg *config.Config) (*sql.DB, error) {
 		cfg.DBName,
 	)
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := openPostgres(dsn, cfg.DBName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
 	// Test the connection
@@ -30,9 +45,79 @@ func ConnectDB(cfg *config.Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	return db, nil
+}
+
+// ConnectReplicas opens the read replicas in DB_REPLICA_DSNS. Connections are
+// lazy: an unreachable replica is left out of rotation by its health check
+// rather than failing startup.
+func ConnectReplicas(cfg *config.Config) ([]*sql.DB, error) {
+	if cfg.DBDriver != "postgres" && len(cfg.DBReplicaDSNs) > 0 {
+		return nil, fmt.Errorf("read replicas require the postgres driver")
+	}
+
+	var replicas []*sql.DB
+	for i, dsn := range cfg.DBReplicaDSNs {
+		db, err := openPostgres(dsn, cfg.DBName)
+		if err != nil {
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
+// openPostgres opens a traced PostgreSQL connection pool
+func openPostgres(dsn, name string) (*sql.DB, error) {
+	// Every statement gets a span as a child of the caller's context
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBName(name)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			DisableErrSkip:       true,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
 	// Set connection pool settings
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 
 	return db, nil
 }
+
+// connectSQLite opens the SQLite database file at SQLITE_PATH, creating it if needed
+func connectSQLite(cfg *config.Config) (*sql.DB, error) {
+	// Foreign keys are off by default in SQLite; timestamps are stored as
+	// sortable text so they compare correctly in queries
+	dsn := "file:" + cfg.SQLitePath +
+		"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+
+	db, err := otelsql.Open("sqlite", dsn,
+		otelsql.WithAttributes(semconv.DBSystemSqlite, semconv.DBName(cfg.SQLitePath)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			DisableErrSkip:       true,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database %s: %w", cfg.SQLitePath, err)
+	}
+
+	// SQLite allows a single writer; one connection avoids lock contention
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -3,148 +3,182 @@ package utils
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"gonotes/internal/config"
+	"gonotes/internal/metrics"
+	"gonotes/internal/queue"
+	"gonotes/internal/store"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 // ConnectRedis establishes a connection to Redis
 func ConnectRedis(cfg *config.Config) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
-		Password:     cfg.RedisPassword, // Use password from config
-		DB:           0,                 // Default DB
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
-		PoolSize:     10,
-		MinIdleConns: 5,
-	})
+	rdb := newRedisClient(cfg)
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
 	return rdb, nil
 }
 
-// SetSession stores session data in Redis
-func SetSession(rdb *redis.Client, key string, value interface{}, expiration time.Duration) error {
+// ConnectStore creates the key-value store selected by STORE_BACKEND. The
+// Redis backend is wrapped in a circuit breaker that degrades to an in-memory
+// store; unless REDIS_REQUIRED is set, an unreachable Redis at startup starts
+// the application in degraded mode instead of failing.
+func ConnectStore(cfg *config.Config) (store.Store, error) {
+	switch cfg.StoreBackend {
+	case "memory":
+		return store.NewMemoryStore(), nil
+	case "redis":
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", cfg.StoreBackend)
+	}
+
+	rdb := newRedisClient(cfg)
+	failover := store.NewFailoverStore(store.NewRedisStore(rdb), store.NewMemoryStore(), store.DefaultBreakerOptions())
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return rdb.Set(ctx, key, value, expiration).Err()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		if cfg.RedisRequired {
+			failover.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		slog.Warn("Redis unavailable, starting with in-memory fallback", "error", err)
+		failover.Trip()
+	}
+
+	return failover, nil
 }
 
-// GetSession retrieves session data from Redis
-func GetSession(rdb *redis.Client, key string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// ConnectQueue creates the job queue broker for STORE_BACKEND. The Redis
+// broker has its own client, so workers blocked on the stream do not hold
+// connections of the key-value store. Redis is not contacted until a job
+// handler is registered and the queue runs, or the admin endpoints are used;
+// jobs are not queued in memory, where they would be lost.
+func ConnectQueue(cfg *config.Config) (queue.Broker, error) {
+	switch cfg.StoreBackend {
+	case "memory":
+		return queue.NewMemoryBroker(), nil
+	case "redis":
+		return queue.NewRedisBroker(newRedisClient(cfg)), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", cfg.StoreBackend)
+	}
+}
 
-	val, err := rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", nil // Key does not exist
+// newRedisClient creates a Redis client from configuration
+func newRedisClient(cfg *config.Config) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password:     cfg.RedisPassword, // Use password from config
+		DB:           0,                 // Default DB
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  cfg.RedisTimeout,
+		WriteTimeout: cfg.RedisTimeout,
+		PoolSize:     10,
+		MinIdleConns: 5,
+	})
+	rdb.AddHook(metrics.RedisHook{})
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		slog.Warn("Failed to instrument Redis tracing", "error", err)
 	}
+	return rdb
+}
+
+// SetSession stores session data in the store
+func SetSession(ctx context.Context, st store.Store, key string, value string, expiration time.Duration) error {
+	return st.Set(ctx, key, value, expiration)
+}
+
+// GetSession retrieves session data from the store
+func GetSession(ctx context.Context, st store.Store, key string) (string, error) {
+	val, found, err := st.Get(ctx, key)
 	if err != nil {
 		return "", fmt.Errorf("failed to get session: %w", err)
 	}
+	if !found {
+		return "", nil // Key does not exist
+	}
 
 	return val, nil
 }
 
-// DeleteSession removes session data from Redis
-func DeleteSession(rdb *redis.Client, key string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	return rdb.Del(ctx, key).Err()
+// DeleteSession removes session data from the store
+func DeleteSession(ctx context.Context, st store.Store, key string) error {
+	return st.Delete(ctx, key)
 }
 
-// SessionExists checks if a session exists in Redis
-func SessionExists(rdb *redis.Client, key string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	count, err := rdb.Exists(ctx, key).Result()
+// SessionExists checks if a session exists in the store
+func SessionExists(ctx context.Context, st store.Store, key string) (bool, error) {
+	_, found, err := st.Get(ctx, key)
 	if err != nil {
 		return false, fmt.Errorf("failed to check session existence: %w", err)
 	}
 
-	return count > 0, nil
+	return found, nil
 }
 
 // SetRefreshToken stores refresh token with user ID mapping
-func SetRefreshToken(rdb *redis.Client, tokenID, userID string, expiration time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+func SetRefreshToken(ctx context.Context, st store.Store, tokenID, userID string, expiration time.Duration) error {
 	key := fmt.Sprintf("refresh_token:%s", tokenID)
-	return rdb.Set(ctx, key, userID, expiration).Err()
+	return st.Set(ctx, key, userID, expiration)
 }
 
-// GetUserIDFromRefreshToken retrieves user ID from refresh token
-func GetUserIDFromRefreshToken(rdb *redis.Client, tokenID string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+// GetUserIDFromRefreshToken retrieves user ID from refresh token. The error
+// wraps store.ErrUnavailable when the registry cannot confirm the token.
+func GetUserIDFromRefreshToken(ctx context.Context, st store.Store, tokenID string) (string, error) {
 	key := fmt.Sprintf("refresh_token:%s", tokenID)
-	val, err := rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", nil // Token does not exist or expired
-	}
+	val, found, err := st.Get(ctx, key)
 	if err != nil {
 		return "", fmt.Errorf("failed to get user ID from refresh token: %w", err)
 	}
+	if !found {
+		return "", nil // Token does not exist or expired
+	}
 
 	return val, nil
 }
 
-// InvalidateRefreshToken removes refresh token from Redis
-func InvalidateRefreshToken(rdb *redis.Client, tokenID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+// InvalidateRefreshToken removes refresh token from the store
+func InvalidateRefreshToken(ctx context.Context, st store.Store, tokenID string) error {
 	key := fmt.Sprintf("refresh_token:%s", tokenID)
-	return rdb.Del(ctx, key).Err()
+	return st.Delete(ctx, key)
 }
 
-// SetProfileCache stores user profile in Redis cache
-func SetProfileCache(rdb *redis.Client, userID string, profileData interface{}, expiration time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+// SetProfileCache stores user profile in the cache
+func SetProfileCache(ctx context.Context, st store.Store, userID string, profileData []byte, expiration time.Duration) error {
 	key := fmt.Sprintf("profile:%s", userID)
-	return rdb.Set(ctx, key, profileData, expiration).Err()
+	return st.Set(ctx, key, string(profileData), expiration)
 }
 
-// GetProfileCache retrieves user profile from Redis cache
-func GetProfileCache(rdb *redis.Client, userID string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+// GetProfileCache retrieves user profile from the cache
+func GetProfileCache(ctx context.Context, st store.Store, userID string) (string, error) {
 	key := fmt.Sprintf("profile:%s", userID)
-	val, err := rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", nil // Profile not cached
-	}
+	val, found, err := st.Get(ctx, key)
 	if err != nil {
 		return "", fmt.Errorf("failed to get profile from cache: %w", err)
 	}
+	if !found {
+		return "", nil // Profile not cached
+	}
 
 	return val, nil
 }
 
-// InvalidateProfileCache removes user profile from Redis cache
-func InvalidateProfileCache(rdb *redis.Client, userID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+// InvalidateProfileCache removes user profile from the cache
+func InvalidateProfileCache(ctx context.Context, st store.Store, userID string) error {
 	key := fmt.Sprintf("profile:%s", userID)
-	return rdb.Del(ctx, key).Err()
+	return st.Delete(ctx, key)
 }
@@ -0,0 +1,17 @@
+-- Create outbox table for side effects applied after commit
+CREATE TABLE outbox (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  processed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The relay polls for pending messages that are due
+CREATE INDEX idx_outbox_pending ON outbox(next_attempt_at) WHERE processed_at IS NULL;
+CREATE INDEX idx_outbox_processed_at ON outbox(processed_at) WHERE processed_at IS NOT NULL;
+
+COMMENT ON TABLE outbox IS 'Transactional outbox: side effects recorded with a change and delivered after commit';
@@ -0,0 +1,2 @@
+-- Drop note view stats table
+DROP TABLE IF EXISTS note_view_stats;
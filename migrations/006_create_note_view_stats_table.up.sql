@@ -0,0 +1,15 @@
+-- Create daily view rollup per note and referrer domain ('' for direct views)
+CREATE TABLE note_view_stats (
+  note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+  day DATE NOT NULL,
+  referrer TEXT NOT NULL DEFAULT '',
+  views BIGINT NOT NULL DEFAULT 0,
+  unique_viewers BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (note_id, day, referrer)
+);
+
+-- Analytics read the recent days of a note or of all of a user's notes
+CREATE INDEX idx_note_view_stats_day ON note_view_stats(day);
+
+COMMENT ON TABLE note_view_stats IS 'Views per note, day and referrer domain, written in batches by the view counter';
+COMMENT ON COLUMN note_view_stats.unique_viewers IS 'Viewers whose first view of the note that day came from this referrer';
@@ -0,0 +1,2 @@
+-- Drop share links table
+DROP TABLE IF EXISTS share_links;
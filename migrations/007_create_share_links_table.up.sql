@@ -0,0 +1,21 @@
+-- Create share links giving unauthenticated access to a note
+CREATE TABLE share_links (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  slug TEXT NOT NULL UNIQUE,
+  password_hash TEXT,
+  expires_at TIMESTAMP WITH TIME ZONE,
+  max_views BIGINT CHECK (max_views > 0),
+  view_count BIGINT NOT NULL DEFAULT 0,
+  last_viewed_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Owners list the links of a note
+CREATE INDEX idx_share_links_note_id ON share_links(note_id);
+
+COMMENT ON TABLE share_links IS 'Links serving a note at /s/{slug} without authentication';
+COMMENT ON COLUMN share_links.password_hash IS 'bcrypt hash; NULL when the link has no password';
+COMMENT ON COLUMN share_links.max_views IS 'Views after which the link stops working; NULL for no limit';
@@ -0,0 +1,2 @@
+-- Remove feed opt-out from users
+ALTER TABLE users DROP COLUMN IF EXISTS feed_opt_out;
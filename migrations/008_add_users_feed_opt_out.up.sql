@@ -0,0 +1,4 @@
+-- Let users hide their public notes feed
+ALTER TABLE users ADD COLUMN feed_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN users.feed_opt_out IS 'Hides the per-user feed at /feeds/users/{id}; public notes stay in other feeds';
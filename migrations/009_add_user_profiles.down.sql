@@ -0,0 +1,7 @@
+-- Remove public profiles from users
+DROP TABLE IF EXISTS handle_redirects;
+DROP INDEX IF EXISTS idx_users_handle;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_public;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+ALTER TABLE users DROP COLUMN IF EXISTS handle;
@@ -0,0 +1,20 @@
+-- Add opt-in public profiles to users
+ALTER TABLE users ADD COLUMN handle TEXT;
+ALTER TABLE users ADD COLUMN bio TEXT;
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+ALTER TABLE users ADD COLUMN profile_public BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX idx_users_handle ON users(handle);
+
+-- Old handles keep resolving to their user after a change
+CREATE TABLE handle_redirects (
+  handle TEXT PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_handle_redirects_user_id ON handle_redirects(user_id);
+
+COMMENT ON COLUMN users.handle IS 'Lowercase name of the public profile at /api/v1/users/{handle}; NULL until chosen';
+COMMENT ON COLUMN users.profile_public IS 'Shows the profile and the handle on public notes';
+COMMENT ON TABLE handle_redirects IS 'Previous handles of users, redirected to the current one and reserved for their former owner';
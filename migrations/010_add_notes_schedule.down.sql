@@ -0,0 +1,6 @@
+-- Remove publishing schedule from notes
+DROP INDEX IF EXISTS idx_notes_unpublish_at;
+DROP INDEX IF EXISTS idx_notes_publish_at;
+ALTER TABLE notes
+DROP COLUMN IF EXISTS unpublish_at,
+DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,11 @@
+-- Let notes be published and unpublished at a set time
+ALTER TABLE notes
+ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN unpublish_at TIMESTAMP WITH TIME ZONE;
+
+-- The scheduler looks up due notes on every tick
+CREATE INDEX idx_notes_publish_at ON notes(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX idx_notes_unpublish_at ON notes(unpublish_at) WHERE unpublish_at IS NOT NULL;
+
+COMMENT ON COLUMN notes.publish_at IS 'When the note becomes public, activating drafts; cleared once applied';
+COMMENT ON COLUMN notes.unpublish_at IS 'When the note stops being public; cleared once applied';
@@ -0,0 +1,3 @@
+-- Remove trash retention override from users
+DROP INDEX IF EXISTS idx_notes_trash;
+ALTER TABLE users DROP COLUMN IF EXISTS trash_retention_days;
@@ -0,0 +1,7 @@
+-- Let users choose how long deleted notes stay in the trash
+ALTER TABLE users ADD COLUMN trash_retention_days INTEGER CHECK (trash_retention_days > 0);
+
+-- The purge job scans deleted notes by age
+CREATE INDEX idx_notes_trash ON notes(deleted_at) WHERE status = 'deleted';
+
+COMMENT ON COLUMN users.trash_retention_days IS 'Days deleted notes are kept before being purged; NULL uses TRASH_RETENTION_DAYS';
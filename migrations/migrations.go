@@ -0,0 +1,18 @@
+// Package migrations embeds the schema migrations into the binary
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds the numbered up/down SQL files for PostgreSQL, e.g. 001_create_users_table.up.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS holds the SQLite migrations, numbered independently of FS
+var SQLiteFS, _ = fs.Sub(sqliteFiles, "sqlite")
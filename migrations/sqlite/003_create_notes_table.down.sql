@@ -0,0 +1,2 @@
+-- Drop notes table
+DROP TABLE IF EXISTS notes;
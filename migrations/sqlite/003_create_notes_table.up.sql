@@ -0,0 +1,28 @@
+-- Create notes table. seq is a stable rowid for the full-text index: VACUUM
+-- may renumber the implicit rowid of tables without an INTEGER PRIMARY KEY.
+CREATE TABLE notes (
+  seq INTEGER PRIMARY KEY,
+  id TEXT UNIQUE NOT NULL,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  content TEXT,
+  status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'draft', 'deleted')),
+  tags TEXT,
+  is_public BOOLEAN NOT NULL DEFAULT FALSE,
+  view_count INTEGER NOT NULL DEFAULT 0 CHECK (view_count >= 0),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP,
+
+  -- Deleted notes have a deleted_at timestamp, others do not
+  CHECK (
+    (status = 'deleted' AND deleted_at IS NOT NULL) OR
+    (status != 'deleted' AND deleted_at IS NULL)
+  )
+);
+
+-- Create indexes for common query patterns
+CREATE INDEX idx_notes_user_status ON notes(user_id, status);
+CREATE INDEX idx_notes_user_updated ON notes(user_id, updated_at DESC);
+CREATE INDEX idx_notes_public_active ON notes(is_public, status) WHERE is_public = TRUE AND status = 'active';
+CREATE INDEX idx_notes_deleted_at ON notes(deleted_at) WHERE deleted_at IS NOT NULL;
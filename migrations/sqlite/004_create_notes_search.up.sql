@@ -0,0 +1,26 @@
+-- Full-text index over title and content, the SQLite counterpart of the
+-- to_tsvector('english', ...) indexes. The porter tokenizer stems English words.
+CREATE VIRTUAL TABLE notes_fts USING fts5(
+  title,
+  content,
+  content = 'notes',
+  content_rowid = 'seq',
+  tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+-- Keep the index in sync with the notes table
+CREATE TRIGGER notes_fts_insert AFTER INSERT ON notes BEGIN
+  INSERT INTO notes_fts (rowid, title, content) VALUES (new.seq, new.title, new.content);
+END;
+
+CREATE TRIGGER notes_fts_delete AFTER DELETE ON notes BEGIN
+  INSERT INTO notes_fts (notes_fts, rowid, title, content) VALUES ('delete', old.seq, old.title, old.content);
+END;
+
+CREATE TRIGGER notes_fts_update AFTER UPDATE OF title, content ON notes BEGIN
+  INSERT INTO notes_fts (notes_fts, rowid, title, content) VALUES ('delete', old.seq, old.title, old.content);
+  INSERT INTO notes_fts (rowid, title, content) VALUES (new.seq, new.title, new.content);
+END;
+
+-- Index notes created before this migration
+INSERT INTO notes_fts (notes_fts) VALUES ('rebuild');
@@ -0,0 +1,12 @@
+-- Create daily view rollup per note and referrer domain ('' for direct views)
+CREATE TABLE note_view_stats (
+  note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+  day TEXT NOT NULL, -- YYYY-MM-DD
+  referrer TEXT NOT NULL DEFAULT '',
+  views INTEGER NOT NULL DEFAULT 0,
+  unique_viewers INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (note_id, day, referrer)
+);
+
+-- Analytics read the recent days of a note or of all of a user's notes
+CREATE INDEX idx_note_view_stats_day ON note_view_stats(day);
@@ -0,0 +1,17 @@
+-- Create share links giving unauthenticated access to a note
+CREATE TABLE share_links (
+  id TEXT PRIMARY KEY,
+  note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  slug TEXT NOT NULL UNIQUE,
+  password_hash TEXT,
+  expires_at TIMESTAMP,
+  max_views INTEGER CHECK (max_views > 0),
+  view_count INTEGER NOT NULL DEFAULT 0,
+  last_viewed_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Owners list the links of a note
+CREATE INDEX idx_share_links_note_id ON share_links(note_id);
@@ -0,0 +1,2 @@
+-- Let users hide their public notes feed
+ALTER TABLE users ADD COLUMN feed_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
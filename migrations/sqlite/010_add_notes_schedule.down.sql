@@ -0,0 +1,5 @@
+-- Remove publishing schedule from notes
+DROP INDEX IF EXISTS idx_notes_unpublish_at;
+DROP INDEX IF EXISTS idx_notes_publish_at;
+ALTER TABLE notes DROP COLUMN unpublish_at;
+ALTER TABLE notes DROP COLUMN publish_at;
@@ -0,0 +1,7 @@
+-- Let notes be published and unpublished at a set time
+ALTER TABLE notes ADD COLUMN publish_at TIMESTAMP;
+ALTER TABLE notes ADD COLUMN unpublish_at TIMESTAMP;
+
+-- The scheduler looks up due notes on every tick
+CREATE INDEX idx_notes_publish_at ON notes(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX idx_notes_unpublish_at ON notes(unpublish_at) WHERE unpublish_at IS NOT NULL;
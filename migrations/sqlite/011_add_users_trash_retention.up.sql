@@ -0,0 +1,5 @@
+-- Let users choose how long deleted notes stay in the trash
+ALTER TABLE users ADD COLUMN trash_retention_days INTEGER CHECK (trash_retention_days > 0);
+
+-- The purge job scans deleted notes by age
+CREATE INDEX idx_notes_trash ON notes(deleted_at) WHERE status = 'deleted';